@@ -0,0 +1,91 @@
+package avroint
+
+import (
+	"io"
+	"math"
+
+	"flux/encoding/leb128"
+	"flux/encoding/varint"
+	"flux/encoding/zigzag"
+)
+
+// Avro writes both int and long as a zigzag-mapped value in unsigned LEB128,
+// so small magnitudes of either sign take a single byte. The two types share
+// the wire form; an int only differs in having to fit in 32 bits
+
+// MaxLongLen and MaxIntLen are the maximum encoded lengths of a long and an
+// int
+const (
+	MaxLongLen = leb128.MaxLen
+	MaxIntLen  = 5
+)
+
+// ErrOutOfRange is reported when a value read as an int does not fit in 32
+// bits. It is varint.ErrOutOfRange itself
+var ErrOutOfRange = varint.ErrOutOfRange
+
+// AppendLong encodes v as an Avro long and appends it to dst
+func AppendLong(dst []byte, v int64) []byte {
+	return leb128.Append(dst, zigzag.Encode(v))
+}
+
+// ParseLong reads an Avro long from the start of b and returns it with the
+// number of bytes consumed. Errors are as for leb128.Parse
+func ParseLong(b []byte) (int64, int, error) {
+	u, n, err := leb128.Parse(b)
+	if err != nil {
+		return 0, 0, err
+	}
+	return zigzag.Decode(u), n, nil
+}
+
+// AppendInt encodes v as an Avro int and appends it to dst
+func AppendInt(dst []byte, v int32) []byte {
+	return leb128.Append(dst, uint64(zigzag.Encode32(v)))
+}
+
+// ParseInt reads an Avro int from the start of b and returns it with the
+// number of bytes consumed. A value outside the int32 range fails with
+// ErrOutOfRange; other errors are as for leb128.Parse
+func ParseInt(b []byte) (int32, int, error) {
+	u, n, err := leb128.Parse(b)
+	if err != nil {
+		return 0, 0, err
+	}
+	if u > math.MaxUint32 {
+		return 0, 0, ErrOutOfRange
+	}
+	return zigzag.Decode32(uint32(u)), n, nil
+}
+
+// ReadLong reads an Avro long from r, with the errors of leb128.Read
+func ReadLong(r io.ByteReader) (int64, error) {
+	u, err := leb128.Read(r)
+	if err != nil {
+		return 0, err
+	}
+	return zigzag.Decode(u), nil
+}
+
+// ReadInt reads an Avro int from r, failing with ErrOutOfRange for a value
+// outside the int32 range
+func ReadInt(r io.ByteReader) (int32, error) {
+	u, err := leb128.Read(r)
+	if err != nil {
+		return 0, err
+	}
+	if u > math.MaxUint32 {
+		return 0, ErrOutOfRange
+	}
+	return zigzag.Decode32(uint32(u)), nil
+}
+
+// WriteLong encodes v as an Avro long and writes it to w
+func WriteLong(w io.ByteWriter, v int64) error {
+	return leb128.Write(w, zigzag.Encode(v))
+}
+
+// WriteInt encodes v as an Avro int and writes it to w
+func WriteInt(w io.ByteWriter, v int32) error {
+	return leb128.Write(w, uint64(zigzag.Encode32(v)))
+}
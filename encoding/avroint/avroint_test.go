@@ -0,0 +1,147 @@
+package avroint
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"testing"
+
+	"flux/encoding/leb128"
+	"flux/encoding/varint"
+)
+
+// -------------------------
+// AppendLong / ParseLong
+// -------------------------
+
+func TestSpecVectors(t *testing.T) {
+	// The examples from the Avro specification's section on binary encoding
+	cases := []struct {
+		v    int64
+		want []byte
+	}{
+		{0, []byte{0x00}},
+		{-1, []byte{0x01}},
+		{1, []byte{0x02}},
+		{-2, []byte{0x03}},
+		{2, []byte{0x04}},
+		{-64, []byte{0x7f}},
+		{64, []byte{0x80, 0x01}},
+	}
+	for _, c := range cases {
+		if got := AppendLong(nil, c.v); !bytes.Equal(got, c.want) {
+			t.Fatalf("AppendLong(%d) = %x, want %x", c.v, got, c.want)
+		}
+		if got := AppendInt(nil, int32(c.v)); !bytes.Equal(got, c.want) {
+			t.Fatalf("AppendInt(%d) = %x, want %x", c.v, got, c.want)
+		}
+		if v, n, err := ParseLong(c.want); v != c.v || n != len(c.want) || err != nil {
+			t.Fatalf("ParseLong(%x) = %d, %d, %v; want %d", c.want, v, n, err, c.v)
+		}
+		if v, n, err := ParseInt(c.want); int64(v) != c.v || n != len(c.want) || err != nil {
+			t.Fatalf("ParseInt(%x) = %d, %d, %v; want %d", c.want, v, n, err, c.v)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	for _, v := range []int64{math.MinInt64, math.MaxInt64} {
+		b := AppendLong(nil, v)
+		if len(b) != MaxLongLen {
+			t.Fatalf("AppendLong(%d) took %d bytes, want %d", v, len(b), MaxLongLen)
+		}
+		if got, _, err := ParseLong(b); got != v || err != nil {
+			t.Fatalf("ParseLong(%x) = %d, %v; want %d", b, got, err, v)
+		}
+	}
+	for _, v := range []int32{math.MinInt32, math.MaxInt32} {
+		b := AppendInt(nil, v)
+		if len(b) != MaxIntLen {
+			t.Fatalf("AppendInt(%d) took %d bytes, want %d", v, len(b), MaxIntLen)
+		}
+		if got, _, err := ParseInt(b); got != v || err != nil {
+			t.Fatalf("ParseInt(%x) = %d, %v; want %d", b, got, err, v)
+		}
+	}
+
+	// A long just outside the int32 range is rejected as an int
+	for _, v := range []int64{math.MaxInt32 + 1, math.MinInt32 - 1} {
+		b := AppendLong(nil, v)
+		if _, _, err := ParseInt(b); !errors.Is(err, varint.ErrOutOfRange) {
+			t.Fatalf("ParseInt(%x) error = %v, want ErrOutOfRange", b, err)
+		}
+		if _, err := ReadInt(bytes.NewReader(b)); err != ErrOutOfRange {
+			t.Fatalf("ReadInt(%x) error = %v, want ErrOutOfRange", b, err)
+		}
+	}
+
+	if _, _, err := ParseLong(bytes.Repeat([]byte{0xff}, 11)); err != leb128.ErrOverflow {
+		t.Fatalf("ParseLong(11 bytes) error = %v, want leb128.ErrOverflow", err)
+	}
+	if _, err := ReadLong(bytes.NewReader([]byte{0x80})); err != io.ErrUnexpectedEOF {
+		t.Fatalf("ReadLong(truncated) error = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func FuzzLongRoundTrip(f *testing.F) {
+	f.Add(int64(0))
+	f.Add(int64(-64))
+	f.Add(int64(math.MinInt64))
+	f.Add(int64(math.MaxInt64))
+	f.Fuzz(func(t *testing.T, v int64) {
+		b := AppendLong(nil, v)
+		if got, n, err := ParseLong(b); got != v || n != len(b) || err != nil {
+			t.Fatalf("ParseLong(AppendLong(%d)) = %d, %d, %v", v, got, n, err)
+		}
+		if got, err := ReadLong(bytes.NewReader(b)); got != v || err != nil {
+			t.Fatalf("ReadLong(AppendLong(%d)) = %d, %v", v, got, err)
+		}
+		got, _, err := ParseInt(b)
+		switch {
+		case v >= math.MinInt32 && v <= math.MaxInt32:
+			if int64(got) != v || err != nil || !bytes.Equal(AppendInt(nil, int32(v)), b) {
+				t.Fatalf("ParseInt(AppendLong(%d)) = %d, %v", v, got, err)
+			}
+		case err != ErrOutOfRange:
+			t.Fatalf("ParseInt(AppendLong(%d)) error = %v, want ErrOutOfRange", v, err)
+		}
+	})
+}
+
+// -------------------------
+// Streams
+// -------------------------
+
+func TestReadWriteStream(t *testing.T) {
+	longs := []int64{0, -1, 1, -64, 64, math.MinInt64, math.MaxInt64, 1 << 40}
+	ints := []int32{0, -1, math.MinInt32, math.MaxInt32, 300}
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	for _, v := range longs {
+		if err := WriteLong(bw, v); err != nil {
+			t.Fatalf("WriteLong(%d) error = %v", v, err)
+		}
+	}
+	for _, v := range ints {
+		if err := WriteInt(bw, v); err != nil {
+			t.Fatalf("WriteInt(%d) error = %v", v, err)
+		}
+	}
+	bw.Flush()
+	r := bufio.NewReader(&buf)
+	for _, want := range longs {
+		if v, err := ReadLong(r); v != want || err != nil {
+			t.Fatalf("ReadLong = %d, %v; want %d", v, err, want)
+		}
+	}
+	for _, want := range ints {
+		if v, err := ReadInt(r); v != want || err != nil {
+			t.Fatalf("ReadInt = %d, %v; want %d", v, err, want)
+		}
+	}
+	if _, err := ReadLong(r); err != io.EOF {
+		t.Fatalf("ReadLong at end error = %v, want io.EOF", err)
+	}
+}
@@ -0,0 +1,194 @@
+package cborint
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+
+	"flux/encoding/varint"
+)
+
+// Every CBOR data item (RFC 8949 section 3) starts with a head: a major type
+// in the top 3 bits of the initial byte and an argument below it. An
+// argument under 24 sits in those 5 bits; 24, 25, 26 and 27 announce a
+// big-endian argument of 1, 2, 4 or 8 bytes that follows. Unsigned integers
+// are major type 0 with the value as argument, and negative integers major
+// type 1 with -1-value, which reaches down to -2^64. Deterministic encoding
+// (section 4.2.1) requires the shortest head
+
+// Major types of the integers
+const (
+	MajorUnsigned = 0
+	MajorNegative = 1
+)
+
+// MaxLen is the maximum encoded length of a head
+const MaxLen = 9
+
+// ErrNonCanonical is reported by the strict parsers when a head is longer
+// than needed. It is varint.ErrNonCanonical itself
+var ErrNonCanonical = varint.ErrNonCanonical
+
+// ErrOutOfRange is reported when a negative integer is below math.MinInt64
+// or an unsigned one above math.MaxInt64 is read as an int64. It is
+// varint.ErrOutOfRange itself
+var ErrOutOfRange = varint.ErrOutOfRange
+
+// ErrNotInteger is reported when an integer parser meets a head of another
+// major type
+var ErrNotInteger = errors.New("cborint: data item is not an integer")
+
+// ErrAdditionalInfo is reported for the additional information values 28 to
+// 31, which are reserved or mark indefinite lengths, neither of which an
+// integer can have
+var ErrAdditionalInfo = errors.New("cborint: invalid additional information")
+
+// Len returns the length of the shortest head with argument arg
+func Len(arg uint64) int {
+	switch {
+	case arg < 24:
+		return 1
+	case arg <= math.MaxUint8:
+		return 2
+	case arg <= math.MaxUint16:
+		return 3
+	case arg <= math.MaxUint32:
+		return 5
+	default:
+		return 9
+	}
+}
+
+// AppendHead appends the shortest head of major type major, taken from its
+// low 3 bits, and argument arg to dst
+func AppendHead(dst []byte, major byte, arg uint64) []byte {
+	m := major << 5
+	switch Len(arg) {
+	case 1:
+		return append(dst, m|byte(arg))
+	case 2:
+		return append(dst, m|24, byte(arg))
+	case 3:
+		return binary.BigEndian.AppendUint16(append(dst, m|25), uint16(arg))
+	case 5:
+		return binary.BigEndian.AppendUint32(append(dst, m|26), uint32(arg))
+	default:
+		return binary.BigEndian.AppendUint64(append(dst, m|27), arg)
+	}
+}
+
+// ParseHead reads a head from the start of b and returns its major type and
+// argument with the number of bytes consumed. It returns io.EOF for an empty
+// b, io.ErrUnexpectedEOF if b ends inside the head and ErrAdditionalInfo for
+// an indefinite or reserved argument. Heads longer than needed are accepted
+func ParseHead(b []byte) (major byte, arg uint64, n int, err error) {
+	if len(b) == 0 {
+		return 0, 0, 0, io.EOF
+	}
+	major, info := b[0]>>5, b[0]&0x1f
+	switch {
+	case info < 24:
+		return major, uint64(info), 1, nil
+	case info > 27:
+		return 0, 0, 0, ErrAdditionalInfo
+	}
+	n = 1 + 1<<(info-24)
+	if len(b) < n {
+		return 0, 0, 0, io.ErrUnexpectedEOF
+	}
+	switch n {
+	case 2:
+		arg = uint64(b[1])
+	case 3:
+		arg = uint64(binary.BigEndian.Uint16(b[1:]))
+	case 5:
+		arg = uint64(binary.BigEndian.Uint32(b[1:]))
+	default:
+		arg = binary.BigEndian.Uint64(b[1:])
+	}
+	return major, arg, n, nil
+}
+
+// ParseHeadCanonical is like ParseHead but rejects heads longer than needed
+// with ErrNonCanonical
+func ParseHeadCanonical(b []byte) (major byte, arg uint64, n int, err error) {
+	major, arg, n, err = ParseHead(b)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if Len(arg) != n {
+		return 0, 0, 0, ErrNonCanonical
+	}
+	return major, arg, n, nil
+}
+
+// AppendUint appends v as a CBOR unsigned integer in its shortest form
+func AppendUint(dst []byte, v uint64) []byte {
+	return AppendHead(dst, MajorUnsigned, v)
+}
+
+// AppendInt appends v as a CBOR unsigned or negative integer in its shortest
+// form
+func AppendInt(dst []byte, v int64) []byte {
+	if v < 0 {
+		return AppendHead(dst, MajorNegative, uint64(-1-v))
+	}
+	return AppendHead(dst, MajorUnsigned, uint64(v))
+}
+
+// ParseUint reads a CBOR unsigned integer from the start of b and returns it
+// with the number of bytes consumed. A data item of any other major type,
+// negative integers included, fails with ErrNotInteger; other errors are as
+// for ParseHead
+func ParseUint(b []byte) (uint64, int, error) {
+	return parseUint(b, ParseHead)
+}
+
+// ParseUintCanonical is like ParseUint but rejects heads longer than needed
+// with ErrNonCanonical
+func ParseUintCanonical(b []byte) (uint64, int, error) {
+	return parseUint(b, ParseHeadCanonical)
+}
+
+func parseUint(b []byte, head func([]byte) (byte, uint64, int, error)) (uint64, int, error) {
+	major, arg, n, err := head(b)
+	if err != nil {
+		return 0, 0, err
+	}
+	if major != MajorUnsigned {
+		return 0, 0, ErrNotInteger
+	}
+	return arg, n, nil
+}
+
+// ParseInt reads a CBOR unsigned or negative integer from the start of b and
+// returns it with the number of bytes consumed. A value outside the int64
+// range fails with ErrOutOfRange and a data item of another major type with
+// ErrNotInteger; other errors are as for ParseHead
+func ParseInt(b []byte) (int64, int, error) {
+	return parseInt(b, ParseHead)
+}
+
+// ParseIntCanonical is like ParseInt but rejects heads longer than needed
+// with ErrNonCanonical
+func ParseIntCanonical(b []byte) (int64, int, error) {
+	return parseInt(b, ParseHeadCanonical)
+}
+
+func parseInt(b []byte, head func([]byte) (byte, uint64, int, error)) (int64, int, error) {
+	major, arg, n, err := head(b)
+	if err != nil {
+		return 0, 0, err
+	}
+	switch {
+	case major != MajorUnsigned && major != MajorNegative:
+		return 0, 0, ErrNotInteger
+	case arg > math.MaxInt64:
+		return 0, 0, ErrOutOfRange
+	case major == MajorNegative:
+		return -1 - int64(arg), n, nil
+	default:
+		return int64(arg), n, nil
+	}
+}
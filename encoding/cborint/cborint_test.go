@@ -0,0 +1,132 @@
+package cborint
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"math"
+	"testing"
+
+	"flux/encoding/internal/testcorpus"
+)
+
+// -------------------------
+// RFC 8949 appendix A
+// -------------------------
+
+func TestAppendixA(t *testing.T) {
+	unsigned := []struct {
+		v    uint64
+		want string
+	}{
+		{0, "00"},
+		{1, "01"},
+		{10, "0a"},
+		{23, "17"},
+		{24, "1818"},
+		{25, "1819"},
+		{100, "1864"},
+		{1000, "1903e8"},
+		{1000000, "1a000f4240"},
+		{1000000000000, "1b000000e8d4a51000"},
+		{18446744073709551615, "1bffffffffffffffff"},
+	}
+	for _, c := range unsigned {
+		want, _ := hex.DecodeString(c.want)
+		if got := AppendUint(nil, c.v); !bytes.Equal(got, want) {
+			t.Fatalf("AppendUint(%d) = %x, want %s", c.v, got, c.want)
+		}
+		if v, n, err := ParseUintCanonical(want); v != c.v || n != len(want) || err != nil {
+			t.Fatalf("ParseUintCanonical(%s) = %d, %d, %v; want %d", c.want, v, n, err, c.v)
+		}
+	}
+
+	signed := []struct {
+		v    int64
+		want string
+	}{
+		{0, "00"},
+		{100, "1864"},
+		{-1, "20"},
+		{-10, "29"},
+		{-100, "3863"},
+		{-1000, "3903e7"},
+		{math.MinInt64, "3b7fffffffffffffff"},
+	}
+	for _, c := range signed {
+		want, _ := hex.DecodeString(c.want)
+		if got := AppendInt(nil, c.v); !bytes.Equal(got, want) {
+			t.Fatalf("AppendInt(%d) = %x, want %s", c.v, got, c.want)
+		}
+		if v, n, err := ParseIntCanonical(want); v != c.v || n != len(want) || err != nil {
+			t.Fatalf("ParseIntCanonical(%s) = %d, %d, %v; want %d", c.want, v, n, err, c.v)
+		}
+	}
+
+	// -18446744073709551616 is a valid head even though no int64 holds it
+	b, _ := hex.DecodeString("3bffffffffffffffff")
+	if major, arg, n, err := ParseHeadCanonical(b); major != MajorNegative || arg != math.MaxUint64 || n != 9 || err != nil {
+		t.Fatalf("ParseHeadCanonical(3bff..ff) = %d, %d, %d, %v", major, arg, n, err)
+	}
+	if _, _, err := ParseInt(b); err != ErrOutOfRange {
+		t.Fatalf("ParseInt(3bff..ff) error = %v, want ErrOutOfRange", err)
+	}
+}
+
+// -------------------------
+// Parse
+// -------------------------
+
+func TestRoundTrip(t *testing.T) {
+	vs := append(testcorpus.Skewed(1000), testcorpus.Uniform(1000)...)
+	vs = append(vs, 23, 24, 255, 256, 65535, 65536, math.MaxUint32, math.MaxUint32+1, math.MaxUint64)
+	for _, v := range vs {
+		b := AppendUint(nil, v)
+		if len(b) != Len(v) {
+			t.Fatalf("AppendUint(%d) took %d bytes, Len = %d", v, len(b), Len(v))
+		}
+		if got, n, err := ParseUintCanonical(b); got != v || n != len(b) || err != nil {
+			t.Fatalf("ParseUintCanonical(%x) = %d, %d, %v", b, got, n, err)
+		}
+		s := -int64(v >> 1)
+		if got, _, err := ParseIntCanonical(AppendInt(nil, s)); got != s || err != nil {
+			t.Fatalf("ParseIntCanonical(AppendInt(%d)) = %d, %v", s, got, err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		name      string
+		hex       string
+		lenient   error
+		canonical error
+	}{
+		{"empty", "", io.EOF, io.EOF},
+		{"truncated", "1a000f42", io.ErrUnexpectedEOF, io.ErrUnexpectedEOF},
+		{"reserved info", "1c", ErrAdditionalInfo, ErrAdditionalInfo},
+		{"indefinite", "1f", ErrAdditionalInfo, ErrAdditionalInfo},
+		{"byte string", "43010203", ErrNotInteger, ErrNotInteger},
+		{"1-byte argument under 24", "1817", nil, ErrNonCanonical},
+		{"2-byte argument under 256", "190064", nil, ErrNonCanonical},
+		{"8-byte argument under 2^32", "1b00000000000f4240", nil, ErrNonCanonical},
+		{"padded negative", "3800", nil, ErrNonCanonical},
+		{"above int64", "1b8000000000000000", ErrOutOfRange, ErrOutOfRange},
+	}
+	for _, c := range cases {
+		b, _ := hex.DecodeString(c.hex)
+		if _, _, err := ParseInt(b); err != c.lenient {
+			t.Fatalf("%s: ParseInt error = %v, want %v", c.name, err, c.lenient)
+		}
+		if _, _, err := ParseIntCanonical(b); err != c.canonical {
+			t.Fatalf("%s: ParseIntCanonical error = %v, want %v", c.name, err, c.canonical)
+		}
+	}
+	if _, _, err := ParseUint([]byte{0x20}); err != ErrNotInteger {
+		t.Fatalf("ParseUint(-1) error = %v, want ErrNotInteger", err)
+	}
+	// A head of another major type still parses
+	if major, arg, n, err := ParseHead([]byte{0x43, 1, 2, 3}); major != 2 || arg != 3 || n != 1 || err != nil {
+		t.Fatalf("ParseHead(43) = %d, %d, %d, %v", major, arg, n, err)
+	}
+}
@@ -0,0 +1,147 @@
+package compactsize
+
+import (
+	"encoding/binary"
+	"io"
+
+	"flux/encoding/varint"
+)
+
+// Bitcoin's CompactSize stores a value below 0xfd in a single byte. Larger
+// values follow a marker byte, 0xfd, 0xfe or 0xff, as a little-endian
+// uint16, uint32 or uint64. Nothing stops a writer from choosing a wider form
+// than needed, so consensus code insists on the narrowest one
+
+// MaxLen is the maximum encoded length of a value
+const MaxLen = 9
+
+// ErrNonCanonical is reported by the strict parsers when a value uses a wider
+// form than it needs, such as 0xfd followed by a value below 0xfd. It is
+// varint.ErrNonCanonical itself
+var ErrNonCanonical = varint.ErrNonCanonical
+
+// Len returns the number of bytes needed to encode v
+func Len(v uint64) int {
+	switch {
+	case v < 0xfd:
+		return 1
+	case v <= 0xffff:
+		return 3
+	case v <= 0xffffffff:
+		return 5
+	default:
+		return 9
+	}
+}
+
+// Append encodes v and appends it to dst, returning the new slice
+func Append(dst []byte, v uint64) []byte {
+	switch Len(v) {
+	case 1:
+		return append(dst, byte(v))
+	case 3:
+		return binary.LittleEndian.AppendUint16(append(dst, 0xfd), uint16(v))
+	case 5:
+		return binary.LittleEndian.AppendUint32(append(dst, 0xfe), uint32(v))
+	default:
+		return binary.LittleEndian.AppendUint64(append(dst, 0xff), v)
+	}
+}
+
+// encodedLen returns the encoded length announced by the first byte
+func encodedLen(first byte) int {
+	switch first {
+	case 0xfd:
+		return 3
+	case 0xfe:
+		return 5
+	case 0xff:
+		return 9
+	default:
+		return 1
+	}
+}
+
+// Parse reads a value from the start of b and returns it with the number of
+// bytes consumed. It returns io.EOF for an empty b and io.ErrUnexpectedEOF if
+// b ends before the value does. Non-minimal encodings are accepted
+func Parse(b []byte) (value uint64, consumed int, err error) {
+	if len(b) == 0 {
+		return 0, 0, io.EOF
+	}
+	n := encodedLen(b[0])
+	if len(b) < n {
+		return 0, 0, io.ErrUnexpectedEOF
+	}
+	switch n {
+	case 1:
+		return uint64(b[0]), 1, nil
+	case 3:
+		return uint64(binary.LittleEndian.Uint16(b[1:])), 3, nil
+	case 5:
+		return uint64(binary.LittleEndian.Uint32(b[1:])), 5, nil
+	default:
+		return binary.LittleEndian.Uint64(b[1:]), 9, nil
+	}
+}
+
+// ParseCanonical is like Parse but rejects values that use a wider form than
+// needed with ErrNonCanonical. Append always produces canonical encodings
+func ParseCanonical(b []byte) (uint64, int, error) {
+	v, n, err := Parse(b)
+	if err != nil {
+		return 0, 0, err
+	}
+	if Len(v) != n {
+		return 0, 0, ErrNonCanonical
+	}
+	return v, n, nil
+}
+
+// Read reads a value from r. It returns io.EOF only if r ends before the
+// first byte and io.ErrUnexpectedEOF if it ends partway through a value, so a
+// truncated value is never mistaken for a clean end
+func Read(r io.ByteReader) (uint64, error) {
+	return read(r, false)
+}
+
+// ReadCanonical is like Read but fails with ErrNonCanonical on a value that
+// uses a wider form than needed
+func ReadCanonical(r io.ByteReader) (uint64, error) {
+	return read(r, true)
+}
+
+func read(r io.ByteReader, canonical bool) (uint64, error) {
+	var buf [MaxLen]byte
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	buf[0] = c
+	n := encodedLen(c)
+	for i := 1; i < n; i++ {
+		if buf[i], err = r.ReadByte(); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+	}
+	if canonical {
+		v, _, err := ParseCanonical(buf[:n])
+		return v, err
+	}
+	v, _, err := Parse(buf[:n])
+	return v, err
+}
+
+// Write encodes v and writes it to w
+func Write(w io.ByteWriter, v uint64) error {
+	var buf [MaxLen]byte
+	for _, c := range Append(buf[:0], v) {
+		if err := w.WriteByte(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package compactsize
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"math"
+	"testing"
+	"testing/iotest"
+
+	"flux/encoding/internal/testcorpus"
+)
+
+// -------------------------
+// Append / Parse
+// -------------------------
+
+func TestVectors(t *testing.T) {
+	cases := []struct {
+		v    uint64
+		want []byte
+	}{
+		{0, []byte{0x00}},
+		{252, []byte{0xfc}},
+		{253, []byte{0xfd, 0xfd, 0x00}},
+		{515, []byte{0xfd, 0x03, 0x02}},
+		{65535, []byte{0xfd, 0xff, 0xff}},
+		{65536, []byte{0xfe, 0x00, 0x00, 0x01, 0x00}},
+		{1<<32 - 1, []byte{0xfe, 0xff, 0xff, 0xff, 0xff}},
+		{1 << 32, []byte{0xff, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00}},
+		{math.MaxUint64, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+	}
+	for _, c := range cases {
+		if got := Append(nil, c.v); !bytes.Equal(got, c.want) {
+			t.Fatalf("Append(%d) = %x, want %x", c.v, got, c.want)
+		}
+		if Len(c.v) != len(c.want) {
+			t.Fatalf("Len(%d) = %d, want %d", c.v, Len(c.v), len(c.want))
+		}
+		if v, n, err := ParseCanonical(append(c.want, 0xaa)); v != c.v || n != len(c.want) || err != nil {
+			t.Fatalf("ParseCanonical(%x) = %d, %d, %v; want %d", c.want, v, n, err, c.v)
+		}
+		if v, err := ReadCanonical(bytes.NewReader(c.want)); v != c.v || err != nil {
+			t.Fatalf("ReadCanonical(%x) = %d, %v; want %d", c.want, v, err, c.v)
+		}
+	}
+}
+
+func TestNonCanonical(t *testing.T) {
+	cases := []struct {
+		b    []byte
+		want uint64
+	}{
+		{[]byte{0xfd, 0xfc, 0x00}, 252},
+		{[]byte{0xfd, 0x00, 0x00}, 0},
+		{[]byte{0xfe, 0xff, 0xff, 0x00, 0x00}, 65535},
+		{[]byte{0xfe, 0x05, 0x00, 0x00, 0x00}, 5},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0x00, 0x00, 0x00, 0x00}, 1<<32 - 1},
+		{[]byte{0xff, 0xfd, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, 253},
+	}
+	for _, c := range cases {
+		if v, n, err := Parse(c.b); v != c.want || n != len(c.b) || err != nil {
+			t.Fatalf("Parse(%x) = %d, %d, %v; want %d", c.b, v, n, err, c.want)
+		}
+		if v, err := Read(bytes.NewReader(c.b)); v != c.want || err != nil {
+			t.Fatalf("Read(%x) = %d, %v; want %d", c.b, v, err, c.want)
+		}
+		if _, _, err := ParseCanonical(c.b); err != ErrNonCanonical {
+			t.Fatalf("ParseCanonical(%x) error = %v, want ErrNonCanonical", c.b, err)
+		}
+		if _, err := ReadCanonical(bytes.NewReader(c.b)); err != ErrNonCanonical {
+			t.Fatalf("ReadCanonical(%x) error = %v, want ErrNonCanonical", c.b, err)
+		}
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	if _, _, err := Parse(nil); err != io.EOF {
+		t.Fatalf("Parse(empty) error = %v, want io.EOF", err)
+	}
+	if _, err := Read(bytes.NewReader(nil)); err != io.EOF {
+		t.Fatalf("Read(empty) error = %v, want io.EOF", err)
+	}
+	for _, v := range []uint64{253, 65536, 1 << 32} {
+		b := Append(nil, v)
+		for cut := 1; cut < len(b); cut++ {
+			if _, _, err := Parse(b[:cut]); err != io.ErrUnexpectedEOF {
+				t.Fatalf("Parse(%x) error = %v, want io.ErrUnexpectedEOF", b[:cut], err)
+			}
+			if _, err := Read(bytes.NewReader(b[:cut])); err != io.ErrUnexpectedEOF {
+				t.Fatalf("Read(%x) error = %v, want io.ErrUnexpectedEOF", b[:cut], err)
+			}
+		}
+	}
+}
+
+func TestReadWriteStream(t *testing.T) {
+	vs := append(testcorpus.Skewed(1000), 252, 253, 65535, 65536, 1<<32-1, 1<<32, math.MaxUint64)
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	for _, v := range vs {
+		if err := Write(bw, v); err != nil {
+			t.Fatalf("Write(%d) error = %v", v, err)
+		}
+	}
+	bw.Flush()
+	r := bufio.NewReader(iotest.OneByteReader(&buf))
+	for _, want := range vs {
+		if v, err := ReadCanonical(r); v != want || err != nil {
+			t.Fatalf("ReadCanonical = %d, %v; want %d", v, err, want)
+		}
+	}
+	if _, err := Read(r); err != io.EOF {
+		t.Fatalf("Read at end error = %v, want io.EOF", err)
+	}
+}
@@ -0,0 +1,101 @@
+package gitoffset
+
+import (
+	"errors"
+	"io"
+)
+
+// A git packfile stores the distance back to the base of an OFS_DELTA object
+// as a big-endian run of 7-bit groups, every byte but the last with its high
+// bit set. Each continuation also adds one before the value shifts left, so
+// the two-byte forms start where the one-byte forms end, at 128, and the
+// three-byte forms at 16512. As a result every value has exactly one
+// encoding and there is nothing non-canonical to reject
+
+// MaxLen is the maximum encoded length of a uint64
+const MaxLen = 10
+
+// ErrOverflow is reported when an encoding denotes a value past 64 bits
+var ErrOverflow = errors.New("gitoffset: value overflows 64 bits")
+
+// Len returns the number of bytes needed to encode v
+func Len(v uint64) int {
+	n := 1
+	for v >>= 7; v != 0; v >>= 7 {
+		v--
+		n++
+	}
+	return n
+}
+
+// Append encodes v and appends it to dst, returning the new slice
+func Append(dst []byte, v uint64) []byte {
+	// Groups come out least significant first, so build them backwards
+	var buf [MaxLen]byte
+	i := MaxLen - 1
+	buf[i] = byte(v & 0x7f)
+	for v >>= 7; v != 0; v >>= 7 {
+		v--
+		i--
+		buf[i] = 0x80 | byte(v&0x7f)
+	}
+	return append(dst, buf[i:]...)
+}
+
+// Parse reads a value from the start of b and returns it with the number of
+// bytes consumed. It returns io.EOF for an empty b, io.ErrUnexpectedEOF if b
+// ends while the high bit is still set and ErrOverflow for a value past 64
+// bits
+func Parse(b []byte) (value uint64, consumed int, err error) {
+	if len(b) == 0 {
+		return 0, 0, io.EOF
+	}
+	value = uint64(b[0] & 0x7f)
+	for i, c := range b {
+		if i > 0 {
+			if value > 1<<57-2 {
+				return 0, 0, ErrOverflow
+			}
+			value = (value+1)<<7 | uint64(c&0x7f)
+		}
+		if c < 0x80 {
+			return value, i + 1, nil
+		}
+	}
+	return 0, 0, io.ErrUnexpectedEOF
+}
+
+// Read reads a value from r. It returns io.EOF only if r ends before the
+// first byte and io.ErrUnexpectedEOF if it ends partway through a value, so a
+// truncated value is never mistaken for a clean end
+func Read(r io.ByteReader) (uint64, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	v := uint64(c & 0x7f)
+	for c >= 0x80 {
+		if c, err = r.ReadByte(); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		if v > 1<<57-2 {
+			return 0, ErrOverflow
+		}
+		v = (v+1)<<7 | uint64(c&0x7f)
+	}
+	return v, nil
+}
+
+// Write encodes v and writes it to w
+func Write(w io.ByteWriter, v uint64) error {
+	var buf [MaxLen]byte
+	for _, c := range Append(buf[:0], v) {
+		if err := w.WriteByte(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package gitoffset
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"math"
+	"testing"
+
+	"flux/encoding/internal/testcorpus"
+)
+
+// referenceParse is the decoder loop from git's packfile reader, kept in its
+// original shape
+func referenceParse(b []byte) (uint64, int) {
+	used := 0
+	c := b[used]
+	used++
+	ofs := uint64(c & 127)
+	for c&128 != 0 {
+		ofs++
+		c = b[used]
+		used++
+		ofs = ofs<<7 + uint64(c&127)
+	}
+	return ofs, used
+}
+
+// -------------------------
+// Append / Parse
+// -------------------------
+
+func TestBoundaryVectors(t *testing.T) {
+	cases := []struct {
+		v    uint64
+		want []byte
+	}{
+		{0, []byte{0x00}},
+		{127, []byte{0x7f}},
+		{128, []byte{0x80, 0x00}},
+		{129, []byte{0x80, 0x01}},
+		{255, []byte{0x80, 0x7f}},
+		{256, []byte{0x81, 0x00}},
+		{16511, []byte{0xff, 0x7f}},
+		{16512, []byte{0x80, 0x80, 0x00}},
+		{2113663, []byte{0xff, 0xff, 0x7f}},
+		{2113664, []byte{0x80, 0x80, 0x80, 0x00}},
+		{math.MaxUint64, []byte{0x80, 0xfe, 0xfe, 0xfe, 0xfe, 0xfe, 0xfe, 0xfe, 0xfe, 0x7f}},
+	}
+	for _, c := range cases {
+		if got := Append(nil, c.v); !bytes.Equal(got, c.want) {
+			t.Fatalf("Append(%d) = %x, want %x", c.v, got, c.want)
+		}
+		if Len(c.v) != len(c.want) {
+			t.Fatalf("Len(%d) = %d, want %d", c.v, Len(c.v), len(c.want))
+		}
+		if v, n, err := Parse(append(c.want, 0x55)); v != c.v || n != len(c.want) || err != nil {
+			t.Fatalf("Parse(%x) = %d, %d, %v; want %d", c.want, v, n, err, c.v)
+		}
+		if v, n := referenceParse(c.want); v != c.v || n != len(c.want) {
+			t.Fatalf("git's loop reads %x as %d, %d; want %d", c.want, v, n, c.v)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	vs := append(testcorpus.Skewed(2000), testcorpus.Uniform(2000)...)
+	// Every length boundary, found by summing the ranges of the shorter forms
+	first := uint64(0)
+	for n := 1; n < MaxLen; n++ {
+		first = (first + 1) << 7
+		vs = append(vs, first-1, first, first+1)
+	}
+	for _, v := range vs {
+		b := Append(nil, v)
+		if got, n, err := Parse(b); got != v || n != len(b) || err != nil {
+			t.Fatalf("Parse(Append(%d)) = %d, %d, %v", v, got, n, err)
+		}
+		if got, n := referenceParse(b); got != v || n != len(b) {
+			t.Fatalf("git's loop reads Append(%d) as %d, %d", v, got, n)
+		}
+		if got, err := Read(bytes.NewReader(b)); got != v || err != nil {
+			t.Fatalf("Read(Append(%d)) = %d, %v", v, got, err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		b    []byte
+		want error
+	}{
+		{"empty", nil, io.EOF},
+		{"truncated", []byte{0x80, 0xff}, io.ErrUnexpectedEOF},
+		{"past 64 bits", []byte{0x80, 0xfe, 0xfe, 0xfe, 0xfe, 0xfe, 0xfe, 0xfe, 0xff, 0x00}, ErrOverflow},
+		{"eleven bytes", bytes.Repeat([]byte{0x80}, 11), ErrOverflow},
+	}
+	for _, c := range cases {
+		if _, _, err := Parse(c.b); err != c.want {
+			t.Fatalf("%s: Parse error = %v, want %v", c.name, err, c.want)
+		}
+		if _, err := Read(bytes.NewReader(c.b)); err != c.want {
+			t.Fatalf("%s: Read error = %v, want %v", c.name, err, c.want)
+		}
+	}
+}
+
+func TestReadWriteStream(t *testing.T) {
+	vs := testcorpus.Skewed(1000)
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	for _, v := range vs {
+		if err := Write(bw, v); err != nil {
+			t.Fatalf("Write(%d) error = %v", v, err)
+		}
+	}
+	bw.Flush()
+	r := bufio.NewReader(&buf)
+	for _, want := range vs {
+		if v, err := Read(r); v != want || err != nil {
+			t.Fatalf("Read = %d, %v; want %d", v, err, want)
+		}
+	}
+	if _, err := Read(r); err != io.EOF {
+		t.Fatalf("Read at end error = %v, want io.EOF", err)
+	}
+}
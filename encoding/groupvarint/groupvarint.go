@@ -0,0 +1,158 @@
+package groupvarint
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"slices"
+
+	"flux/encoding/varint"
+)
+
+// A group packs four uint32 values behind a single control byte. Bits
+// 2i..2i+1 of the control byte hold the byte length minus one of value i, and
+// the values follow in order, each little-endian in 1 to 4 bytes. A group is
+// therefore between 5 and 17 bytes long
+
+// MaxGroupLen is the maximum encoded length of a group
+const MaxGroupLen = 17
+
+// ErrCount is reported by DecodeAll when the declared value count does not
+// match the encoded groups
+var ErrCount = errors.New("invalid group varint value count")
+
+// masks[l] keeps the low l bytes of a 32-bit word
+var masks = [5]uint32{0, 0xFF, 0xFFFF, 0xFFFFFF, 0xFFFFFFFF}
+
+func byteLen(v uint32) int {
+	switch {
+	case v < 1<<8:
+		return 1
+	case v < 1<<16:
+		return 2
+	case v < 1<<24:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// Append encodes the four values in vs as a group and appends it to dst
+func Append(dst []byte, vs [4]uint32) []byte {
+	return appendGroup(dst, vs[:])
+}
+
+// appendGroup encodes up to four values; missing values have no data bytes
+// and a zero length code
+func appendGroup(dst []byte, vs []uint32) []byte {
+	ctrl := len(dst)
+	dst = append(dst, 0)
+	var c byte
+	for i, v := range vs {
+		l := byteLen(v)
+		c |= byte(l-1) << (2 * i)
+		dst = binary.LittleEndian.AppendUint32(dst, v)[:len(dst)+l]
+	}
+	dst[ctrl] = c
+	return dst
+}
+
+// GroupLen returns the encoded length of the group whose control byte is c
+func GroupLen(c byte) int {
+	return 5 + int(c&3) + int(c>>2&3) + int(c>>4&3) + int(c>>6)
+}
+
+// Parse reads one group from b and returns its values and the number of bytes
+// consumed
+func Parse(b []byte) (vs [4]uint32, n int, err error) {
+	if len(b) == 0 {
+		return vs, 0, io.EOF
+	}
+	n = GroupLen(b[0])
+	if len(b) < n {
+		return vs, 0, io.ErrUnexpectedEOF
+	}
+	parseGroup(&vs, b, 4)
+	return vs, n, nil
+}
+
+// parseGroup decodes the first count values of the group at the start of b,
+// which must hold the whole group
+func parseGroup(vs *[4]uint32, b []byte, count int) int {
+	c := b[0]
+	off := 1
+	if len(b) >= MaxGroupLen {
+		// Every value can be loaded as a full word without running off b
+		for i := 0; i < count; i++ {
+			l := int(c>>(2*i)&3) + 1
+			vs[i] = binary.LittleEndian.Uint32(b[off:]) & masks[l]
+			off += l
+		}
+		return off
+	}
+	for i := 0; i < count; i++ {
+		l := int(c>>(2*i)&3) + 1
+		var v uint32
+		for j := l - 1; j >= 0; j-- {
+			v = v<<8 | uint32(b[off+j])
+		}
+		vs[i] = v
+		off += l
+	}
+	return off
+}
+
+// EncodeAll encodes vs as a varint value count followed by groups of four,
+// the last of which may hold fewer values, and appends the result to dst
+func EncodeAll(dst []byte, vs []uint32) []byte {
+	dst = varint.Append(dst, uint64(len(vs)))
+	dst = slices.Grow(dst, len(vs)/4*MaxGroupLen+MaxGroupLen)
+	for len(vs) >= 4 {
+		dst = appendGroup(dst, vs[:4])
+		vs = vs[4:]
+	}
+	if len(vs) > 0 {
+		dst = appendGroup(dst, vs)
+	}
+	return dst
+}
+
+// DecodeAll decodes a buffer written by EncodeAll, appending the values to
+// dst. It returns ErrCount if b holds bytes beyond the declared values or too
+// few bytes to hold the count it declares
+func DecodeAll(dst []uint32, b []byte) ([]uint32, error) {
+	count, off, err := varint.Parse(b)
+	if err != nil {
+		return dst, err
+	}
+	// Every value needs at least one data byte plus a quarter control byte
+	if count > uint64(len(b)-off) {
+		return dst, ErrCount
+	}
+	dst = slices.Grow(dst, int(count))
+	var vs [4]uint32
+	for remaining := int(count); remaining > 0; {
+		if off >= len(b) {
+			return dst, io.ErrUnexpectedEOF
+		}
+		n := min(remaining, 4)
+		glen := GroupLen(b[off])
+		if n < 4 {
+			glen = 1
+			for i := 0; i < n; i++ {
+				glen += int(b[off]>>(2*i)&3) + 1
+			}
+		}
+		if len(b)-off < glen {
+			return dst, io.ErrUnexpectedEOF
+		}
+		parseGroup(&vs, b[off:], n)
+		dst = append(dst, vs[:n]...)
+		off += glen
+		remaining -= n
+	}
+	if off != len(b) {
+		return dst, ErrCount
+	}
+	return dst, nil
+}
@@ -0,0 +1,133 @@
+package groupvarint
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"flux/encoding/internal/testcorpus"
+	"flux/encoding/varint"
+)
+
+var sinkInt int
+
+// -------------------------
+// Append / Parse
+// -------------------------
+
+func TestAppend(t *testing.T) {
+	got := Append(nil, [4]uint32{1, 256, 65536, math.MaxUint32})
+	want := []byte{
+		0b11_10_01_00,
+		0x01,
+		0x00, 0x01,
+		0x00, 0x00, 0x01,
+		0xFF, 0xFF, 0xFF, 0xFF,
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("Append = %x, want %x", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	groups := [][4]uint32{
+		{0, 0, 0, 0},
+		{1, 256, 65536, math.MaxUint32},
+		{math.MaxUint32, math.MaxUint32, math.MaxUint32, math.MaxUint32},
+		{255, 65535, 1<<24 - 1, 1 << 24},
+	}
+	for _, g := range groups {
+		b := Append(nil, g)
+		// Parse with and without trailing room exercises both load paths
+		for _, in := range [][]byte{b, append(slices.Clone(b), make([]byte, MaxGroupLen)...)} {
+			got, n, err := Parse(in)
+			if err != nil || got != g || n != len(b) {
+				t.Fatalf("Parse(%x) = %v, %d, %v; want %v, %d, nil", in, got, n, err, g, len(b))
+			}
+		}
+		if _, _, err := Parse(b[:len(b)-1]); err != io.ErrUnexpectedEOF {
+			t.Fatalf("Parse(truncated) error = %v, want io.ErrUnexpectedEOF", err)
+		}
+	}
+	if _, _, err := Parse(nil); err != io.EOF {
+		t.Fatalf("Parse(nil) error = %v, want io.EOF", err)
+	}
+}
+
+// -------------------------
+// EncodeAll / DecodeAll
+// -------------------------
+
+func TestEncodeAllRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for n := 0; n <= 9; n++ {
+		vs := testcorpus.Uint32s(rng, n)
+		b := EncodeAll(nil, vs)
+		got, err := DecodeAll(nil, b)
+		if err != nil || !slices.Equal(got, vs) {
+			t.Fatalf("DecodeAll(EncodeAll(%v)) = %v, %v", vs, got, err)
+		}
+	}
+}
+
+func TestDecodeAllErrors(t *testing.T) {
+	b := EncodeAll(nil, []uint32{1, 2, 3, 4, 5, 1 << 20})
+	if _, err := DecodeAll(nil, b[:len(b)-1]); err != io.ErrUnexpectedEOF {
+		t.Fatalf("DecodeAll(truncated) error = %v, want io.ErrUnexpectedEOF", err)
+	}
+	if _, err := DecodeAll(nil, append(slices.Clone(b), 0)); err != ErrCount {
+		t.Fatalf("DecodeAll(trailing) error = %v, want ErrCount", err)
+	}
+	huge := varint.Append(nil, 1<<40)
+	if _, err := DecodeAll(nil, huge); err != ErrCount {
+		t.Fatalf("DecodeAll(huge count) error = %v, want ErrCount", err)
+	}
+}
+
+// -------------------------
+// Benchmarks
+// -------------------------
+
+func BenchmarkDecodeAll(b *testing.B) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	vs := testcorpus.Uint32s(rng, 1<<20)
+
+	b.Run("groupvarint", func(b *testing.B) {
+		buf := EncodeAll(nil, vs)
+		dst := make([]uint32, 0, len(vs))
+		b.SetBytes(int64(len(vs) * 4))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			var err error
+			dst, err = DecodeAll(dst[:0], buf)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+		sinkInt = len(dst)
+	})
+	b.Run("varint.Parse", func(b *testing.B) {
+		var buf []byte
+		for _, v := range vs {
+			buf = varint.Append(buf, uint64(v))
+		}
+		dst := make([]uint32, 0, len(vs))
+		b.SetBytes(int64(len(vs) * 4))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			dst = dst[:0]
+			for off := 0; off < len(buf); {
+				v, n, err := varint.Parse(buf[off:])
+				if err != nil {
+					b.Fatal(err)
+				}
+				dst = append(dst, uint32(v))
+				off += n
+			}
+		}
+		sinkInt = len(dst)
+	})
+}
@@ -0,0 +1,139 @@
+package grpcframe
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"flux/encoding/varint"
+)
+
+// A gRPC message on the wire is a 5-byte prefix, a compressed flag byte and a
+// big-endian uint32 length, followed by the message. The converters below
+// move messages between that framing and varint.WriteMessage frames without
+// touching their bytes
+
+// HeaderLen is the length of a gRPC frame prefix
+const HeaderLen = 5
+
+// ErrCompressed is reported for a frame with the compressed flag set when no
+// decompressor was supplied
+var ErrCompressed = errors.New("grpcframe: compressed frame and no decompressor")
+
+// ErrFlag is reported for a frame whose flag byte is neither 0 nor 1
+var ErrFlag = errors.New("grpcframe: invalid compressed flag")
+
+// Option adjusts ConvertGRPCToVarint
+type Option func(*options)
+
+type options struct {
+	decompress func([]byte) ([]byte, error)
+}
+
+// WithDecompressor makes ConvertGRPCToVarint pass the payload of every
+// compressed frame through decompress and forward its output instead of
+// failing with ErrCompressed. The output is subject to the same size limit
+func WithDecompressor(decompress func([]byte) ([]byte, error)) Option {
+	return func(o *options) { o.decompress = decompress }
+}
+
+// ConvertGRPCToVarint re-frames the gRPC messages read from src as
+// varint.WriteMessage frames on dst until src ends, and returns the number of
+// frames converted. A message longer than max, capped at the math.MaxInt
+// bytes a slice can hold, fails with a *varint.FrameSizeError before it is
+// read. src ending inside a frame fails with io.ErrUnexpectedEOF. Frames
+// converted before a failure still reach dst
+func ConvertGRPCToVarint(dst io.Writer, src io.Reader, max uint64, opts ...Option) (frames int, err error) {
+	max = min(max, math.MaxInt)
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	br := bufio.NewReader(src)
+	bw := bufio.NewWriter(dst)
+	defer func() {
+		if ferr := bw.Flush(); err == nil {
+			err = ferr
+		}
+	}()
+	var hdr [HeaderLen]byte
+	var buf []byte
+	for {
+		if _, err := io.ReadFull(br, hdr[:]); err != nil {
+			if err == io.EOF {
+				return frames, nil
+			}
+			return frames, err
+		}
+		switch {
+		case hdr[0] > 1:
+			return frames, ErrFlag
+		case hdr[0] == 1 && o.decompress == nil:
+			return frames, ErrCompressed
+		}
+		length := uint64(binary.BigEndian.Uint32(hdr[1:]))
+		if length > max {
+			return frames, &varint.FrameSizeError{Declared: length, Limit: max}
+		}
+		if uint64(cap(buf)) < length {
+			buf = make([]byte, length)
+		}
+		msg := buf[:length]
+		if _, err := io.ReadFull(br, msg); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return frames, err
+		}
+		if hdr[0] == 1 {
+			if msg, err = o.decompress(msg); err != nil {
+				return frames, fmt.Errorf("grpcframe: decompressing frame %d: %w", frames, err)
+			}
+			if uint64(len(msg)) > max {
+				return frames, &varint.FrameSizeError{Declared: uint64(len(msg)), Limit: max}
+			}
+		}
+		if err := varint.WriteMessage(bw, msg); err != nil {
+			return frames, err
+		}
+		frames++
+	}
+}
+
+// ConvertVarintToGRPC re-frames the varint.WriteMessage frames read from src
+// as uncompressed gRPC messages on dst until src ends, and returns the number
+// of frames converted. Errors are as for varint.ReadMessage with a limit of
+// max, capped at the 4 GiB - 1 a gRPC frame can declare. Frames converted
+// before a failure still reach dst
+func ConvertVarintToGRPC(dst io.Writer, src io.Reader, max uint64) (frames int, err error) {
+	max = min(max, math.MaxUint32)
+	br := bufio.NewReader(src)
+	bw := bufio.NewWriter(dst)
+	defer func() {
+		if ferr := bw.Flush(); err == nil {
+			err = ferr
+		}
+	}()
+	var hdr [HeaderLen]byte
+	var buf []byte
+	for {
+		buf, err = varint.ReadMessage(br, max, buf)
+		if err == io.EOF {
+			return frames, nil
+		}
+		if err != nil {
+			return frames, err
+		}
+		binary.BigEndian.PutUint32(hdr[1:], uint32(len(buf)))
+		if _, err := bw.Write(hdr[:]); err != nil {
+			return frames, err
+		}
+		if _, err := bw.Write(buf); err != nil {
+			return frames, err
+		}
+		frames++
+	}
+}
@@ -0,0 +1,123 @@
+package grpcframe
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"flux/encoding/varint"
+)
+
+// grpcFrame returns msg behind a gRPC prefix with the given flag
+func grpcFrame(flag byte, msg []byte) []byte {
+	b := binary.BigEndian.AppendUint32([]byte{flag}, uint32(len(msg)))
+	return append(b, msg...)
+}
+
+// -------------------------
+// Conversion
+// -------------------------
+
+func TestConvertRoundTrip(t *testing.T) {
+	const max = 1000
+	msgs := [][]byte{
+		nil,
+		[]byte("hello"),
+		{},
+		bytes.Repeat([]byte{0xab}, max),
+		[]byte{0, 0, 0, 0, 0},
+	}
+	var src []byte
+	for _, m := range msgs {
+		src = append(src, grpcFrame(0, m)...)
+	}
+
+	var native bytes.Buffer
+	n, err := ConvertGRPCToVarint(&native, bytes.NewReader(src), max)
+	if err != nil || n != len(msgs) {
+		t.Fatalf("ConvertGRPCToVarint = %d, %v; want %d", n, err, len(msgs))
+	}
+	r := bufio.NewReader(bytes.NewReader(native.Bytes()))
+	for i, m := range msgs {
+		if got, err := varint.ReadMessage(r, max, nil); err != nil || !bytes.Equal(got, m) {
+			t.Fatalf("message %d: ReadMessage = %x, %v; want %x", i, got, err, m)
+		}
+	}
+	if _, err := varint.ReadMessage(r, max, nil); err != io.EOF {
+		t.Fatalf("ReadMessage past the last message error = %v, want io.EOF", err)
+	}
+
+	var back bytes.Buffer
+	n, err = ConvertVarintToGRPC(&back, bytes.NewReader(native.Bytes()), max)
+	if err != nil || n != len(msgs) || !bytes.Equal(back.Bytes(), src) {
+		t.Fatalf("ConvertVarintToGRPC = %d, %v; output differs from the original frames", n, err)
+	}
+
+	// Empty input converts nothing
+	if n, err := ConvertGRPCToVarint(io.Discard, bytes.NewReader(nil), max); n != 0 || err != nil {
+		t.Fatalf("ConvertGRPCToVarint(empty) = %d, %v", n, err)
+	}
+}
+
+func TestConvertLimit(t *testing.T) {
+	const max = 64
+	src := append(grpcFrame(0, []byte("ok")), grpcFrame(0, make([]byte, max+1))...)
+	var out bytes.Buffer
+	n, err := ConvertGRPCToVarint(&out, bytes.NewReader(src), max)
+	var sizeErr *varint.FrameSizeError
+	if n != 1 || !errors.As(err, &sizeErr) || sizeErr.Declared != max+1 {
+		t.Fatalf("ConvertGRPCToVarint = %d, %v; want 1 then a FrameSizeError", n, err)
+	}
+	if want := varint.AppendBytes(nil, []byte("ok")); !bytes.Equal(out.Bytes(), want) {
+		t.Fatalf("ConvertGRPCToVarint wrote %x before failing, want %x", out.Bytes(), want)
+	}
+
+	native := varint.AppendBytes(varint.AppendBytes(nil, make([]byte, max)), make([]byte, max+1))
+	n, err = ConvertVarintToGRPC(io.Discard, bytes.NewReader(native), max)
+	if n != 1 || !errors.Is(err, varint.ErrLimitExceeded) {
+		t.Fatalf("ConvertVarintToGRPC = %d, %v; want 1 then ErrLimitExceeded", n, err)
+	}
+}
+
+func TestConvertCompressed(t *testing.T) {
+	src := append(grpcFrame(0, []byte("plain")), grpcFrame(1, []byte("xyz"))...)
+	n, err := ConvertGRPCToVarint(io.Discard, bytes.NewReader(src), 100)
+	if n != 1 || err != ErrCompressed {
+		t.Fatalf("ConvertGRPCToVarint = %d, %v; want 1 then ErrCompressed", n, err)
+	}
+
+	// A decompressor takes over, and its output is what gets forwarded and
+	// limited
+	expand := func(p []byte) ([]byte, error) { return bytes.Repeat(p, 10), nil }
+	var out bytes.Buffer
+	n, err = ConvertGRPCToVarint(&out, bytes.NewReader(src), 100, WithDecompressor(expand))
+	want := varint.AppendBytes(varint.AppendBytes(nil, []byte("plain")), bytes.Repeat([]byte("xyz"), 10))
+	if n != 2 || err != nil || !bytes.Equal(out.Bytes(), want) {
+		t.Fatalf("ConvertGRPCToVarint(decompressor) = %d, %v, %x", n, err, out.Bytes())
+	}
+	_, err = ConvertGRPCToVarint(io.Discard, bytes.NewReader(src), 29, WithDecompressor(expand))
+	if !errors.Is(err, varint.ErrLimitExceeded) {
+		t.Fatalf("ConvertGRPCToVarint(decompressed over limit) error = %v, want ErrLimitExceeded", err)
+	}
+	boom := errors.New("boom")
+	_, err = ConvertGRPCToVarint(io.Discard, bytes.NewReader(src), 100,
+		WithDecompressor(func([]byte) ([]byte, error) { return nil, boom }))
+	if !errors.Is(err, boom) {
+		t.Fatalf("ConvertGRPCToVarint(failing decompressor) error = %v, want boom", err)
+	}
+}
+
+func TestConvertMalformed(t *testing.T) {
+	frame := grpcFrame(0, []byte("message"))
+	for cut := 1; cut < len(frame); cut++ {
+		if _, err := ConvertGRPCToVarint(io.Discard, bytes.NewReader(frame[:cut]), 100); err != io.ErrUnexpectedEOF {
+			t.Fatalf("ConvertGRPCToVarint cut at %d error = %v, want io.ErrUnexpectedEOF", cut, err)
+		}
+	}
+	if _, err := ConvertGRPCToVarint(io.Discard, bytes.NewReader(grpcFrame(2, nil)), 100); err != ErrFlag {
+		t.Fatalf("ConvertGRPCToVarint(flag 2) error = %v, want ErrFlag", err)
+	}
+}
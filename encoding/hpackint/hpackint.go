@@ -0,0 +1,102 @@
+package hpackint
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// HPACK (RFC 7541 section 5.1) and QPACK encode an integer starting in the
+// low prefixBits of a byte whose high bits belong to the surrounding field.
+// A value below 2^prefixBits-1 fits in the prefix. Otherwise the prefix is
+// all ones and the rest of the value follows in 7-bit groups, least
+// significant first, every byte but the last with its high bit set. Nothing
+// in the format bounds the number of groups, so parsers must
+
+// MaxLen is the longest encoding ParsePrefixed accepts: the prefix byte and
+// the ten groups needed for 64 bits
+const MaxLen = 11
+
+// ErrOverflow is reported when an encoding denotes a value past 64 bits
+var ErrOverflow = errors.New("hpackint: value overflows 64 bits")
+
+// ErrTooLong is reported when an encoding runs past MaxLen bytes, as zero
+// groups padding a small value would
+var ErrTooLong = errors.New("hpackint: integer encoding too long")
+
+func checkPrefix(prefixBits int) {
+	if prefixBits < 1 || prefixBits > 8 {
+		panic(fmt.Sprintf("hpackint: prefix of %d bits, want 1 to 8", prefixBits))
+	}
+}
+
+// Len returns the number of bytes needed to encode v with an N-bit prefix.
+// It panics if prefixBits is not between 1 and 8
+func Len(v uint64, prefixBits int) int {
+	checkPrefix(prefixBits)
+	mask := uint64(1)<<prefixBits - 1
+	if v < mask {
+		return 1
+	}
+	n := 2
+	for v -= mask; v >= 0x80; v >>= 7 {
+		n++
+	}
+	return n
+}
+
+// AppendPrefixed encodes v with a prefixBits-bit prefix and appends it to
+// dst. The bits of firstByteFlags above the prefix are kept in the first byte
+// and the rest are ignored. It panics if prefixBits is not between 1 and 8
+func AppendPrefixed(dst []byte, v uint64, prefixBits int, firstByteFlags byte) []byte {
+	checkPrefix(prefixBits)
+	mask := uint64(1)<<prefixBits - 1
+	flags := firstByteFlags &^ byte(mask)
+	if v < mask {
+		return append(dst, flags|byte(v))
+	}
+	dst = append(dst, flags|byte(mask))
+	for v -= mask; v >= 0x80; v >>= 7 {
+		dst = append(dst, byte(v)|0x80)
+	}
+	return append(dst, byte(v))
+}
+
+// ParsePrefixed reads an integer with a prefixBits-bit prefix from the start
+// of b, ignoring the bits of b[0] above the prefix, and returns it with the
+// number of bytes consumed. It returns io.EOF for an empty b,
+// io.ErrUnexpectedEOF if b ends before the value does, ErrOverflow for a
+// value past 64 bits and ErrTooLong once the encoding exceeds MaxLen bytes,
+// so a hostile peer cannot make it scan an unbounded run of continuation
+// bytes. It panics if prefixBits is not between 1 and 8
+func ParsePrefixed(b []byte, prefixBits int) (v uint64, n int, err error) {
+	checkPrefix(prefixBits)
+	if len(b) == 0 {
+		return 0, 0, io.EOF
+	}
+	mask := uint64(1)<<prefixBits - 1
+	v = uint64(b[0]) & mask
+	if v < mask {
+		return v, 1, nil
+	}
+	var shift uint
+	for i := 1; ; i++ {
+		if i == MaxLen {
+			return 0, 0, ErrTooLong
+		}
+		if i == len(b) {
+			return 0, 0, io.ErrUnexpectedEOF
+		}
+		c := b[i]
+		group := uint64(c & 0x7f)
+		if shift > 0 && group > math.MaxUint64>>shift || v+group<<shift < v {
+			return 0, 0, ErrOverflow
+		}
+		v += group << shift
+		if c < 0x80 {
+			return v, i + 1, nil
+		}
+		shift += 7
+	}
+}
@@ -0,0 +1,104 @@
+package hpackint
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+
+	"flux/encoding/internal/testcorpus"
+)
+
+// -------------------------
+// AppendPrefixed / ParsePrefixed
+// -------------------------
+
+func TestRFCExamples(t *testing.T) {
+	// RFC 7541 appendix C.1
+	cases := []struct {
+		name   string
+		v      uint64
+		prefix int
+		flags  byte
+		want   []byte
+	}{
+		{"C.1.1 10 with a 5-bit prefix", 10, 5, 0, []byte{0x0a}},
+		{"C.1.2 1337 with a 5-bit prefix", 1337, 5, 0, []byte{0x1f, 0x9a, 0x0a}},
+		{"C.1.3 42 starting at an octet boundary", 42, 8, 0, []byte{0x2a}},
+		// The same values under the flag bits of a literal header field
+		{"10 with flags", 10, 5, 0xe0, []byte{0xea}},
+		{"1337 with flags", 1337, 5, 0xa0, []byte{0xbf, 0x9a, 0x0a}},
+	}
+	for _, c := range cases {
+		got := AppendPrefixed(nil, c.v, c.prefix, c.flags)
+		if !bytes.Equal(got, c.want) {
+			t.Fatalf("%s: AppendPrefixed = %x, want %x", c.name, got, c.want)
+		}
+		if Len(c.v, c.prefix) != len(c.want) {
+			t.Fatalf("%s: Len = %d, want %d", c.name, Len(c.v, c.prefix), len(c.want))
+		}
+		if v, n, err := ParsePrefixed(append(c.want, 0xff), c.prefix); v != c.v || n != len(c.want) || err != nil {
+			t.Fatalf("%s: ParsePrefixed = %d, %d, %v", c.name, v, n, err)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	vs := append(testcorpus.Skewed(1000), testcorpus.Uniform(1000)...)
+	vs = append(vs, 0, 126, 127, 128, 254, 255, 256, math.MaxUint64, math.MaxUint64-1)
+	for prefix := 1; prefix <= 8; prefix++ {
+		mask := uint64(1)<<prefix - 1
+		for _, v := range append(vs, mask-1, mask, mask+127, mask+128) {
+			b := AppendPrefixed([]byte{0x77}, v, prefix, 0xff)[1:]
+			if b[0]|byte(mask) != 0xff {
+				t.Fatalf("prefix %d: AppendPrefixed(%d) lost the flag bits: %x", prefix, v, b[0])
+			}
+			if len(b) != Len(v, prefix) || len(b) > MaxLen {
+				t.Fatalf("prefix %d: AppendPrefixed(%d) took %d bytes, Len = %d", prefix, v, len(b), Len(v, prefix))
+			}
+			if got, n, err := ParsePrefixed(b, prefix); got != v || n != len(b) || err != nil {
+				t.Fatalf("prefix %d: ParsePrefixed(%x) = %d, %d, %v; want %d", prefix, b, got, n, err, v)
+			}
+		}
+	}
+}
+
+func TestParsePrefixedErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		b      []byte
+		prefix int
+		want   error
+	}{
+		{"empty", nil, 5, io.EOF},
+		{"no groups", []byte{0x1f}, 5, io.ErrUnexpectedEOF},
+		{"truncated groups", []byte{0x1f, 0x9a}, 5, io.ErrUnexpectedEOF},
+		// The groups alone hold 2^64-1, and the prefix adds 1
+		{"overflow by sum", []byte{0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, 1, ErrOverflow},
+		{"overflow in last group", []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x02}, 8, ErrOverflow},
+		{"zero padding", append([]byte{0xff}, append(bytes.Repeat([]byte{0x80}, 10), 0x00)...), 8, ErrTooLong},
+		{"hostile length", append([]byte{0xff}, bytes.Repeat([]byte{0x80}, 100)...), 8, ErrTooLong},
+	}
+	for _, c := range cases {
+		if _, _, err := ParsePrefixed(c.b, c.prefix); err != c.want {
+			t.Fatalf("%s: ParsePrefixed error = %v, want %v", c.name, err, c.want)
+		}
+	}
+	// The largest value takes every allowed byte with a 1-bit prefix
+	if b := AppendPrefixed(nil, math.MaxUint64, 1, 0); len(b) != MaxLen {
+		t.Fatalf("AppendPrefixed(MaxUint64, 1) took %d bytes, want %d", len(b), MaxLen)
+	}
+}
+
+func TestInvalidPrefixPanics(t *testing.T) {
+	for _, prefix := range []int{0, 9, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("AppendPrefixed with a %d-bit prefix did not panic", prefix)
+				}
+			}()
+			AppendPrefixed(nil, 1, prefix, 0)
+		}()
+	}
+}
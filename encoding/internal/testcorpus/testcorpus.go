@@ -0,0 +1,68 @@
+// Package testcorpus holds the value generators and round-trip check shared by
+// the tests and benchmarks of the encoding packages, so that codecs are
+// measured on identical data
+package testcorpus
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// max62 is the largest value of the QUIC varint wire format
+const max62 = 1<<62 - 1
+
+// Skewed returns n values below 2^62 where each successive varint width is
+// ten times less likely than the one before, as in typical IDs, lengths and
+// counters. The sequence is the same on every call
+func Skewed(n int) []uint64 {
+	rng := rand.New(rand.NewPCG(1, 1))
+	vs := make([]uint64, n)
+	for i := range vs {
+		switch r := rng.IntN(1111); {
+		case r < 1000:
+			vs[i] = rng.Uint64N(1 << 6)
+		case r < 1100:
+			vs[i] = rng.Uint64N(1 << 14)
+		case r < 1110:
+			vs[i] = rng.Uint64N(1 << 30)
+		default:
+			vs[i] = rng.Uint64N(max62 + 1)
+		}
+	}
+	return vs
+}
+
+// Uniform returns n values drawn uniformly from [0, 2^62), nearly all of which
+// take 8 bytes as varints. The sequence is the same on every call
+func Uniform(n int) []uint64 {
+	rng := rand.New(rand.NewPCG(2, 2))
+	vs := make([]uint64, n)
+	for i := range vs {
+		vs[i] = rng.Uint64N(max62 + 1)
+	}
+	return vs
+}
+
+// Uint32s returns n values from rng with byte lengths spread evenly over 1 to
+// 4
+func Uint32s(rng *rand.Rand, n int) []uint32 {
+	vs := make([]uint32, n)
+	for i := range vs {
+		vs[i] = rng.Uint32() >> (8 * rng.IntN(4))
+	}
+	return vs
+}
+
+// CheckRoundTrip fails t unless decode(encode(vs)) returns vs without error
+func CheckRoundTrip[T comparable](t testing.TB, vs []T, encode func([]T) []byte, decode func([]byte) ([]T, error)) {
+	t.Helper()
+	b := encode(vs)
+	got, err := decode(b)
+	if err != nil {
+		t.Fatalf("decoding %d values from %x: %v", len(vs), b, err)
+	}
+	if !slices.Equal(got, vs) {
+		t.Fatalf("round trip of %v = %v", vs, got)
+	}
+}
@@ -0,0 +1,104 @@
+package leb128
+
+import (
+	"bufio"
+	"io"
+
+	"flux/encoding/varint"
+)
+
+// WriteDelimited writes msg to w prefixed with its length in LEB128, the
+// framing of protobuf's writeDelimitedTo, using at most two Write calls
+func WriteDelimited(w io.Writer, msg []byte) error {
+	var buf [MaxLen]byte
+	if _, err := w.Write(Append(buf[:0], uint64(len(msg)))); err != nil {
+		return err
+	}
+	if len(msg) == 0 {
+		return nil
+	}
+	_, err := w.Write(msg)
+	return err
+}
+
+// ReadDelimited reads a message written by WriteDelimited, with the limit
+// and reuse rules of varint.ReadMessage: a declared length above max fails
+// with a *varint.FrameSizeError before anything is allocated or read, and the
+// message is read into buf when it has enough capacity. It returns io.EOF
+// only if r ends before the length prefix, and io.ErrUnexpectedEOF if it ends
+// anywhere after that
+func ReadDelimited(r *bufio.Reader, max uint64, buf []byte) ([]byte, error) {
+	length, err := Read(r)
+	if err != nil {
+		return nil, err
+	}
+	if length > max {
+		return nil, &varint.FrameSizeError{Declared: length, Limit: max}
+	}
+	if uint64(cap(buf)) >= length {
+		buf = buf[:length]
+	} else {
+		buf = make([]byte, length)
+	}
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf, nil
+}
+
+// DelimitedToMessages re-frames the LEB128-delimited messages read from r as
+// varint.WriteMessage frames on w until r ends, and returns the number of
+// messages copied. Messages above max bytes fail as in ReadDelimited. Those
+// copied before a failure still reach w
+func DelimitedToMessages(w io.Writer, r io.Reader, max uint64) (messages int64, err error) {
+	br := bufio.NewReader(r)
+	bw := bufio.NewWriter(w)
+	defer func() {
+		if ferr := bw.Flush(); err == nil {
+			err = ferr
+		}
+	}()
+	var buf []byte
+	for {
+		buf, err = ReadDelimited(br, max, buf)
+		if err == io.EOF {
+			return messages, nil
+		}
+		if err != nil {
+			return messages, err
+		}
+		if err := varint.WriteMessage(bw, buf); err != nil {
+			return messages, err
+		}
+		messages++
+	}
+}
+
+// MessagesToDelimited is the reverse of DelimitedToMessages, re-framing
+// varint.WriteMessage frames read from r as LEB128-delimited messages on w
+func MessagesToDelimited(w io.Writer, r io.Reader, max uint64) (messages int64, err error) {
+	br := bufio.NewReader(r)
+	bw := bufio.NewWriter(w)
+	defer func() {
+		if ferr := bw.Flush(); err == nil {
+			err = ferr
+		}
+	}()
+	var buf []byte
+	for {
+		buf, err = varint.ReadMessage(br, max, buf)
+		if err == io.EOF {
+			return messages, nil
+		}
+		if err != nil {
+			return messages, err
+		}
+		if err := WriteDelimited(bw, buf); err != nil {
+			return messages, err
+		}
+		messages++
+	}
+}
@@ -0,0 +1,118 @@
+package leb128
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"testing"
+
+	"flux/encoding/varint"
+)
+
+// -------------------------
+// Delimited messages
+// -------------------------
+
+// delimitedMessages returns n messages of assorted sizes, including empty
+// ones and ones whose length prefix takes more than one byte
+func delimitedMessages(n int) [][]byte {
+	rng := rand.New(rand.NewPCG(3, 3))
+	msgs := make([][]byte, n)
+	for i := range msgs {
+		size := rng.IntN(200)
+		if i%97 == 0 {
+			size = rng.IntN(20000)
+		}
+		msgs[i] = make([]byte, size)
+		for j := range msgs[i] {
+			msgs[i][j] = byte(rng.Uint32())
+		}
+	}
+	msgs[1] = nil
+	return msgs
+}
+
+func TestDelimitedRoundTrip(t *testing.T) {
+	msgs := delimitedMessages(5000)
+	var file bytes.Buffer
+	for _, m := range msgs {
+		if err := WriteDelimited(&file, m); err != nil {
+			t.Fatalf("WriteDelimited error = %v", err)
+		}
+	}
+	// The file is what protobuf's writeDelimitedTo would produce
+	var want []byte
+	for _, m := range msgs {
+		want = append(binary.AppendUvarint(want, uint64(len(m))), m...)
+	}
+	if !bytes.Equal(file.Bytes(), want) {
+		t.Fatal("WriteDelimited output differs from uvarint-prefixed messages")
+	}
+
+	r := bufio.NewReader(bytes.NewReader(file.Bytes()))
+	buf := make([]byte, 0, 1<<15)
+	for i, m := range msgs {
+		got, err := ReadDelimited(r, 1<<15, buf)
+		if err != nil || !bytes.Equal(got, m) {
+			t.Fatalf("message %d: ReadDelimited = %d bytes, %v; want %d bytes", i, len(got), err, len(m))
+		}
+		if len(got) > 0 && &got[0] != &buf[:1][0] {
+			t.Fatalf("message %d: ReadDelimited did not reuse buf", i)
+		}
+	}
+	if _, err := ReadDelimited(r, 1<<15, buf); err != io.EOF {
+		t.Fatalf("ReadDelimited at end error = %v, want io.EOF", err)
+	}
+
+	// Through varint framing and back, byte for byte
+	var native, back bytes.Buffer
+	if n, err := DelimitedToMessages(&native, bytes.NewReader(file.Bytes()), 1<<15); err != nil || n != int64(len(msgs)) {
+		t.Fatalf("DelimitedToMessages = %d, %v; want %d", n, err, len(msgs))
+	}
+	nr := bufio.NewReader(bytes.NewReader(native.Bytes()))
+	for i, m := range msgs {
+		if got, err := varint.ReadMessage(nr, 1<<15, nil); err != nil || !bytes.Equal(got, m) {
+			t.Fatalf("message %d: ReadMessage = %d bytes, %v; want %d bytes", i, len(got), err, len(m))
+		}
+	}
+	if n, err := MessagesToDelimited(&back, bytes.NewReader(native.Bytes()), 1<<15); err != nil || n != int64(len(msgs)) {
+		t.Fatalf("MessagesToDelimited = %d, %v; want %d", n, err, len(msgs))
+	}
+	if !bytes.Equal(back.Bytes(), file.Bytes()) {
+		t.Fatal("re-framing there and back changed the file")
+	}
+}
+
+func TestReadDelimitedErrors(t *testing.T) {
+	var file bytes.Buffer
+	WriteDelimited(&file, []byte("ok"))
+	WriteDelimited(&file, bytes.Repeat([]byte{1}, 300))
+	b := file.Bytes()
+
+	r := bufio.NewReader(bytes.NewReader(b))
+	ReadDelimited(r, 299, nil)
+	var sizeErr *varint.FrameSizeError
+	if _, err := ReadDelimited(r, 299, nil); !errors.As(err, &sizeErr) || sizeErr.Declared != 300 {
+		t.Fatalf("ReadDelimited over the limit error = %v, want a FrameSizeError", err)
+	}
+	second := b[3:]
+	for _, cut := range []int{1, 2, 3, len(second) - 1} {
+		r := bufio.NewReader(bytes.NewReader(second[:cut]))
+		if _, err := ReadDelimited(r, 1000, nil); err != io.ErrUnexpectedEOF {
+			t.Fatalf("ReadDelimited cut at %d error = %v, want io.ErrUnexpectedEOF", cut, err)
+		}
+	}
+
+	// A failure part way keeps the messages before it
+	var native bytes.Buffer
+	n, err := DelimitedToMessages(&native, bytes.NewReader(b), 299)
+	if n != 1 || !errors.Is(err, varint.ErrLimitExceeded) {
+		t.Fatalf("DelimitedToMessages = %d, %v; want 1 and ErrLimitExceeded", n, err)
+	}
+	if want := varint.AppendBytes(nil, []byte("ok")); !bytes.Equal(native.Bytes(), want) {
+		t.Fatalf("DelimitedToMessages wrote %x, want %x", native.Bytes(), want)
+	}
+}
@@ -0,0 +1,244 @@
+package leb128
+
+import (
+	"errors"
+	"io"
+	"math"
+	"math/bits"
+
+	"flux/encoding/varint"
+)
+
+// Unsigned LEB128, as used by protobuf, WebAssembly and encoding/binary's
+// Uvarint, stores a value seven bits at a time, least significant group
+// first. Every byte but the last has its high bit set. A uint64 needs up to
+// ten bytes, the tenth carrying only the top bit. Unlike the QUIC format the
+// length is not known until the last byte has been read, and zero-valued
+// trailing groups make longer encodings of the same value possible.
+//
+// Signed LEB128, as used by DWARF and WebAssembly, stores the two's
+// complement of a value the same way and sign-extends from bit 6 of the last
+// byte. The tenth byte of an int64 holds only the sign, so it is either 0x00
+// or 0x7f
+
+// MaxLen is the maximum encoded length of a uint64
+const MaxLen = 10
+
+// ErrOverflow is reported when an encoding runs past ten bytes or its tenth
+// byte holds more than the top bit of a uint64, or for signed values, more
+// than the sign
+var ErrOverflow = errors.New("leb128: value overflows 64 bits")
+
+// ErrNonCanonical is reported by the strict parsers when a value ends in a
+// group that only repeats what the one before it implies, a zero group for
+// unsigned values or a bare sign extension for signed ones. It is varint.ErrNonCanonical
+// itself, so callers handling both formats can test for either
+var ErrNonCanonical = varint.ErrNonCanonical
+
+// Len returns the number of bytes needed to encode v
+func Len(v uint64) int {
+	return max(bits.Len64(v)+6, 7) / 7
+}
+
+// Append encodes v and appends it to dst, returning the new slice
+func Append(dst []byte, v uint64) []byte {
+	for v >= 0x80 {
+		dst = append(dst, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(dst, byte(v))
+}
+
+// Parse reads a value from the start of b and returns it with the number of
+// bytes consumed. It returns io.EOF for an empty b, io.ErrUnexpectedEOF if b
+// ends while the high bit is still set and ErrOverflow for a value past 64
+// bits. Over-long encodings are accepted, as by binary.Uvarint
+func Parse(b []byte) (value uint64, consumed int, err error) {
+	if len(b) == 0 {
+		return 0, 0, io.EOF
+	}
+	var shift uint
+	for i, c := range b {
+		if i == MaxLen-1 && c > 1 {
+			return 0, 0, ErrOverflow
+		}
+		value |= uint64(c&0x7f) << shift
+		if c < 0x80 {
+			return value, i + 1, nil
+		}
+		shift += 7
+	}
+	return 0, 0, io.ErrUnexpectedEOF
+}
+
+// ParseCanonical is like Parse but rejects encodings that are longer than
+// necessary with ErrNonCanonical, so every value has exactly one accepted
+// byte representation. Append always produces canonical encodings
+func ParseCanonical(b []byte) (uint64, int, error) {
+	v, n, err := Parse(b)
+	if err != nil {
+		return 0, 0, err
+	}
+	if n > 1 && b[n-1] == 0 {
+		return 0, 0, ErrNonCanonical
+	}
+	return v, n, nil
+}
+
+// Read reads a value from r. It returns io.EOF only if r ends before the
+// first byte and io.ErrUnexpectedEOF if it ends partway through a value, so a
+// truncated value is never mistaken for a clean end
+func Read(r io.ByteReader) (uint64, error) {
+	return read(r, false)
+}
+
+// ReadCanonical is like Read but fails with ErrNonCanonical on an encoding
+// that is longer than necessary
+func ReadCanonical(r io.ByteReader) (uint64, error) {
+	return read(r, true)
+}
+
+func read(r io.ByteReader, canonical bool) (uint64, error) {
+	var v uint64
+	var shift uint
+	for i := 0; i < MaxLen; i++ {
+		c, err := r.ReadByte()
+		if err != nil {
+			if i > 0 && err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		if i == MaxLen-1 && c > 1 {
+			return 0, ErrOverflow
+		}
+		v |= uint64(c&0x7f) << shift
+		if c < 0x80 {
+			if canonical && i > 0 && c == 0 {
+				return 0, ErrNonCanonical
+			}
+			return v, nil
+		}
+		shift += 7
+	}
+	return 0, ErrOverflow
+}
+
+// Write encodes v and writes it to w
+func Write(w io.ByteWriter, v uint64) error {
+	for v >= 0x80 {
+		if err := w.WriteByte(byte(v) | 0x80); err != nil {
+			return err
+		}
+		v >>= 7
+	}
+	return w.WriteByte(byte(v))
+}
+
+// LenInt returns the number of bytes needed to encode v as signed LEB128
+func LenInt(v int64) int {
+	// Every value needs its magnitude bits plus a sign bit
+	return (bits.Len64(uint64(v^v>>63)) + 7) / 7
+}
+
+// AppendInt encodes v as signed LEB128 and appends it to dst, returning the
+// new slice
+func AppendInt(dst []byte, v int64) []byte {
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 && c&0x40 == 0 || v == -1 && c&0x40 != 0 {
+			return append(dst, c)
+		}
+		dst = append(dst, c|0x80)
+	}
+}
+
+// ParseInt reads a signed LEB128 value from the start of b and returns it
+// with the number of bytes consumed. Errors are as for Parse; redundant sign
+// groups are accepted
+func ParseInt(b []byte) (value int64, consumed int, err error) {
+	if len(b) == 0 {
+		return 0, 0, io.EOF
+	}
+	var v uint64
+	var shift uint
+	for i, c := range b {
+		if i == MaxLen-1 && c != 0 && c != 0x7f {
+			return 0, 0, ErrOverflow
+		}
+		v |= uint64(c&0x7f) << shift
+		shift += 7
+		if c < 0x80 {
+			if shift < 64 && c&0x40 != 0 {
+				v |= math.MaxUint64 << shift
+			}
+			return int64(v), i + 1, nil
+		}
+	}
+	return 0, 0, io.ErrUnexpectedEOF
+}
+
+// ParseIntCanonical is like ParseInt but rejects encodings that are longer
+// than necessary with ErrNonCanonical. AppendInt always produces canonical
+// encodings
+func ParseIntCanonical(b []byte) (int64, int, error) {
+	v, n, err := ParseInt(b)
+	if err != nil {
+		return 0, 0, err
+	}
+	if n > 1 && redundant(b[n-2], b[n-1]) {
+		return 0, 0, ErrNonCanonical
+	}
+	return v, n, nil
+}
+
+// redundant reports whether last, the final byte of a signed value, only
+// repeats the sign already given by bit 6 of prev
+func redundant(prev, last byte) bool {
+	return last == 0 && prev&0x40 == 0 || last == 0x7f && prev&0x40 != 0
+}
+
+// ReadInt reads a signed LEB128 value from r, with the same errors as Read
+func ReadInt(r io.ByteReader) (int64, error) {
+	return readInt(r, false)
+}
+
+// ReadIntCanonical is like ReadInt but fails with ErrNonCanonical on an
+// encoding that is longer than necessary
+func ReadIntCanonical(r io.ByteReader) (int64, error) {
+	return readInt(r, true)
+}
+
+func readInt(r io.ByteReader, canonical bool) (int64, error) {
+	var buf [MaxLen]byte
+	for i := 0; ; i++ {
+		c, err := r.ReadByte()
+		if err != nil {
+			if i > 0 && err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		buf[i] = c
+		if c < 0x80 || i == MaxLen-1 {
+			if canonical {
+				v, _, err := ParseIntCanonical(buf[:i+1])
+				return v, err
+			}
+			v, _, err := ParseInt(buf[:i+1])
+			return v, err
+		}
+	}
+}
+
+// WriteInt encodes v as signed LEB128 and writes it to w
+func WriteInt(w io.ByteWriter, v int64) error {
+	var buf [MaxLen]byte
+	for _, c := range AppendInt(buf[:0], v) {
+		if err := w.WriteByte(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,326 @@
+package leb128
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"testing/iotest"
+
+	"flux/encoding/internal/testcorpus"
+	"flux/encoding/varint"
+)
+
+var sinkU64 uint64
+
+// values covers every length boundary together with the shared corpora
+func values() []uint64 {
+	vs := []uint64{0, math.MaxUint64}
+	for k := 1; k < MaxLen; k++ {
+		vs = append(vs, 1<<(7*k)-1, 1<<(7*k))
+	}
+	vs = append(vs, testcorpus.Skewed(1000)...)
+	return append(vs, testcorpus.Uniform(1000)...)
+}
+
+// -------------------------
+// Interop with encoding/binary
+// -------------------------
+
+func TestInteropBinary(t *testing.T) {
+	for _, v := range values() {
+		want := binary.AppendUvarint(nil, v)
+		if got := Append(nil, v); !bytes.Equal(got, want) {
+			t.Fatalf("Append(%d) = %x, binary.AppendUvarint = %x", v, got, want)
+		}
+		if Len(v) != len(want) {
+			t.Fatalf("Len(%d) = %d, want %d", v, Len(v), len(want))
+		}
+		var buf [binary.MaxVarintLen64]byte
+		n := binary.PutUvarint(buf[:], v)
+		if got, m, err := ParseCanonical(buf[:n]); got != v || m != n || err != nil {
+			t.Fatalf("ParseCanonical(%x) = %d, %d, %v; want %d", buf[:n], got, m, err, v)
+		}
+		if got, m := binary.Uvarint(Append(nil, v)); got != v || m != n {
+			t.Fatalf("binary.Uvarint(Append(%d)) = %d, %d", v, got, m)
+		}
+	}
+	if MaxLen != binary.MaxVarintLen64 {
+		t.Fatalf("MaxLen = %d, binary.MaxVarintLen64 = %d", MaxLen, binary.MaxVarintLen64)
+	}
+}
+
+func FuzzParseBinary(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0x96, 0x01})
+	f.Add([]byte{0x80, 0x00})
+	f.Add(Append(nil, math.MaxUint64))
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x02})
+	f.Fuzz(func(t *testing.T, b []byte) {
+		want, wantN := binary.Uvarint(b)
+		v, n, err := Parse(b)
+		switch {
+		case wantN > 0:
+			if v != want || n != wantN || err != nil {
+				t.Fatalf("Parse(%x) = %d, %d, %v; binary.Uvarint = %d, %d", b, v, n, err, want, wantN)
+			}
+		case wantN < 0:
+			if err != ErrOverflow {
+				t.Fatalf("Parse(%x) error = %v, binary.Uvarint overflowed", b, err)
+			}
+		case len(b) >= MaxLen:
+			// binary.Uvarint still waits for more input after ten bytes
+			// that keep the high bit set, which can only ever overflow
+			if err != ErrOverflow {
+				t.Fatalf("Parse(%x) error = %v, want ErrOverflow", b, err)
+			}
+		case len(b) == 0:
+			if err != io.EOF {
+				t.Fatalf("Parse(empty) error = %v, want io.EOF", err)
+			}
+		default:
+			if err != io.ErrUnexpectedEOF {
+				t.Fatalf("Parse(%x) error = %v, want io.ErrUnexpectedEOF", b, err)
+			}
+		}
+
+		rv, rerr := Read(bytes.NewReader(b))
+		if rv != v || rerr != err {
+			t.Fatalf("Read(%x) = %d, %v; Parse = %d, %v", b, rv, rerr, v, err)
+		}
+		cv, cn, cerr := ParseCanonical(b)
+		switch {
+		case err != nil:
+			if cerr != err {
+				t.Fatalf("ParseCanonical(%x) error = %v, Parse = %v", b, cerr, err)
+			}
+		case bytes.Equal(Append(nil, v), b[:n]):
+			if cv != v || cn != n || cerr != nil {
+				t.Fatalf("ParseCanonical(%x) = %d, %d, %v; want %d, %d", b, cv, cn, cerr, v, n)
+			}
+		default:
+			if cerr != ErrNonCanonical {
+				t.Fatalf("ParseCanonical(%x) error = %v, want ErrNonCanonical", b, cerr)
+			}
+		}
+		if _, rerr := ReadCanonical(bytes.NewReader(b)); rerr != cerr {
+			t.Fatalf("ReadCanonical(%x) error = %v, ParseCanonical = %v", b, rerr, cerr)
+		}
+	})
+}
+
+// -------------------------
+// Parse / Read
+// -------------------------
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		b    []byte
+		want error
+	}{
+		{"empty", nil, io.EOF},
+		{"truncated", []byte{0x80, 0x80}, io.ErrUnexpectedEOF},
+		{"tenth byte too big", []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x02}, ErrOverflow},
+		{"eleven bytes", bytes.Repeat([]byte{0x80}, 11), ErrOverflow},
+	}
+	for _, c := range cases {
+		if _, _, err := Parse(c.b); err != c.want {
+			t.Fatalf("%s: Parse error = %v, want %v", c.name, err, c.want)
+		}
+		if _, err := Read(bytes.NewReader(c.b)); err != c.want {
+			t.Fatalf("%s: Read error = %v, want %v", c.name, err, c.want)
+		}
+	}
+
+	// Trailing zero groups are only rejected by the strict parsers
+	padded := []byte{0x81, 0x80, 0x00}
+	if v, n, err := Parse(padded); v != 1 || n != 3 || err != nil {
+		t.Fatalf("Parse(%x) = %d, %d, %v; want 1, 3", padded, v, n, err)
+	}
+	if _, _, err := ParseCanonical(padded); !errors.Is(err, varint.ErrNonCanonical) {
+		t.Fatalf("ParseCanonical(%x) error = %v, want ErrNonCanonical", padded, err)
+	}
+	if _, err := ReadCanonical(bytes.NewReader(padded)); err != ErrNonCanonical {
+		t.Fatalf("ReadCanonical(%x) error = %v, want ErrNonCanonical", padded, err)
+	}
+	if v, _, err := ParseCanonical([]byte{0}); v != 0 || err != nil {
+		t.Fatalf("ParseCanonical(00) = %d, %v", v, err)
+	}
+}
+
+func TestReadWriteStream(t *testing.T) {
+	vs := values()
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	for _, v := range vs {
+		if err := Write(bw, v); err != nil {
+			t.Fatalf("Write(%d) error = %v", v, err)
+		}
+	}
+	bw.Flush()
+	r := bufio.NewReader(iotest.OneByteReader(bytes.NewReader(buf.Bytes())))
+	for _, want := range vs {
+		if v, err := Read(r); v != want || err != nil {
+			t.Fatalf("Read = %d, %v; want %d", v, err, want)
+		}
+	}
+	if _, err := Read(r); err != io.EOF {
+		t.Fatalf("Read at end error = %v, want io.EOF", err)
+	}
+}
+
+// -------------------------
+// Benchmarks
+// -------------------------
+
+func BenchmarkParse(b *testing.B) {
+	buf := []byte{}
+	for _, v := range testcorpus.Skewed(1000) {
+		buf = Append(buf, v)
+	}
+	b.SetBytes(int64(len(buf)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for off := 0; off < len(buf); {
+			v, n, _ := Parse(buf[off:])
+			sinkU64 += v
+			off += n
+		}
+	}
+}
+
+// -------------------------
+// Signed LEB128
+// -------------------------
+
+func TestAppendIntVectors(t *testing.T) {
+	cases := []struct {
+		v    int64
+		want []byte
+	}{
+		// From the DWARF specification's examples
+		{2, []byte{0x02}},
+		{-2, []byte{0x7e}},
+		{127, []byte{0xff, 0x00}},
+		{-127, []byte{0x81, 0x7f}},
+		{128, []byte{0x80, 0x01}},
+		{-128, []byte{0x80, 0x7f}},
+		{129, []byte{0x81, 0x01}},
+		{-129, []byte{0xff, 0x7e}},
+		{0, []byte{0x00}},
+		{-1, []byte{0x7f}},
+		// The sign bit lands on bit 6 of a group: the positive value needs
+		// one more group to show its sign, the negative one does not
+		{63, []byte{0x3f}},
+		{64, []byte{0xc0, 0x00}},
+		{-64, []byte{0x40}},
+		{-65, []byte{0xbf, 0x7f}},
+		{1<<13 - 1, []byte{0xff, 0x3f}},
+		{1 << 13, []byte{0x80, 0xc0, 0x00}},
+		{-1 << 13, []byte{0x80, 0x40}},
+		{-1<<13 - 1, []byte{0xff, 0xbf, 0x7f}},
+		{1<<62 - 1, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x3f}},
+		{1 << 62, []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0xc0, 0x00}},
+		{-1 << 62, []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x40}},
+		{math.MaxInt64, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00}},
+		{math.MinInt64, []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x7f}},
+	}
+	for _, c := range cases {
+		if got := AppendInt(nil, c.v); !bytes.Equal(got, c.want) {
+			t.Fatalf("AppendInt(%d) = %x, want %x", c.v, got, c.want)
+		}
+		if LenInt(c.v) != len(c.want) {
+			t.Fatalf("LenInt(%d) = %d, want %d", c.v, LenInt(c.v), len(c.want))
+		}
+		if v, n, err := ParseIntCanonical(c.want); v != c.v || n != len(c.want) || err != nil {
+			t.Fatalf("ParseIntCanonical(%x) = %d, %d, %v; want %d", c.want, v, n, err, c.v)
+		}
+		if v, err := ReadIntCanonical(bytes.NewReader(c.want)); v != c.v || err != nil {
+			t.Fatalf("ReadIntCanonical(%x) = %d, %v; want %d", c.want, v, err, c.v)
+		}
+	}
+}
+
+func TestParseIntErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		b    []byte
+		want error
+	}{
+		{"empty", nil, io.EOF},
+		{"truncated", []byte{0xff, 0x80}, io.ErrUnexpectedEOF},
+		{"tenth byte not a sign", []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01}, ErrOverflow},
+		{"tenth byte continues", []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0xff}, ErrOverflow},
+	}
+	for _, c := range cases {
+		if _, _, err := ParseInt(c.b); err != c.want {
+			t.Fatalf("%s: ParseInt error = %v, want %v", c.name, err, c.want)
+		}
+		if _, err := ReadInt(bytes.NewReader(c.b)); err != c.want {
+			t.Fatalf("%s: ReadInt error = %v, want %v", c.name, err, c.want)
+		}
+	}
+
+	// Redundant sign groups decode but fail the strict parsers
+	for _, c := range []struct {
+		b    []byte
+		want int64
+	}{
+		{[]byte{0x80, 0x00}, 0},
+		{[]byte{0xff, 0x7f}, -1},
+		{[]byte{0x82, 0x80, 0x00}, 2},
+		{[]byte{0xc0, 0x7f}, -64},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}, -1},
+	} {
+		if v, n, err := ParseInt(c.b); v != c.want || n != len(c.b) || err != nil {
+			t.Fatalf("ParseInt(%x) = %d, %d, %v; want %d", c.b, v, n, err, c.want)
+		}
+		if _, _, err := ParseIntCanonical(c.b); err != ErrNonCanonical {
+			t.Fatalf("ParseIntCanonical(%x) error = %v, want ErrNonCanonical", c.b, err)
+		}
+		if _, err := ReadIntCanonical(bytes.NewReader(c.b)); err != ErrNonCanonical {
+			t.Fatalf("ReadIntCanonical(%x) error = %v, want ErrNonCanonical", c.b, err)
+		}
+	}
+}
+
+func FuzzParseInt(f *testing.F) {
+	f.Add([]byte{0x7f})
+	f.Add(AppendInt(nil, math.MinInt64))
+	f.Add([]byte{0xc0, 0x7f})
+	f.Fuzz(func(t *testing.T, b []byte) {
+		v, n, err := ParseInt(b)
+		if rv, rerr := ReadInt(bytes.NewReader(b)); rv != v || rerr != err {
+			t.Fatalf("ReadInt(%x) = %d, %v; ParseInt = %d, %v", b, rv, rerr, v, err)
+		}
+		if err != nil {
+			return
+		}
+		canonical := bytes.Equal(AppendInt(nil, v), b[:n])
+		if _, _, cerr := ParseIntCanonical(b); (cerr == nil) != canonical {
+			t.Fatalf("ParseIntCanonical(%x) error = %v, canonical = %v", b, cerr, canonical)
+		}
+	})
+}
+
+func TestIntRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	vs := []int64{math.MinInt64, math.MaxInt64}
+	for _, u := range values() {
+		vs = append(vs, int64(u), -int64(u>>1))
+	}
+	for _, v := range vs {
+		if err := WriteInt(&buf, v); err != nil {
+			t.Fatalf("WriteInt(%d) error = %v", v, err)
+		}
+	}
+	for _, want := range vs {
+		if v, err := ReadIntCanonical(&buf); v != want || err != nil {
+			t.Fatalf("ReadIntCanonical = %d, %v; want %d", v, err, want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package leb128
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"flux/encoding/varint"
+)
+
+// OverflowError is reported when a LEB128 value is too large for the 62 bits
+// of a QUIC varint. It matches varint.ErrOverflow under errors.Is. The
+// transcoders return it wrapped in a *varint.OffsetError, or a
+// *varint.StreamOffsetError for the Copy functions, carrying the position of
+// the value in the input
+type OverflowError struct {
+	Value uint64
+}
+
+func (e *OverflowError) Error() string {
+	return fmt.Sprintf("leb128 value %d overflows 62 bits", e.Value)
+}
+
+func (e *OverflowError) Is(target error) bool {
+	return target == varint.ErrOverflow
+}
+
+// QUICToLEB appends to dst the LEB128 encodings of the back-to-back QUIC
+// varints in src. Failures are reported as a *varint.OffsetError carrying the
+// offset of the value in src, with dst holding the values converted before it
+func QUICToLEB(dst, src []byte) ([]byte, error) {
+	for off := 0; off < len(src); {
+		v, n, err := varint.Parse(src[off:])
+		if err != nil {
+			return dst, &varint.OffsetError{Offset: off, Err: err}
+		}
+		dst = Append(dst, v)
+		off += n
+	}
+	return dst, nil
+}
+
+// LEBToQUIC appends to dst the QUIC varint encodings of the back-to-back
+// LEB128 values in src. A value above varint.Max is reported as an
+// *OverflowError and a malformed one with the error from Parse, either way
+// wrapped in a *varint.OffsetError carrying its offset in src, with dst
+// holding the values converted before it
+func LEBToQUIC(dst, src []byte) ([]byte, error) {
+	for off := 0; off < len(src); {
+		v, n, err := Parse(src[off:])
+		if err == nil && v > varint.Max {
+			err = &OverflowError{Value: v}
+		}
+		if err != nil {
+			return dst, &varint.OffsetError{Offset: off, Err: err}
+		}
+		dst = varint.Append(dst, v)
+		off += n
+	}
+	return dst, nil
+}
+
+// CopyQUICToLEB streams the QUIC varints read from r to w as LEB128 until r
+// ends, and returns the number of values copied. Errors are as for QUICToLEB
+// but carried in a *varint.StreamOffsetError, with offsets counted from the
+// start of r; read and write errors are returned as they are. Values
+// converted before a failure still reach w
+func CopyQUICToLEB(w io.Writer, r io.Reader) (values int64, err error) {
+	vr := varint.NewReader(r, 0)
+	bw := bufio.NewWriter(w)
+	defer func() {
+		if ferr := bw.Flush(); err == nil {
+			err = ferr
+		}
+	}()
+	for {
+		off := vr.Offset()
+		v, err := vr.Uvarint()
+		switch {
+		case err == io.EOF:
+			return values, nil
+		case err == io.ErrUnexpectedEOF:
+			return values, &varint.StreamOffsetError{Offset: off, Err: err}
+		case err != nil:
+			return values, err
+		}
+		if err := Write(bw, v); err != nil {
+			return values, err
+		}
+		values++
+	}
+}
+
+// CopyLEBToQUIC streams the LEB128 values read from r to w as QUIC varints
+// until r ends, and returns the number of values copied. Errors are as for
+// LEBToQUIC but carried in a *varint.StreamOffsetError, with offsets counted
+// from the start of r; read and write errors are returned as they are.
+// Values converted before a failure still reach w
+func CopyLEBToQUIC(w io.Writer, r io.Reader) (values int64, err error) {
+	cr := varint.NewCountingReader(bufio.NewReader(r))
+	vw := varint.NewWriter(w, 0)
+	defer func() {
+		if ferr := vw.Flush(); err == nil {
+			err = ferr
+		}
+	}()
+	for {
+		off := cr.BytesRead()
+		v, err := Read(cr)
+		switch {
+		case err == io.EOF:
+			return values, nil
+		case err == io.ErrUnexpectedEOF || err == ErrOverflow:
+			return values, &varint.StreamOffsetError{Offset: off, Err: err}
+		case err != nil:
+			return values, err
+		case v > varint.Max:
+			return values, &varint.StreamOffsetError{Offset: off, Err: &OverflowError{Value: v}}
+		}
+		if err := vw.Uvarint(v); err != nil {
+			return values, err
+		}
+		values++
+	}
+}
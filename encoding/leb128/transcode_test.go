@@ -0,0 +1,116 @@
+package leb128
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"flux/encoding/internal/testcorpus"
+	"flux/encoding/varint"
+)
+
+// -------------------------
+// Transcoding
+// -------------------------
+
+func TestTranscodeRoundTrip(t *testing.T) {
+	vs := append(testcorpus.Skewed(5000), 0, varint.Max, 1<<56, 127, 128)
+	quic := varint.AppendMany(nil, vs...)
+	var leb []byte
+	for _, v := range vs {
+		leb = Append(leb, v)
+	}
+
+	got, err := QUICToLEB(nil, quic)
+	if err != nil || !bytes.Equal(got, leb) {
+		t.Fatalf("QUICToLEB = %d bytes, %v; want %d bytes", len(got), err, len(leb))
+	}
+	got, err = LEBToQUIC([]byte("prefix"), leb)
+	if err != nil || !bytes.Equal(got, append([]byte("prefix"), quic...)) {
+		t.Fatalf("LEBToQUIC = %d bytes, %v; want the prefix and %d bytes", len(got), err, len(quic))
+	}
+
+	var out bytes.Buffer
+	n, err := CopyQUICToLEB(&out, iotest.HalfReader(bytes.NewReader(quic)))
+	if err != nil || n != int64(len(vs)) || !bytes.Equal(out.Bytes(), leb) {
+		t.Fatalf("CopyQUICToLEB = %d, %v; want %d values", n, err, len(vs))
+	}
+	out.Reset()
+	n, err = CopyLEBToQUIC(&out, iotest.OneByteReader(bytes.NewReader(leb)))
+	if err != nil || n != int64(len(vs)) || !bytes.Equal(out.Bytes(), quic) {
+		t.Fatalf("CopyLEBToQUIC = %d, %v; want %d values", n, err, len(vs))
+	}
+}
+
+// errOffset returns the offset carried by err, from the buffer and streaming
+// functions alike
+func errOffset(err error) (int64, bool) {
+	var offErr *varint.OffsetError
+	if errors.As(err, &offErr) {
+		return int64(offErr.Offset), true
+	}
+	var streamErr *varint.StreamOffsetError
+	if errors.As(err, &streamErr) {
+		return streamErr.Offset, true
+	}
+	return 0, false
+}
+
+func TestTranscodeOverflow(t *testing.T) {
+	leb := Append(Append(Append(nil, 5), 300), varint.Max+1)
+	want := varint.AppendMany(nil, 5, 300)
+	check := func(name string, got []byte, err error) {
+		t.Helper()
+		var ovErr *OverflowError
+		if !errors.Is(err, varint.ErrOverflow) || !errors.As(err, &ovErr) {
+			t.Fatalf("%s error = %v, want an OverflowError", name, err)
+		}
+		if off, ok := errOffset(err); !ok || off != 3 || ovErr.Value != varint.Max+1 {
+			t.Fatalf("%s error = %v, want value %d at byte 3", name, err, uint64(varint.Max+1))
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("%s wrote %x before failing, want %x", name, got, want)
+		}
+	}
+	got, err := LEBToQUIC(nil, leb)
+	check("LEBToQUIC", got, err)
+	var out bytes.Buffer
+	n, err := CopyLEBToQUIC(&out, bytes.NewReader(leb))
+	check("CopyLEBToQUIC", out.Bytes(), err)
+	if n != 2 {
+		t.Fatalf("CopyLEBToQUIC copied %d values, want 2", n)
+	}
+}
+
+func TestTranscodeMalformed(t *testing.T) {
+	quic := varint.AppendMany(nil, 7, varint.Max)
+	leb := append(Append(nil, 7), 0x80, 0x80)
+	cases := []struct {
+		name   string
+		run    func() error
+		want   error
+		offset int
+	}{
+		{"QUICToLEB", func() error { _, err := QUICToLEB(nil, quic[:5]); return err }, io.ErrUnexpectedEOF, 1},
+		{"LEBToQUIC", func() error { _, err := LEBToQUIC(nil, leb); return err }, io.ErrUnexpectedEOF, 1},
+		{"CopyQUICToLEB", func() error { _, err := CopyQUICToLEB(io.Discard, bytes.NewReader(quic[:5])); return err }, io.ErrUnexpectedEOF, 1},
+		{"CopyLEBToQUIC", func() error { _, err := CopyLEBToQUIC(io.Discard, bytes.NewReader(leb)); return err }, io.ErrUnexpectedEOF, 1},
+		{"LEBToQUIC overflow", func() error {
+			_, err := LEBToQUIC(nil, append([]byte{1}, bytes.Repeat([]byte{0xff}, 11)...))
+			return err
+		}, ErrOverflow, 1},
+	}
+	for _, c := range cases {
+		err := c.run()
+		if off, ok := errOffset(err); !errors.Is(err, c.want) || !ok || off != int64(c.offset) {
+			t.Fatalf("%s error = %v, want %v at byte %d", c.name, err, c.want, c.offset)
+		}
+	}
+
+	boom := errors.New("boom")
+	if _, err := CopyLEBToQUIC(io.Discard, iotest.ErrReader(boom)); err != boom {
+		t.Fatalf("CopyLEBToQUIC read error = %v, want boom", err)
+	}
+}
@@ -0,0 +1,131 @@
+package sqlitevarint
+
+import (
+	"fmt"
+	"io"
+
+	"flux/encoding/varint"
+)
+
+// SQLite stores integers in 1 to 9 bytes, most significant group first. Each
+// of the first eight bytes holds 7 bits with its high bit set when another
+// byte follows. A ninth byte, if reached, contributes all 8 of its bits, so
+// eight continued bytes plus the ninth cover the full 64 bits. Values up to
+// 2^56-1 take at most eight bytes; anything larger always takes nine
+
+// MaxLen is the maximum encoded length of a value
+const MaxLen = 9
+
+// Len returns the number of bytes needed to encode v
+func Len(v uint64) int {
+	switch {
+	case v < 1<<7:
+		return 1
+	case v < 1<<14:
+		return 2
+	case v < 1<<21:
+		return 3
+	case v < 1<<28:
+		return 4
+	case v < 1<<35:
+		return 5
+	case v < 1<<42:
+		return 6
+	case v < 1<<49:
+		return 7
+	case v < 1<<56:
+		return 8
+	default:
+		return 9
+	}
+}
+
+// Append encodes v and appends it to dst, returning the new slice
+func Append(dst []byte, v uint64) []byte {
+	n := Len(v)
+	if n == MaxLen {
+		// The last byte takes the low 8 bits and the other eight the rest
+		for i := 7; i >= 0; i-- {
+			dst = append(dst, byte(v>>(8+7*i))|0x80)
+		}
+		return append(dst, byte(v))
+	}
+	for i := n - 1; i > 0; i-- {
+		dst = append(dst, byte(v>>(7*i))|0x80)
+	}
+	return append(dst, byte(v)&0x7f)
+}
+
+// Parse reads a value from the start of b and returns it with the number of
+// bytes consumed. It returns io.EOF for an empty b and io.ErrUnexpectedEOF if
+// b ends before the value does. Like SQLite it accepts encodings padded with
+// leading zero groups
+func Parse(b []byte) (value uint64, consumed int, err error) {
+	if len(b) == 0 {
+		return 0, 0, io.EOF
+	}
+	for i := 0; i < MaxLen-1; i++ {
+		if i == len(b) {
+			return 0, 0, io.ErrUnexpectedEOF
+		}
+		value = value<<7 | uint64(b[i]&0x7f)
+		if b[i] < 0x80 {
+			return value, i + 1, nil
+		}
+	}
+	if len(b) < MaxLen {
+		return 0, 0, io.ErrUnexpectedEOF
+	}
+	return value<<8 | uint64(b[MaxLen-1]), MaxLen, nil
+}
+
+// OverflowError is reported when a SQLite varint is too large for the 62 bits
+// of a QUIC varint. It matches varint.ErrOverflow under errors.Is. ToQUIC
+// returns it wrapped in a *varint.OffsetError carrying the position of the
+// value in the input
+type OverflowError struct {
+	Value uint64
+}
+
+func (e *OverflowError) Error() string {
+	return fmt.Sprintf("sqlite varint value %d overflows 62 bits", e.Value)
+}
+
+func (e *OverflowError) Is(target error) bool {
+	return target == varint.ErrOverflow
+}
+
+// FromQUIC appends to dst the SQLite encodings of the back-to-back QUIC
+// varints in src. Every QUIC value fits. A truncated input is reported as a
+// *varint.OffsetError, with dst holding the values converted before it
+func FromQUIC(dst, src []byte) ([]byte, error) {
+	for off := 0; off < len(src); {
+		v, n, err := varint.Parse(src[off:])
+		if err != nil {
+			return dst, &varint.OffsetError{Offset: off, Err: err}
+		}
+		dst = Append(dst, v)
+		off += n
+	}
+	return dst, nil
+}
+
+// ToQUIC appends to dst the QUIC varint encodings of the back-to-back SQLite
+// varints in src. A value above varint.Max is reported as an *OverflowError
+// and a truncated one as io.ErrUnexpectedEOF, either way wrapped in a
+// *varint.OffsetError carrying its offset in src, with dst holding the values
+// converted before it
+func ToQUIC(dst, src []byte) ([]byte, error) {
+	for off := 0; off < len(src); {
+		v, n, err := Parse(src[off:])
+		if err == nil && v > varint.Max {
+			err = &OverflowError{Value: v}
+		}
+		if err != nil {
+			return dst, &varint.OffsetError{Offset: off, Err: err}
+		}
+		dst = varint.Append(dst, v)
+		off += n
+	}
+	return dst, nil
+}
@@ -0,0 +1,125 @@
+package sqlitevarint
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"testing"
+
+	"flux/encoding/internal/testcorpus"
+	"flux/encoding/varint"
+)
+
+// -------------------------
+// Append / Parse
+// -------------------------
+
+// vectors follow the varint rules of the SQLite file format document: seven bits
+// per byte while the high bit is set, and all eight bits of a ninth byte
+var vectors = []struct {
+	v    uint64
+	want []byte
+}{
+	{0, []byte{0x00}},
+	{1, []byte{0x01}},
+	{127, []byte{0x7f}},
+	{128, []byte{0x81, 0x00}},
+	{240, []byte{0x81, 0x70}},
+	{2287, []byte{0x91, 0x6f}},
+	{16383, []byte{0xff, 0x7f}},
+	{16384, []byte{0x81, 0x80, 0x00}},
+	{1<<21 - 1, []byte{0xff, 0xff, 0x7f}},
+	{1<<56 - 1, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}},
+	// From 2^56 on the ninth byte carries the low 8 bits
+	{1 << 56, []byte{0x80, 0xc0, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x00}},
+	{1<<56 | 0xff, []byte{0x80, 0xc0, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0xff}},
+	{varint.Max, []byte{0x9f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+	{math.MaxInt64, []byte{0xbf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+	// Negative integers as SQLite stores them, in two's complement
+	{1 << 63, []byte{0xc0, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x00}},
+	{math.MaxUint64, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+}
+
+func TestVectors(t *testing.T) {
+	for _, c := range vectors {
+		if got := Append(nil, c.v); !bytes.Equal(got, c.want) {
+			t.Fatalf("Append(%d) = %x, want %x", c.v, got, c.want)
+		}
+		if Len(c.v) != len(c.want) {
+			t.Fatalf("Len(%d) = %d, want %d", c.v, Len(c.v), len(c.want))
+		}
+		// Trailing input must be left alone
+		if v, n, err := Parse(append(c.want, 0xff)); v != c.v || n != len(c.want) || err != nil {
+			t.Fatalf("Parse(%x) = %d, %d, %v; want %d", c.want, v, n, err, c.v)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	vs := append(testcorpus.Skewed(2000), testcorpus.Uniform(2000)...)
+	for k := 1; k < 64; k++ {
+		vs = append(vs, 1<<k-1, 1<<k)
+	}
+	for _, v := range vs {
+		b := Append(nil, v)
+		if got, n, err := Parse(b); got != v || n != len(b) || err != nil {
+			t.Fatalf("Parse(Append(%d)) = %d, %d, %v", v, got, n, err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, _, err := Parse(nil); err != io.EOF {
+		t.Fatalf("Parse(empty) error = %v, want io.EOF", err)
+	}
+	full := Append(nil, math.MaxUint64)
+	for cut := 1; cut < len(full); cut++ {
+		if _, _, err := Parse(full[:cut]); err != io.ErrUnexpectedEOF {
+			t.Fatalf("Parse(%x) error = %v, want io.ErrUnexpectedEOF", full[:cut], err)
+		}
+	}
+	// Leading zero groups are accepted, as by SQLite
+	if v, n, err := Parse([]byte{0x80, 0x80, 0x05}); v != 5 || n != 3 || err != nil {
+		t.Fatalf("Parse(padded) = %d, %d, %v; want 5, 3", v, n, err)
+	}
+}
+
+// -------------------------
+// Conversion
+// -------------------------
+
+func TestConvert(t *testing.T) {
+	vs := append(testcorpus.Skewed(3000), 0, varint.Max, 1<<56)
+	quic := varint.AppendMany(nil, vs...)
+	var sq []byte
+	for _, v := range vs {
+		sq = Append(sq, v)
+	}
+	if got, err := FromQUIC(nil, quic); err != nil || !bytes.Equal(got, sq) {
+		t.Fatalf("FromQUIC = %d bytes, %v; want %d bytes", len(got), err, len(sq))
+	}
+	if got, err := ToQUIC(nil, sq); err != nil || !bytes.Equal(got, quic) {
+		t.Fatalf("ToQUIC = %d bytes, %v; want %d bytes", len(got), err, len(quic))
+	}
+
+	// A value past 62 bits stops ToQUIC at its offset
+	over := Append(Append(nil, 9), varint.Max+1)
+	got, err := ToQUIC(nil, over)
+	var offErr *varint.OffsetError
+	var ovErr *OverflowError
+	if !errors.Is(err, varint.ErrOverflow) || !errors.As(err, &offErr) || !errors.As(err, &ovErr) ||
+		offErr.Offset != 1 || ovErr.Value != varint.Max+1 {
+		t.Fatalf("ToQUIC(overflow) error = %v, want value %d at byte 1", err, uint64(varint.Max+1))
+	}
+	if !bytes.Equal(got, []byte{9}) {
+		t.Fatalf("ToQUIC(overflow) = %x, want the value before it", got)
+	}
+
+	if _, err := ToQUIC(nil, sq[:len(sq)-1]); !errors.Is(err, io.ErrUnexpectedEOF) || !errors.As(err, &offErr) {
+		t.Fatalf("ToQUIC(truncated) error = %v", err)
+	}
+	if _, err := FromQUIC(nil, quic[:len(quic)-1]); !errors.Is(err, io.ErrUnexpectedEOF) || !errors.As(err, &offErr) {
+		t.Fatalf("FromQUIC(truncated) error = %v", err)
+	}
+}
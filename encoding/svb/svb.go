@@ -0,0 +1,157 @@
+package svb
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"slices"
+
+	"flux/encoding/varint"
+)
+
+// Stream VByte keeps the lengths and the bytes of uint32 values in separate
+// streams. Each control byte describes four values: bits 2i..2i+1 hold the
+// byte length minus one of value i. The data stream holds the values in order,
+// each little-endian in 1 to 4 bytes. Since no value carries its own length,
+// the count of values has to travel alongside the streams; the framed format
+// puts it in front as a QUIC varint. None of this is the varint wire format,
+// and the two cannot be read as each other
+
+// ErrLength is returned when the control and data streams do not agree with
+// each other or with the value count
+var ErrLength = errors.New("svb: stream lengths do not match the value count")
+
+// masks[l] keeps the low l bytes of a 32-bit word
+var masks = [5]uint32{0, 0xFF, 0xFFFF, 0xFFFFFF, 0xFFFFFFFF}
+
+// groupLen[c] is the number of data bytes described by control byte c
+var groupLen = func() (t [256]uint8) {
+	for c := range t {
+		t[c] = uint8(4 + c&3 + c>>2&3 + c>>4&3 + c>>6)
+	}
+	return t
+}()
+
+func byteLen(v uint32) int {
+	return max(bits.Len32(v)+7, 8) >> 3
+}
+
+// ControlLen returns the length of the control stream for n values
+func ControlLen(n int) int {
+	return (n + 3) / 4
+}
+
+// EncodeAll encodes vs into a control stream and a data stream
+func EncodeAll(vs []uint32) (ctrl, data []byte) {
+	ctrl = make([]byte, ControlLen(len(vs)))
+	total := 0
+	for i, v := range vs {
+		l := byteLen(v)
+		ctrl[i>>2] |= byte(l-1) << (2 * (i & 3))
+		total += l
+	}
+	// Every value is stored as a full word and then overlapped by the next,
+	// so data needs 3 bytes of slack past the last value
+	data = make([]byte, total+3)
+	off := 0
+	for i, v := range vs {
+		binary.LittleEndian.PutUint32(data[off:], v)
+		off += int(ctrl[i>>2]>>(2*(i&3))&3) + 1
+	}
+	return ctrl, data[:total:total]
+}
+
+// DataLen returns the length of the data stream that ctrl describes for n
+// values. It returns ErrLength if ctrl is not ControlLen(n) bytes long
+func DataLen(ctrl []byte, n int) (int, error) {
+	if n < 0 || len(ctrl) != ControlLen(n) {
+		return 0, ErrLength
+	}
+	total := 0
+	full := n / 4
+	for _, c := range ctrl[:full] {
+		total += int(groupLen[c])
+	}
+	for i := 0; i < n%4; i++ {
+		total += int(ctrl[full]>>(2*i)&3) + 1
+	}
+	return total, nil
+}
+
+// DecodeAll decodes n values from the streams written by EncodeAll and
+// appends them to dst. It returns ErrLength, without appending anything, if
+// ctrl does not describe exactly n values stored in data
+func DecodeAll(dst []uint32, n int, ctrl, data []byte) ([]uint32, error) {
+	total, err := DataLen(ctrl, n)
+	if err != nil {
+		return dst, err
+	}
+	if total != len(data) {
+		return dst, ErrLength
+	}
+	dst = slices.Grow(dst, n)
+	out := dst[len(dst) : len(dst)+n]
+	off := 0
+	i := 0
+	for _, c := range ctrl[:n/4] {
+		if off+16 > len(data) {
+			break
+		}
+		// Every value of the group can be loaded as a full word without
+		// running off data
+		o := out[i : i+4]
+		l0, l1, l2, l3 := int(c&3)+1, int(c>>2&3)+1, int(c>>4&3)+1, int(c>>6)+1
+		o[0] = binary.LittleEndian.Uint32(data[off:]) & masks[l0]
+		off += l0
+		o[1] = binary.LittleEndian.Uint32(data[off:]) & masks[l1]
+		off += l1
+		o[2] = binary.LittleEndian.Uint32(data[off:]) & masks[l2]
+		off += l2
+		o[3] = binary.LittleEndian.Uint32(data[off:]) & masks[l3]
+		off += l3
+		i += 4
+	}
+	for ; i < n; i++ {
+		l := int(ctrl[i>>2]>>(2*(i&3))&3) + 1
+		var v uint32
+		for j := l - 1; j >= 0; j-- {
+			v = v<<8 | uint32(data[off+j])
+		}
+		out[i] = v
+		off += l
+	}
+	return dst[:len(dst)+n], nil
+}
+
+// AppendFramed encodes vs as a single buffer, the value count as a QUIC
+// varint followed by the control and data streams, and appends it to dst
+func AppendFramed(dst []byte, vs []uint32) []byte {
+	ctrl, data := EncodeAll(vs)
+	dst = varint.Append(dst, uint64(len(vs)))
+	dst = append(dst, ctrl...)
+	return append(dst, data...)
+}
+
+// DecodeFramed decodes a buffer written by AppendFramed, appending the values
+// to dst. It returns ErrLength if b is too short for the count it declares or
+// holds bytes beyond the values
+func DecodeFramed(dst []uint32, b []byte) ([]uint32, error) {
+	count, off, err := varint.Parse(b)
+	if err != nil {
+		return dst, err
+	}
+	// Every value needs at least one data byte plus a quarter control byte
+	if count > uint64(len(b)-off) {
+		return dst, ErrLength
+	}
+	n := int(count)
+	ctrl := b[off : off+ControlLen(n)]
+	total, err := DataLen(ctrl, n)
+	if err != nil {
+		return dst, err
+	}
+	if len(b)-off-len(ctrl) != total {
+		return dst, ErrLength
+	}
+	return DecodeAll(dst, n, ctrl, b[off+len(ctrl):])
+}
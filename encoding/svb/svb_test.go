@@ -0,0 +1,139 @@
+package svb
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"flux/encoding/internal/testcorpus"
+	"flux/encoding/varint"
+)
+
+var sinkInt int
+
+// -------------------------
+// EncodeAll / DecodeAll
+// -------------------------
+
+func TestEncodeAll(t *testing.T) {
+	ctrl, data := EncodeAll([]uint32{1, 256, 65536, math.MaxUint32, 7})
+	wantCtrl := []byte{0b11_10_01_00, 0b00}
+	wantData := []byte{
+		0x01,
+		0x00, 0x01,
+		0x00, 0x00, 0x01,
+		0xFF, 0xFF, 0xFF, 0xFF,
+		0x07,
+	}
+	if !bytes.Equal(ctrl, wantCtrl) || !bytes.Equal(data, wantData) {
+		t.Fatalf("EncodeAll = %x, %x; want %x, %x", ctrl, data, wantCtrl, wantData)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	sizes := []int{0, 1, 2, 3, 4, 5, 7, 8, 9, 15, 16, 17, 1000}
+	for _, n := range sizes {
+		vs := testcorpus.Uint32s(rng, n)
+		testcorpus.CheckRoundTrip(t, vs,
+			func(vs []uint32) []byte { return AppendFramed(nil, vs) },
+			func(b []byte) ([]uint32, error) { return DecodeFramed(nil, b) },
+		)
+		ctrl, data := EncodeAll(vs)
+		got, err := DecodeAll([]uint32{42}, n, ctrl, data)
+		if err != nil || !slices.Equal(got, append([]uint32{42}, vs...)) {
+			t.Fatalf("DecodeAll of %d values = %v, %v", n, got, err)
+		}
+	}
+}
+
+func TestDecodeAllErrors(t *testing.T) {
+	ctrl, data := EncodeAll([]uint32{1, 2, 3, 4, 5, 1 << 20})
+	cases := []struct {
+		name       string
+		n          int
+		ctrl, data []byte
+	}{
+		{"short data", 6, ctrl, data[:len(data)-1]},
+		{"long data", 6, ctrl, append(slices.Clone(data), 0)},
+		{"short control", 6, ctrl[:1], data},
+		{"count", 5, ctrl, data},
+		{"negative count", -1, nil, nil},
+	}
+	for _, c := range cases {
+		if got, err := DecodeAll(nil, c.n, c.ctrl, c.data); err != ErrLength || len(got) != 0 {
+			t.Fatalf("%s: DecodeAll = %v, %v; want ErrLength", c.name, got, err)
+		}
+	}
+}
+
+func TestDecodeFramedErrors(t *testing.T) {
+	b := AppendFramed(nil, []uint32{1, 2, 3, 4, 5, 1 << 20})
+	if _, err := DecodeFramed(nil, b[:len(b)-1]); err != ErrLength {
+		t.Fatalf("DecodeFramed(truncated) error = %v, want ErrLength", err)
+	}
+	if _, err := DecodeFramed(nil, append(slices.Clone(b), 0)); err != ErrLength {
+		t.Fatalf("DecodeFramed(trailing) error = %v, want ErrLength", err)
+	}
+	if _, err := DecodeFramed(nil, varint.Append(nil, 1<<40)); err != ErrLength {
+		t.Fatalf("DecodeFramed(huge count) error = %v, want ErrLength", err)
+	}
+	if _, err := DecodeFramed(nil, nil); err != io.EOF {
+		t.Fatalf("DecodeFramed(nil) error = %v, want io.EOF", err)
+	}
+}
+
+// -------------------------
+// Benchmarks
+// -------------------------
+
+// BenchmarkDecodeAll decodes the same values from Stream VByte streams and
+// from back-to-back QUIC varints
+func BenchmarkDecodeAll(b *testing.B) {
+	corpora := map[string][]uint32{
+		"even": testcorpus.Uint32s(rand.New(rand.NewPCG(3, 4)), 1<<20),
+		"skewed": func() []uint32 {
+			vs := make([]uint32, 1<<20)
+			for i, v := range testcorpus.Skewed(len(vs)) {
+				vs[i] = uint32(v)
+			}
+			return vs
+		}(),
+	}
+	for name, vs := range corpora {
+		b.Run(name+"/svb", func(b *testing.B) {
+			ctrl, data := EncodeAll(vs)
+			dst := make([]uint32, 0, len(vs))
+			b.SetBytes(int64(len(vs) * 4))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var err error
+				dst, err = DecodeAll(dst[:0], len(vs), ctrl, data)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+			sinkInt = len(dst)
+		})
+		b.Run(name+"/varint.DecodeAll", func(b *testing.B) {
+			var buf []byte
+			for _, v := range vs {
+				buf = varint.Append(buf, uint64(v))
+			}
+			dst := make([]uint64, 0, len(vs))
+			b.SetBytes(int64(len(vs) * 4))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var err error
+				dst, err = varint.DecodeAll(dst[:0], buf)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+			sinkInt = len(dst)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package varint
+
+// Arena hands out byte slices carved from one large backing array, so that
+// encoding many short-lived frames costs no allocation per frame. Reset
+// reclaims everything at once: every slice handed out before it, including
+// the output of Encoders over the Arena, is invalid afterwards and will see its
+// bytes zeroed and then overwritten by later allocations. An Arena is not safe
+// for concurrent use
+type Arena struct {
+	buf []byte // allocations are buf[:len(buf)]; the rest is free
+}
+
+// NewArena returns an Arena with a backing array of size bytes. A
+// non-positive size selects a default of 64 KiB
+func NewArena(size int) *Arena {
+	if size <= 0 {
+		size = defaultArenaSize
+	}
+	return &Arena{buf: make([]byte, 0, size)}
+}
+
+const defaultArenaSize = 64 << 10
+
+// Alloc returns a zeroed slice of n bytes whose capacity is also n, so
+// appending to it never overwrites a neighbouring allocation. When the backing
+// array is full a new one of at least twice the size is allocated; slices
+// from the old one stay valid until Reset
+func (a *Arena) Alloc(n int) []byte {
+	if n > cap(a.buf)-len(a.buf) {
+		a.buf = make([]byte, 0, max(2*cap(a.buf), n, defaultArenaSize))
+	}
+	off := len(a.buf)
+	a.buf = a.buf[:off+n]
+	return a.buf[off : off+n : off+n]
+}
+
+// Len returns the number of bytes allocated from the current backing array
+// since the last Reset
+func (a *Arena) Len() int {
+	return len(a.buf)
+}
+
+// Reset reclaims every allocation at once, keeping the current backing array
+// for reuse. All slices returned by Alloc so far become invalid
+func (a *Arena) Reset() {
+	clear(a.buf)
+	a.buf = a.buf[:0]
+}
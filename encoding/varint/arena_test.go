@@ -0,0 +1,101 @@
+package varint
+
+import (
+	"bytes"
+	"testing"
+	"unsafe"
+)
+
+// -------------------------
+// Arena
+// -------------------------
+
+func TestArenaAlloc(t *testing.T) {
+	a := NewArena(64)
+	p := a.Alloc(10)
+	q := a.Alloc(20)
+	if len(p) != 10 || cap(p) != 10 || len(q) != 20 || a.Len() != 30 {
+		t.Fatalf("Alloc gave len/cap %d/%d and %d/%d, arena len %d", len(p), cap(p), len(q), cap(q), a.Len())
+	}
+	// Appending to one allocation must not spill into the next
+	copy(q, bytes.Repeat([]byte{7}, 20))
+	p = append(p[:10], 1)
+	if q[0] != 7 {
+		t.Fatal("append to an allocation overwrote its neighbour")
+	}
+	// Outgrowing the backing array moves on to a new one; old slices survive
+	big := a.Alloc(100)
+	if len(big) != 100 || q[19] != 7 {
+		t.Fatalf("Alloc(100) from a full arena = %d bytes", len(big))
+	}
+	if a.Alloc(0) == nil {
+		t.Fatal("Alloc(0) = nil, want an empty slice")
+	}
+}
+
+func TestArenaReset(t *testing.T) {
+	// Use after Reset is a bug the compiler cannot catch; what the Arena does
+	// guarantee is that stale slices lose their contents and alias new ones
+	a := NewArena(64)
+	stale := a.Alloc(8)
+	copy(stale, "deadbeef")
+	a.Reset()
+	if a.Len() != 0 || !bytes.Equal(stale, make([]byte, 8)) {
+		t.Fatalf("after Reset stale slice holds %q, arena len %d", stale, a.Len())
+	}
+	fresh := a.Alloc(8)
+	if unsafe.SliceData(fresh) != unsafe.SliceData(stale) {
+		t.Fatal("Reset did not reuse the backing array")
+	}
+	copy(fresh, "newbytes")
+	if string(stale) != "newbytes" {
+		t.Fatalf("stale slice = %q, want it to alias the new allocation", stale)
+	}
+}
+
+func TestEncoderArena(t *testing.T) {
+	a := NewArena(256)
+	var e Encoder
+	e.ResetArena(a, 4)
+	e.Uint64(7).Int64(-300).Bytes([]byte("payload")).String("name")
+	for _, v := range []uint64{1, 2, 3} {
+		e.Uint64(v)
+	}
+	got, err := e.Uint64(Max).Finish()
+	if err != nil || !bytes.Equal(got, frame()) {
+		t.Fatalf("Encoder over an arena produced %x, %v; want %x", got, err, frame())
+	}
+	// Growth came from the arena, not the heap
+	arena := a.buf[:cap(a.buf)]
+	start := uintptr(unsafe.Pointer(unsafe.SliceData(arena)))
+	if p := uintptr(unsafe.Pointer(unsafe.SliceData(got))); p < start || p >= start+uintptr(len(arena)) {
+		t.Fatal("Encoder output is not in the arena")
+	}
+
+	next := a.Alloc(8)
+	copy(next, "neighbor")
+	e.ResetArena(a, 16)
+	out, _ := e.Uint64(1).Finish()
+	if string(next) != "neighbor" || !bytes.Equal(out, []byte{1}) {
+		t.Fatalf("a second frame disturbed other allocations: %q, %x", next, out)
+	}
+}
+
+func TestEncoderArenaAllocs(t *testing.T) {
+	a := NewArena(0)
+	var e Encoder
+	payload := []byte("0123456789abcdef")
+	allocs := testing.AllocsPerRun(100, func() {
+		for i := range 100 {
+			e.ResetArena(a, 32)
+			e.Uint64(7).Uint64(uint64(i)).Int64(-42).Bytes(payload).String("name")
+			if _, err := e.Finish(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		a.Reset()
+	})
+	if allocs != 0 {
+		t.Fatalf("encoding frames over an arena allocated %v times per run", allocs)
+	}
+}
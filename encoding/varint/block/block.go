@@ -0,0 +1,215 @@
+package block
+
+import (
+	"errors"
+	"io"
+	"sort"
+
+	"flux/encoding/varint"
+)
+
+// A block stream is a sequence of blocks, each a varint payload length in
+// bytes, a varint value count and then the payload: that many back-to-back
+// varints. A reader can step over a whole block from its header alone, so
+// finding the i-th value only decodes the block that holds it
+
+// MaxBlockValues is the largest number of values a Writer puts in one block.
+// A Reader rejects blocks whose payload could not have been written with it
+const MaxBlockValues = 1 << 16
+
+const (
+	defaultBlockValues = 128
+	maxPayloadLen      = MaxBlockValues * varint.MaxLen
+)
+
+// ErrCorrupt is returned by Reader when a block header or payload is
+// inconsistent
+var ErrCorrupt = errors.New("block: corrupt block")
+
+// Writer groups values into blocks and writes each block to an io.Writer with
+// a single Write. Call Flush when done. The first error from the underlying
+// writer is sticky: every later call returns it
+type Writer struct {
+	wr      io.Writer
+	size    int    // values per block
+	payload []byte // encoded values of the pending block
+	count   int    // values in payload
+	frame   []byte // header and payload of the block being written
+	err     error
+}
+
+// NewWriter returns a Writer over w that puts n values in each block. A
+// non-positive n selects a default of 128; larger values are capped at
+// MaxBlockValues
+func NewWriter(w io.Writer, n int) *Writer {
+	if n <= 0 {
+		n = defaultBlockValues
+	}
+	return &Writer{wr: w, size: min(n, MaxBlockValues)}
+}
+
+// Add appends v to the pending block, writing the block out once it is full.
+// It returns a *varint.ValueTooLargeError if v exceeds varint.Max
+func (w *Writer) Add(v uint64) error {
+	if w.err != nil {
+		return w.err
+	}
+	if v > varint.Max {
+		return &varint.ValueTooLargeError{Num: v}
+	}
+	w.payload = varint.Append(w.payload, v)
+	w.count++
+	if w.count == w.size {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush writes the pending block, which may hold fewer values than the block
+// size. Flushing with no pending values is a no-op
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.count == 0 {
+		return nil
+	}
+	w.frame = varint.Append(w.frame[:0], uint64(len(w.payload)))
+	w.frame = varint.Append(w.frame, uint64(w.count))
+	w.frame = append(w.frame, w.payload...)
+	n, err := w.wr.Write(w.frame)
+	if err == nil && n < len(w.frame) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		w.err = err
+		return err
+	}
+	w.payload = w.payload[:0]
+	w.count = 0
+	return nil
+}
+
+// blockPos locates a block whose header has been read
+type blockPos struct {
+	off   int64  // offset of the payload
+	len   int    // payload length in bytes
+	first uint64 // index of the block's first value in the stream
+	count uint64
+}
+
+// Reader reads values from a block stream. Headers are read lazily and kept,
+// so seeking back to a block already seen costs no header reads, and seeking
+// past unread blocks reads only their headers
+type Reader struct {
+	rs      io.ReadSeeker
+	blocks  []blockPos // every block whose header has been read, in order
+	end     int64      // offset just past the last block in blocks
+	total   uint64     // values in blocks
+	cur     int        // index in blocks of the loaded block, or -1
+	payload []byte     // payload of the loaded block
+	off     int        // read position in payload
+}
+
+// NewReader returns a Reader positioned at the first value of the block
+// stream in rs, which starts at offset 0
+func NewReader(rs io.ReadSeeker) *Reader {
+	return &Reader{rs: rs, cur: -1}
+}
+
+// Next returns the next value, or io.EOF after the last one
+func (r *Reader) Next() (uint64, error) {
+	for r.off >= len(r.payload) {
+		if err := r.load(r.cur + 1); err != nil {
+			return 0, err
+		}
+	}
+	v, n, _ := varint.Parse(r.payload[r.off:])
+	r.off += n
+	return v, nil
+}
+
+// SeekToValue positions the Reader so that Next returns the value with index
+// i in the stream. It returns io.EOF if the stream holds i values or fewer.
+// Only the block holding the value is read and decoded; blocks before it not
+// yet seen cost a header read each
+func (r *Reader) SeekToValue(i uint64) error {
+	for i >= r.total {
+		if err := r.readHeader(); err != nil {
+			return err
+		}
+	}
+	k := sort.Search(len(r.blocks), func(k int) bool {
+		return r.blocks[k].first+r.blocks[k].count > i
+	})
+	if k != r.cur {
+		if err := r.load(k); err != nil {
+			return err
+		}
+	}
+	off, err := varint.Skip(r.payload, int(i-r.blocks[k].first))
+	if err != nil {
+		return ErrCorrupt
+	}
+	r.off = off
+	return nil
+}
+
+// load reads the payload of block k, reading its header first if needed
+func (r *Reader) load(k int) error {
+	if k == len(r.blocks) {
+		if err := r.readHeader(); err != nil {
+			return err
+		}
+	}
+	pos := r.blocks[k]
+	if _, err := r.rs.Seek(pos.off, io.SeekStart); err != nil {
+		return err
+	}
+	if cap(r.payload) < pos.len {
+		r.payload = make([]byte, pos.len)
+	}
+	payload := r.payload[:pos.len]
+	if _, err := io.ReadFull(r.rs, payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	if n, err := varint.Count(payload); err != nil || uint64(n) != pos.count {
+		return ErrCorrupt
+	}
+	r.payload, r.off, r.cur = payload, 0, k
+	return nil
+}
+
+// readHeader reads the header of the block at r.end and records it. It
+// returns io.EOF if the stream ends cleanly before the header
+func (r *Reader) readHeader() error {
+	if _, err := r.rs.Seek(r.end, io.SeekStart); err != nil {
+		return err
+	}
+	length, n1, err := varint.ReadFrom(r.rs)
+	if err != nil {
+		return err
+	}
+	count, n2, err := varint.ReadFrom(r.rs)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	if length > maxPayloadLen {
+		return &varint.FrameSizeError{Declared: length, Limit: maxPayloadLen}
+	}
+	// Every value takes between 1 and MaxLen bytes
+	if count == 0 || count > length || length > count*varint.MaxLen {
+		return ErrCorrupt
+	}
+	off := r.end + int64(n1+n2)
+	r.blocks = append(r.blocks, blockPos{off: off, len: int(length), first: r.total, count: count})
+	r.end = off + int64(length)
+	r.total += count
+	return nil
+}
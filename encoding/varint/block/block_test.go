@@ -0,0 +1,174 @@
+package block
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"flux/encoding/varint"
+)
+
+// countingReadSeeker counts the bytes read through it
+type countingReadSeeker struct {
+	rs io.ReadSeeker
+	n  int
+}
+
+func (c *countingReadSeeker) Read(p []byte) (int, error) {
+	n, err := c.rs.Read(p)
+	c.n += n
+	return n, err
+}
+
+func (c *countingReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	return c.rs.Seek(offset, whence)
+}
+
+// values returns n values of mixed widths; value i is recoverable from i
+func values(n int) []uint64 {
+	vs := make([]uint64, n)
+	for i := range vs {
+		vs[i] = uint64(i) * uint64(i) * 977 % varint.Max
+	}
+	return vs
+}
+
+func encode(t *testing.T, vs []uint64, size int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewWriter(&buf, size)
+	for _, v := range vs {
+		if err := w.Add(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// -------------------------
+// Writer / Reader
+// -------------------------
+
+func TestRoundTrip(t *testing.T) {
+	vs := values(1000)
+	for _, size := range []int{0, 1, 7, 1000, 5000} {
+		r := NewReader(bytes.NewReader(encode(t, vs, size)))
+		for i, want := range vs {
+			if v, err := r.Next(); err != nil || v != want {
+				t.Fatalf("size %d: Next #%d = %d, %v; want %d", size, i, v, err, want)
+			}
+		}
+		if _, err := r.Next(); err != io.EOF {
+			t.Fatalf("size %d: Next at end error = %v, want io.EOF", size, err)
+		}
+	}
+	if _, err := NewReader(bytes.NewReader(nil)).Next(); err != io.EOF {
+		t.Fatalf("Next on an empty stream error = %v, want io.EOF", err)
+	}
+}
+
+func TestWriterBlocks(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 2)
+	for _, v := range []uint64{1, 300, 5} {
+		w.Add(v)
+	}
+	// The full block is written by Add, the partial one only by Flush
+	want := []byte{3, 2, 1, 0x41, 0x2c}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("after Add: %x, want %x", buf.Bytes(), want)
+	}
+	w.Flush()
+	w.Flush()
+	want = append(want, 1, 1, 5)
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("after Flush: %x, want %x", buf.Bytes(), want)
+	}
+	if err := w.Add(varint.Max + 1); !errors.Is(err, varint.ErrValueTooLarge) {
+		t.Fatalf("Add(Max+1) error = %v", err)
+	}
+}
+
+func TestSeekToValue(t *testing.T) {
+	const size = 64
+	vs := values(10000)
+	stream := encode(t, vs, size)
+	headers := len(stream) - len(varint.AppendMany(nil, vs...))
+	// The most a single block's payload can take
+	const maxBlock = size * varint.MaxLen
+
+	for _, i := range []int{0, 1, 63, 64, 5000, 9999, 4321} {
+		c := &countingReadSeeker{rs: bytes.NewReader(stream)}
+		r := NewReader(c)
+		if err := r.SeekToValue(uint64(i)); err != nil {
+			t.Fatalf("SeekToValue(%d) error = %v", i, err)
+		}
+		if v, err := r.Next(); err != nil || v != vs[i] {
+			t.Fatalf("SeekToValue(%d): Next = %d, %v; want %d", i, v, err, vs[i])
+		}
+		if c.n > headers+maxBlock {
+			t.Fatalf("SeekToValue(%d) read %d bytes, want at most %d of headers and one block", i, c.n, headers+maxBlock)
+		}
+
+		// Seeking back to a block whose header is known reads only its payload
+		j := (i * 7919) % (i + 1)
+		c.n = 0
+		if err := r.SeekToValue(uint64(j)); err != nil {
+			t.Fatalf("SeekToValue(%d) error = %v", j, err)
+		}
+		if v, err := r.Next(); err != nil || v != vs[j] {
+			t.Fatalf("SeekToValue(%d): Next = %d, %v; want %d", j, v, err, vs[j])
+		}
+		if c.n > maxBlock {
+			t.Fatalf("SeekToValue(%d) after SeekToValue(%d) read %d bytes, want at most %d", j, i, c.n, maxBlock)
+		}
+	}
+}
+
+func TestSeekToValueThenNext(t *testing.T) {
+	vs := values(300)
+	r := NewReader(bytes.NewReader(encode(t, vs, 16)))
+	if err := r.SeekToValue(250); err != nil {
+		t.Fatal(err)
+	}
+	for i := 250; i < len(vs); i++ {
+		if v, err := r.Next(); err != nil || v != vs[i] {
+			t.Fatalf("Next #%d = %d, %v; want %d", i, v, err, vs[i])
+		}
+	}
+	if err := r.SeekToValue(300); err != io.EOF {
+		t.Fatalf("SeekToValue past the end error = %v, want io.EOF", err)
+	}
+	if err := r.SeekToValue(3); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := r.Next(); err != nil || v != vs[3] {
+		t.Fatalf("Next after seeking back = %d, %v; want %d", v, err, vs[3])
+	}
+}
+
+func TestReaderCorrupt(t *testing.T) {
+	cases := []struct {
+		name   string
+		stream []byte
+		want   error
+	}{
+		{"count over length", []byte{1, 2, 5}, ErrCorrupt},
+		{"empty block", []byte{0, 0}, ErrCorrupt},
+		{"length over count", []byte{9, 1, 5, 5, 5, 5, 5, 5, 5, 5, 5}, ErrCorrupt},
+		{"payload disagrees", []byte{2, 1, 5, 5}, ErrCorrupt},
+		{"truncated header", []byte{2}, io.ErrUnexpectedEOF},
+		{"truncated payload", []byte{2, 2, 5}, io.ErrUnexpectedEOF},
+		{"oversized", varint.AppendMany(nil, maxPayloadLen+1, 1), varint.ErrLimitExceeded},
+	}
+	for _, c := range cases {
+		_, err := NewReader(bytes.NewReader(c.stream)).Next()
+		if !errors.Is(err, c.want) {
+			t.Fatalf("%s: Next error = %v, want %v", c.name, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package varint
+
+import (
+	"slices"
+)
+
+// bulkDecode, when set by an architecture-specific file, decodes whole
+// varints of any width from the start of b into dst and returns how many
+// values it wrote and how many bytes they took. It stops once fewer than
+// bulkMin bytes or bulkMaxOut slots of dst remain, so the values it leaves
+// are for the scalar loop, and it may write to all of dst. It is nil where no
+// such kernel is available, and always with the purego build tag. The tests
+// in bulk_test.go apply to whichever kernel is selected
+var bulkDecode func(dst []uint64, b []byte) (values, consumed int)
+
+// bulkChunk bounds how far ahead of the decoded values DecodeAll grows dst
+// before handing input to a kernel
+const bulkChunk = 4096
+
+// bulkMin is the most input a bulkDecode step reads, and the shortest input
+// worth handing to it
+const bulkMin = 32
+
+// bulkMaxOut is the most values a bulkDecode step writes
+const bulkMaxOut = 32
+
+// decodeBulk hands b to bulkDecode a chunk at a time and returns the grown
+// dst and the number of bytes consumed, which is zero if there is no kernel
+func decodeBulk(dst []uint64, b []byte) ([]uint64, int) {
+	off := 0
+	for bulkDecode != nil && len(b)-off >= bulkMin {
+		m := min(len(b)-off, bulkChunk)
+		dst = slices.Grow(dst, m)
+		n := len(dst)
+		k, used := bulkDecode(dst[n:n+m], b[off:off+m])
+		if used == 0 {
+			break
+		}
+		dst = dst[:n+k]
+		off += used
+	}
+	return dst, off
+}
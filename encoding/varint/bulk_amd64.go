@@ -0,0 +1,83 @@
+//go:build !purego
+
+package varint
+
+func init() {
+	if hasAVX2() && hasBMI2() {
+		bulkDecode = bulkDecodeAVX2
+	}
+}
+
+func bulkDecodeAVX2(dst []uint64, b []byte) (values, consumed int) {
+	if len(b) < bulkMin || len(dst) < bulkMaxOut {
+		return 0, 0
+	}
+	return decodeAVX2(&dst[0], len(dst), &b[0], len(b))
+}
+
+// decodeAVX2 decodes varints of any width from src into dst, following the
+// bulkDecode contract with n and dn the lengths of src and dst. Each step
+// takes the length code every one of the next 32 bytes would have as a first
+// byte, packed into a word. If all are 1-byte values they are widened in one
+// go. Otherwise the leading run of 1-byte values is widened and the values
+// after it are decoded in pairs, following the lengths through the packed
+// codes: each pair is gathered from one 16-byte load by a VPSHUFB whose
+// control, like the mask clearing the length bits, is looked up in
+// decodeTable by the pair's two codes
+//
+//go:noescape
+func decodeAVX2(dst *uint64, dn int, src *byte, n int) (values, consumed int)
+
+// decodeTable holds a VPSHUFB control and then an AND mask for each pair of
+// length codes, the first code in the low two bits of the index. The shuffle
+// moves the big-endian bytes of each varint into the low end of its own
+// little-endian 64-bit lane and zeroes the rest; the mask then clears the
+// length bits, which land in the top byte of each value
+var decodeTable = buildDecodeTable()
+
+func buildDecodeTable() (t [2][16][16]byte) {
+	shuffle, mask := &t[0], &t[1]
+	for k := range shuffle {
+		l0, l1 := 1<<(k&3), 1<<(k>>2)
+		for j := range 8 {
+			shuffle[k][j], shuffle[k][8+j] = 0x80, 0x80
+			if j < l0 {
+				shuffle[k][j] = byte(l0 - 1 - j)
+			}
+			if j < l1 {
+				shuffle[k][8+j] = byte(l0 + l1 - 1 - j)
+			}
+			mask[k][j], mask[k][8+j] = 0xff, 0xff
+		}
+		mask[k][l0-1], mask[k][8+l1-1] = 0x3f, 0x3f
+	}
+	return t
+}
+
+// hasAVX2 reports whether the CPU supports AVX2 and the OS saves the YMM
+// registers
+func hasAVX2() bool {
+	_, _, ecx1, _ := cpuid(1, 0)
+	const osxsave, avx = 1 << 27, 1 << 28
+	if ecx1&osxsave == 0 || ecx1&avx == 0 {
+		return false
+	}
+	// XMM and YMM state must be enabled in XCR0
+	if eax, _ := xgetbv(); eax&6 != 6 {
+		return false
+	}
+	_, ebx7, _, _ := cpuid(7, 0)
+	return ebx7&(1<<5) != 0
+}
+
+// hasBMI2 reports whether the CPU supports BMI1 and BMI2, for TZCNT, PDEP,
+// SHRX and SHLX
+func hasBMI2() bool {
+	_, ebx7, _, _ := cpuid(7, 0)
+	const bmi1, bmi2 = 1 << 3, 1 << 8
+	return ebx7&bmi1 != 0 && ebx7&bmi2 != 0
+}
+
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+
+func xgetbv() (eax, edx uint32)
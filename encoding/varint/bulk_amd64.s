@@ -0,0 +1,140 @@
+//go:build !purego
+
+#include "textflag.h"
+
+// func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+TEXT ·cpuid(SB), NOSPLIT, $0-24
+	MOVL eaxArg+0(FP), AX
+	MOVL ecxArg+4(FP), CX
+	CPUID
+	MOVL AX, eax+8(FP)
+	MOVL BX, ebx+12(FP)
+	MOVL CX, ecx+16(FP)
+	MOVL DX, edx+20(FP)
+	RET
+
+// func xgetbv() (eax, edx uint32)
+TEXT ·xgetbv(SB), NOSPLIT, $0-8
+	MOVL $0, CX
+	XGETBV
+	MOVL AX, eax+0(FP)
+	MOVL DX, edx+4(FP)
+	RET
+
+// WIDEN16 zero-extends the 16 bytes at SI+AX+off to uint64s at R10+8*off
+#define WIDEN16(off) \
+	VPMOVZXBQ off(SI)(AX*1), Y4 \
+	VMOVDQU   Y4, (8*off)(R10) \
+	VPMOVZXBQ (off+4)(SI)(AX*1), Y4 \
+	VMOVDQU   Y4, (8*off+32)(R10) \
+	VPMOVZXBQ (off+8)(SI)(AX*1), Y4 \
+	VMOVDQU   Y4, (8*off+64)(R10) \
+	VPMOVZXBQ (off+12)(SI)(AX*1), Y4 \
+	VMOVDQU   Y4, (8*off+96)(R10)
+
+// func decodeAVX2(dst *uint64, dn int, src *byte, n int) (values, consumed int)
+TEXT ·decodeAVX2(SB), NOSPLIT, $0-48
+	MOVQ dst+0(FP), DI
+	MOVQ dn+8(FP), DX
+	MOVQ src+16(FP), SI
+	MOVQ n+24(FP), R13
+	XORQ AX, AX // bytes consumed
+	XORQ BX, BX // values written
+	LEAQ ·decodeTable(SB), R11
+
+loop:
+	// A step reads at most 32 bytes and writes at most 32 values
+	LEAQ 32(AX), R8
+	CMPQ R8, R13
+	JA   done
+	LEAQ 32(BX), R8
+	CMPQ R8, DX
+	JA   done
+
+	// R8 and R9 collect bits 7 and 6 of the 32 bytes, the high and low bits
+	// of the length code each byte would have as a first byte
+	VMOVDQU   (SI)(AX*1), Y2
+	VPMOVMSKB Y2, R8
+	VPADDB    Y2, Y2, Y3
+	VPMOVMSKB Y3, R9
+	LEAQ      (DI)(BX*8), R10
+	MOVL      R8, CX
+	ORL       R9, CX
+	JNZ       mixed
+
+	// 32 1-byte values
+	WIDEN16(0)
+	WIDEN16(16)
+	ADDQ $32, AX
+	ADDQ $32, BX
+	JMP  loop
+
+mixed:
+	// Interleave them into R9, holding the code of byte i in bits 2i and
+	// 2i+1. The lowest set bit gives the length of the leading run of
+	// 1-byte values
+	MOVQ   $0x5555555555555555, R14
+	PDEPQ  R14, R9, R9
+	MOVQ   $0xaaaaaaaaaaaaaaaa, R14
+	PDEPQ  R14, R8, R8
+	ORQ    R8, R9
+	TZCNTQ R9, CX
+	SHRQ   $1, CX
+
+	// Widen 16 bytes and keep the run, or all 16 if it is longer
+	WIDEN16(0)
+	CMPQ CX, $16
+	JAE  run
+	ADDQ CX, AX
+	ADDQ CX, BX
+
+	// Then decode pairs, starting with the value that ended the run, while
+	// R10, the position in the 32 bytes classified, is at most 16: both
+	// codes of a pair then lie within them, and the pair, at most 16 bytes
+	// long, within the 32 checked above. Two 1-byte values in a row likely
+	// start another run, which is left to the next step to widen
+	MOVQ  CX, R10
+	SHLQ  $1, CX
+	SHRXQ CX, R9, R9
+
+pair:
+	MOVQ  R9, CX
+	ANDQ  $3, CX          // first code
+	MOVL  $1, R8
+	SHLXQ CX, R8, R8      // first length
+	LEAQ  (R8*2), R14
+	SHRXQ R14, R9, R9
+	MOVQ  R9, R14
+	ANDQ  $3, R14         // second code
+	MOVL  $1, R15
+	SHLXQ R14, R15, R15   // second length
+	LEAQ  (CX)(R14*4), CX
+	SHLQ  $4, CX          // table offset
+	LEAQ  (R15*2), R14
+	SHRXQ R14, R9, R9
+
+	VMOVDQU (SI)(AX*1), X2
+	VPSHUFB (R11)(CX*1), X2, X2
+	VPAND   256(R11)(CX*1), X2, X2
+	VMOVDQU X2, (DI)(BX*8)
+	ADDQ    R8, AX
+	ADDQ    R15, AX
+	ADDQ    $2, BX
+	ADDQ    R8, R10
+	ADDQ    R15, R10
+	CMPQ    R10, $16
+	JA      loop
+	TESTQ   $15, R9
+	JNZ     pair
+	JMP     loop
+
+run:
+	ADDQ $16, AX
+	ADDQ $16, BX
+	JMP  loop
+
+done:
+	VZEROUPPER
+	MOVQ BX, values+32(FP)
+	MOVQ AX, consumed+40(FP)
+	RET
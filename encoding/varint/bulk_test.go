@@ -0,0 +1,111 @@
+package varint
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// -------------------------
+// Accelerated bulk decoding
+// -------------------------
+
+// withoutBulk runs f with the vector kernel disabled
+func withoutBulk(f func()) {
+	saved := bulkDecode
+	bulkDecode = nil
+	defer func() { bulkDecode = saved }()
+	f()
+}
+
+func TestBulkDecode(t *testing.T) {
+	if bulkDecode == nil {
+		t.Skip("no vector kernel on this CPU or build")
+	}
+	for _, c := range []struct {
+		name string
+		vs   []uint64
+	}{
+		{"cycled", slices.Repeat(testValues, 40)},
+		{"skewed", skewedValues(300)},
+		{"runs", bulkCorpus(300)},
+		{"uniform", uniformValues(60)},
+	} {
+		name, full := c.name, AppendMany(nil, c.vs...)
+		for n := 0; n <= min(len(full), 300); n++ {
+			b := full[:n]
+			dst := make([]uint64, n)
+			values, consumed := bulkDecode(dst, b)
+			want, err := ParseAll(b[:consumed])
+			if err != nil || values != len(want) || !slices.Equal(dst[:values], want) {
+				t.Fatalf("%s n=%d: bulkDecode = %d values over %d bytes, %v; want %v (%v)",
+					name, n, values, consumed, dst[:values], want, err)
+			}
+			// It stops only when input or room runs short
+			if n-consumed >= bulkMin && n-values >= bulkMaxOut {
+				t.Fatalf("%s n=%d: bulkDecode stopped at byte %d", name, n, consumed)
+			}
+		}
+		// A short dst bounds how far it goes
+		for room := 0; room <= 2*bulkMaxOut; room++ {
+			dst := make([]uint64, room)
+			values, _ := bulkDecode(dst, full)
+			if values > room || room >= bulkMaxOut && values < room-bulkMaxOut+1 {
+				t.Fatalf("%s: bulkDecode with room for %d wrote %d values", name, room, values)
+			}
+		}
+	}
+}
+
+// bulkCorpus mixes long runs of 1-byte values with wider values so that runs
+// end at every position within a vector
+func bulkCorpus(n int) []uint64 {
+	rng := rand.New(rand.NewPCG(7, 7))
+	vs := make([]uint64, 0, n)
+	for len(vs) < n {
+		for run := rng.IntN(200); run > 0; run-- {
+			vs = append(vs, rng.Uint64N(64))
+		}
+		vs = append(vs, testValues[2+rng.IntN(len(testValues)-2)])
+	}
+	return vs
+}
+
+func TestDecodeAllBulkMatchesScalar(t *testing.T) {
+	vs := bulkCorpus(100000)
+	b := AppendMany(nil, vs...)
+	for _, cut := range []int{len(b), len(b) - 1, len(b) - 5, 1000, 33, 31} {
+		got, err := DecodeAll(nil, b[:cut])
+		var want []uint64
+		var wantErr error
+		withoutBulk(func() { want, wantErr = DecodeAll(nil, b[:cut]) })
+		if !slices.Equal(got, want) {
+			t.Fatalf("cut %d: vector and scalar DecodeAll differ", cut)
+		}
+		if (err == nil) != (wantErr == nil) || err != nil && err.Error() != wantErr.Error() {
+			t.Fatalf("cut %d: error = %v, scalar error = %v", cut, err, wantErr)
+		}
+		if cut == len(b) && (!slices.Equal(got, vs) || err != nil) {
+			t.Fatalf("DecodeAll = %d values, %v; want the corpus", len(got), err)
+		}
+	}
+}
+
+func FuzzDecodeAllBulk(f *testing.F) {
+	f.Add(AppendMany(nil, bulkCorpus(300)...), uint8(0))
+	f.Add(make([]byte, 100), uint8(64))
+	f.Add(AppendMany(nil, slices.Repeat(testValues, 20)...), uint8(0))
+	f.Add(AppendMany(nil, uniformValues(40)...), uint8(3))
+	f.Fuzz(func(t *testing.T, b []byte, ones uint8) {
+		// Prefix a run of 1-byte values so that the kernel gets used
+		b = append(make([]byte, int(ones)), b...)
+		got, err := DecodeAll(nil, b)
+		var want []uint64
+		var wantErr error
+		withoutBulk(func() { want, wantErr = DecodeAll(nil, b) })
+		if !slices.Equal(got, want) || fmt.Sprint(err) != fmt.Sprint(wantErr) {
+			t.Fatalf("DecodeAll(%x) = %v, %v; scalar = %v, %v", b, got, err, want, wantErr)
+		}
+	})
+}
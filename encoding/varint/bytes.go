@@ -0,0 +1,78 @@
+package varint
+
+import (
+	"io"
+	"slices"
+	"unicode/utf8"
+)
+
+// AppendBytes appends p to dst prefixed with its length as a varint
+func AppendBytes(dst, p []byte) []byte {
+	dst = slices.Grow(dst, Len(uint64(len(p)))+len(p))
+	dst = Append(dst, uint64(len(p)))
+	return append(dst, p...)
+}
+
+// ParseBytes reads a length-prefixed byte slice from b and returns the payload
+// and the total number of bytes consumed. The payload aliases b. It returns
+// io.ErrUnexpectedEOF if the declared length exceeds the remaining bytes
+func ParseBytes(b []byte) (payload []byte, n int, err error) {
+	return ParseBytesMax(b, Max)
+}
+
+// ParseBytesMax is like ParseBytes but returns a *FrameSizeError if the declared
+// length exceeds max, regardless of how many bytes b holds
+func ParseBytesMax(b []byte, max uint64) (payload []byte, n int, err error) {
+	length, n, err := Parse(b)
+	if err != nil {
+		return nil, 0, err
+	}
+	if length > max {
+		return nil, 0, &FrameSizeError{Declared: length, Limit: max}
+	}
+	if length > uint64(len(b)-n) {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	end := n + int(length)
+	return b[n:end:end], end, nil
+}
+
+// AppendString appends s to dst prefixed with its length as a varint
+func AppendString(dst []byte, s string) []byte {
+	dst = slices.Grow(dst, Len(uint64(len(s)))+len(s))
+	dst = Append(dst, uint64(len(s)))
+	return append(dst, s...)
+}
+
+// ParseString reads a length-prefixed string from b. The declared length is
+// checked against maxLen before anything is allocated, and the result is a
+// copy that does not alias b
+func ParseString(b []byte, maxLen int) (string, int, error) {
+	p, n, err := ParseBytesMax(b, uint64(max(maxLen, 0)))
+	if err != nil {
+		return "", 0, err
+	}
+	return string(p), n, nil
+}
+
+// ParseStringValid is like ParseString but also returns ErrInvalidUTF8 if the
+// string is not valid UTF-8
+func ParseStringValid(b []byte, maxLen int) (string, int, error) {
+	p, n, err := ParseBytesMax(b, uint64(max(maxLen, 0)))
+	if err != nil {
+		return "", 0, err
+	}
+	if !utf8.Valid(p) {
+		return "", 0, ErrInvalidUTF8
+	}
+	return string(p), n, nil
+}
+
+// ReadBytes reads a length-prefixed byte slice from r. The declared length is
+// checked against max before the payload is allocated or read, so a peer can
+// not make the caller allocate more than max bytes. It returns io.EOF only if
+// r is at its end before the prefix, and io.ErrUnexpectedEOF if the stream
+// ends anywhere after that
+func ReadBytes(r io.Reader, max uint64) ([]byte, error) {
+	return ReadMessage(r, max, nil)
+}
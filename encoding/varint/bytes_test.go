@@ -0,0 +1,192 @@
+package varint
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+	"testing/iotest"
+)
+
+// -------------------------
+// AppendBytes / ParseBytes
+// -------------------------
+
+func TestBytesRoundTrip(t *testing.T) {
+	payloads := [][]byte{
+		{},
+		[]byte("flux"),
+		bytes.Repeat([]byte{0xAB}, 64),
+		bytes.Repeat([]byte{0xCD}, 20000),
+	}
+	for _, p := range payloads {
+		b := AppendBytes([]byte{0xAA}, p)
+		got, n, err := ParseBytes(b[1:])
+		if err != nil || !bytes.Equal(got, p) || n != len(b)-1 {
+			t.Fatalf("ParseBytes(AppendBytes(%d bytes)) = %d bytes, %d, %v", len(p), len(got), n, err)
+		}
+		if len(p) > 0 && &got[0] != &b[1+Len(uint64(len(p)))] {
+			t.Fatal("ParseBytes copied the payload")
+		}
+	}
+}
+
+func TestParseBytesTruncated(t *testing.T) {
+	b := AppendBytes(nil, []byte("hello"))
+	for cut := 1; cut < len(b); cut++ {
+		if _, _, err := ParseBytes(b[:cut]); err != io.ErrUnexpectedEOF {
+			t.Fatalf("ParseBytes(%x) error = %v, want io.ErrUnexpectedEOF", b[:cut], err)
+		}
+	}
+	// A declared length larger than the buffer, even one that overflows int
+	for _, length := range []uint64{6, 1 << 40, Max} {
+		b := append(Append(nil, length), "hello"...)
+		if _, _, err := ParseBytes(b); err != io.ErrUnexpectedEOF {
+			t.Fatalf("ParseBytes(length %d) error = %v, want io.ErrUnexpectedEOF", length, err)
+		}
+	}
+}
+
+func TestParseBytesMax(t *testing.T) {
+	b := AppendBytes(nil, []byte("hello"))
+	if got, _, err := ParseBytesMax(b, 5); err != nil || string(got) != "hello" {
+		t.Fatalf("ParseBytesMax(5) = %q, %v", got, err)
+	}
+	checkFrameSizeError(t, "ParseBytesMax(4)", func() error {
+		_, _, err := ParseBytesMax(b, 4)
+		return err
+	}, 5, 4)
+	// The limit is checked before the buffer length
+	checkFrameSizeError(t, "ParseBytesMax(huge)", func() error {
+		_, _, err := ParseBytesMax(Append(nil, 1<<40), 1024)
+		return err
+	}, 1<<40, 1024)
+}
+
+// -------------------------
+// AppendString / ParseString
+// -------------------------
+
+func TestStringRoundTrip(t *testing.T) {
+	for _, s := range []string{"", "flux", "héllo, 世界"} {
+		b := AppendString(nil, s)
+		got, n, err := ParseString(b, len(s))
+		if err != nil || got != s || n != len(b) {
+			t.Fatalf("ParseString(AppendString(%q)) = %q, %d, %v", s, got, n, err)
+		}
+		got, _, err = ParseStringValid(b, len(s))
+		if err != nil || got != s {
+			t.Fatalf("ParseStringValid(AppendString(%q)) = %q, %v", s, got, err)
+		}
+	}
+}
+
+func TestParseStringDoesNotAlias(t *testing.T) {
+	b := AppendString(nil, "hello")
+	s, _, err := ParseString(b, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(b[1:], "XXXXX")
+	if s != "hello" {
+		t.Fatalf("ParseString result changed with its input: %q", s)
+	}
+}
+
+func TestParseStringErrors(t *testing.T) {
+	b := AppendString(nil, "hello")
+	checkFrameSizeError(t, "ParseString(maxLen 4)", func() error {
+		_, _, err := ParseString(b, 4)
+		return err
+	}, 5, 4)
+	if _, _, err := ParseString(b[:3], 5); err != io.ErrUnexpectedEOF {
+		t.Fatalf("ParseString(truncated) error = %v, want io.ErrUnexpectedEOF", err)
+	}
+	bad := AppendBytes(nil, []byte{'a', 0xFF, 'b'})
+	if s, _, err := ParseString(bad, 3); err != nil || s != "a\xffb" {
+		t.Fatalf("ParseString(invalid UTF-8) = %q, %v", s, err)
+	}
+	if _, _, err := ParseStringValid(bad, 3); err != ErrInvalidUTF8 {
+		t.Fatalf("ParseStringValid(invalid UTF-8) error = %v, want ErrInvalidUTF8", err)
+	}
+	prefix := Append(nil, Max)
+	if n := bytesAllocated(func() { ParseString(prefix, 1024) }); n > 1024 {
+		t.Fatalf("ParseString allocated %d bytes for an over-limit length", n)
+	}
+}
+
+// -------------------------
+// ReadBytes
+// -------------------------
+
+func TestReadBytes(t *testing.T) {
+	var stream []byte
+	stream = AppendBytes(stream, []byte("hello"))
+	stream = AppendBytes(stream, nil)
+	stream = AppendBytes(stream, bytes.Repeat([]byte{1}, 300))
+	r := readerOnly{iotest.OneByteReader(bytes.NewReader(stream))}
+	for _, want := range []int{5, 0, 300} {
+		p, err := ReadBytes(r, 300)
+		if err != nil || len(p) != want {
+			t.Fatalf("ReadBytes = %d bytes, %v; want %d", len(p), err, want)
+		}
+	}
+	if _, err := ReadBytes(r, 300); err != io.EOF {
+		t.Fatalf("ReadBytes at end error = %v, want io.EOF", err)
+	}
+}
+
+func TestReadBytesTruncated(t *testing.T) {
+	b := AppendBytes(nil, []byte("hello"))
+	for cut := 1; cut < len(b); cut++ {
+		if _, err := ReadBytes(bytes.NewReader(b[:cut]), 16); err != io.ErrUnexpectedEOF {
+			t.Fatalf("ReadBytes(%x) error = %v, want io.ErrUnexpectedEOF", b[:cut], err)
+		}
+	}
+}
+
+func TestReadBytesLimit(t *testing.T) {
+	// A declared length of Max with no payload behind it must fail on the
+	// limit without allocating the payload or touching the rest of the stream
+	prefix := Append(nil, Max)
+	r := bytes.NewReader(append(prefix, "payload"...))
+	n := bytesAllocated(func() {
+		_, err := ReadBytes(r, 1<<20)
+		var sizeErr *FrameSizeError
+		if !errors.As(err, &sizeErr) {
+			t.Fatalf("ReadBytes error = %v, want *FrameSizeError", err)
+		}
+	})
+	if n > 1024 {
+		t.Fatalf("ReadBytes allocated %d bytes for an over-limit length", n)
+	}
+	if r.Len() != len("payload") {
+		t.Fatalf("ReadBytes consumed %d payload bytes past the prefix", len("payload")-r.Len())
+	}
+	checkFrameSizeError(t, "ReadBytes", func() error {
+		_, err := ReadBytes(bytes.NewReader(prefix), 1<<20)
+		return err
+	}, Max, 1<<20)
+}
+
+func checkFrameSizeError(t *testing.T, name string, fn func() error, declared, limit uint64) {
+	t.Helper()
+	err := fn()
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("%s error = %v, want ErrLimitExceeded", name, err)
+	}
+	var sizeErr *FrameSizeError
+	if !errors.As(err, &sizeErr) || sizeErr.Declared != declared || sizeErr.Limit != limit {
+		t.Fatalf("%s error = %v, want declared %d and limit %d", name, err, declared, limit)
+	}
+}
+
+// bytesAllocated returns the number of heap bytes allocated while running fn
+func bytesAllocated(fn func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	fn()
+	runtime.ReadMemStats(&after)
+	return after.TotalAlloc - before.TotalAlloc
+}
@@ -0,0 +1,102 @@
+package varint
+
+import (
+	"bufio"
+	"io"
+	"iter"
+	"math"
+)
+
+// Capsule is one record of the capsule protocol (RFC 9297 section 3.2): a
+// type and a length as varints, then that many bytes of value
+type Capsule struct {
+	Type    uint64
+	Payload []byte
+	// Raw is the whole encoded capsule, header included, so that a capsule
+	// of a type the caller does not handle can be forwarded unchanged
+	Raw []byte
+}
+
+// WriteCapsule writes a capsule of type typ carrying payload to w, using at
+// most two Write calls. It returns a *ValueTooLargeError, having written
+// nothing, if typ exceeds Max
+func WriteCapsule(w io.Writer, typ uint64, payload []byte) error {
+	if typ > Max {
+		return &ValueTooLargeError{Num: typ}
+	}
+	var buf [MaxFrameHeaderLen]byte
+	hdr := AppendFrameHeader(buf[:0], typ, uint64(len(payload)))
+	if _, err := w.Write(hdr); err != nil {
+		return err
+	}
+	if len(payload) == 0 {
+		return nil
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// ReadCapsule reads the next capsule from r. A declared length above maxLen
+// fails with a *FrameSizeError before anything is allocated or read for the
+// value. It returns io.EOF only if r ends before the capsule starts, and
+// io.ErrUnexpectedEOF if it ends anywhere after that
+func ReadCapsule(r *bufio.Reader, maxLen uint64) (typ uint64, payload []byte, err error) {
+	maxLen = min(maxLen, math.MaxInt)
+	typ, _, err = ReadFrom(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	length, _, err := ReadFrom(r)
+	if err != nil {
+		return 0, nil, unexpectedEOF(err)
+	}
+	if length > maxLen {
+		return 0, nil, &FrameSizeError{Declared: length, Limit: maxLen}
+	}
+	payload, err = readPayload(r, int(length), nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	return typ, payload, nil
+}
+
+// Capsules returns an iterator over the back-to-back capsules in b, of every
+// type, in order. Payload and Raw are views into b. Iteration stops at a
+// capsule cut off by the end of b or declaring more than maxLen bytes; the
+// returned function then reports an *OffsetError carrying the capsule's
+// offset and wrapping io.ErrUnexpectedEOF or a *FrameSizeError. It reports
+// nil if b was exhausted or the caller stopped early
+func Capsules(b []byte, maxLen uint64) (iter.Seq[Capsule], func() error) {
+	var err error
+	seq := func(yield func(Capsule) bool) {
+		err = nil
+		for off := 0; off < len(b); {
+			c, n, cerr := parseCapsule(b[off:], maxLen)
+			if cerr != nil {
+				err = &OffsetError{Offset: off, Err: cerr}
+				return
+			}
+			if !yield(c) {
+				return
+			}
+			off += n
+		}
+	}
+	return seq, func() error { return err }
+}
+
+// parseCapsule reads the capsule at the start of a non-empty b
+func parseCapsule(b []byte, maxLen uint64) (Capsule, int, error) {
+	typ, length, hdr, err := ParseFrameHeader(b)
+	if err != nil {
+		return Capsule{}, 0, io.ErrUnexpectedEOF
+	}
+	if length > maxLen {
+		return Capsule{}, 0, &FrameSizeError{Declared: length, Limit: maxLen}
+	}
+	if length > uint64(len(b)-hdr) {
+		return Capsule{}, 0, io.ErrUnexpectedEOF
+	}
+	n := hdr + int(length)
+	return Capsule{Type: typ, Payload: b[hdr:n:n], Raw: b[:n:n]}, n, nil
+}
@@ -0,0 +1,142 @@
+package varint
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// -------------------------
+// Capsules
+// -------------------------
+
+// capsuleStream interleaves DATAGRAM capsules (type 0x00, RFC 9297) with
+// capsules of types the tests treat as unknown, including a reserved one
+var capsuleStream = []struct {
+	typ     uint64
+	payload []byte
+}{
+	{0x00, []byte("first datagram")},
+	{0x29, []byte{0xde, 0xad}},
+	{0x00, nil},
+	{0x1f*3 + 0x29, bytes.Repeat([]byte{7}, 300)},
+	{Max, []byte("widest type")},
+	{0x00, bytes.Repeat([]byte{1}, 70)},
+}
+
+func writeCapsules(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, c := range capsuleStream {
+		if err := WriteCapsule(&buf, c.typ, c.payload); err != nil {
+			t.Fatalf("WriteCapsule(%#x) error = %v", c.typ, err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestReadCapsule(t *testing.T) {
+	stream := writeCapsules(t)
+	r := bufio.NewReader(bytes.NewReader(stream))
+	for _, want := range capsuleStream {
+		typ, payload, err := ReadCapsule(r, 1000)
+		if err != nil || typ != want.typ || !bytes.Equal(payload, want.payload) {
+			t.Fatalf("ReadCapsule = %#x, %d bytes, %v; want %#x, %d bytes", typ, len(payload), err, want.typ, len(want.payload))
+		}
+	}
+	if _, _, err := ReadCapsule(r, 1000); err != io.EOF {
+		t.Fatalf("ReadCapsule at end error = %v, want io.EOF", err)
+	}
+
+	// Cut anywhere inside a capsule, the stream ends unexpectedly
+	first := len(AppendFrameHeader(nil, 0, 14)) + 14
+	for cut := 1; cut < first; cut++ {
+		r := bufio.NewReader(bytes.NewReader(stream[:cut]))
+		if _, _, err := ReadCapsule(r, 1000); err != io.ErrUnexpectedEOF {
+			t.Fatalf("ReadCapsule cut at %d error = %v, want io.ErrUnexpectedEOF", cut, err)
+		}
+	}
+
+	// The limit applies before the value is read
+	r = bufio.NewReader(bytes.NewReader(stream))
+	for range 3 {
+		ReadCapsule(r, 1000)
+	}
+	var sizeErr *FrameSizeError
+	if _, _, err := ReadCapsule(r, 299); !errors.As(err, &sizeErr) || sizeErr.Declared != 300 || !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("ReadCapsule over the limit error = %v, want a FrameSizeError for 300 bytes", err)
+	}
+
+	if err := WriteCapsule(io.Discard, Max+1, nil); !errors.Is(err, ErrValueTooLarge) {
+		t.Fatalf("WriteCapsule(Max+1) error = %v, want ErrValueTooLarge", err)
+	}
+}
+
+func TestCapsules(t *testing.T) {
+	stream := writeCapsules(t)
+	seq, errf := Capsules(stream, 1000)
+	var forwarded []byte
+	i := 0
+	for c := range seq {
+		want := capsuleStream[i]
+		if c.Type != want.typ || !bytes.Equal(c.Payload, want.payload) {
+			t.Fatalf("capsule %d = %#x, %d bytes; want %#x, %d bytes", i, c.Type, len(c.Payload), want.typ, len(want.payload))
+		}
+		// Only DATAGRAM capsules are handled here; the rest go out unchanged
+		if c.Type != 0x00 {
+			forwarded = append(forwarded, c.Raw...)
+		}
+		i++
+	}
+	if err := errf(); err != nil || i != len(capsuleStream) {
+		t.Fatalf("Capsules yielded %d capsules, error = %v", i, err)
+	}
+	var want bytes.Buffer
+	for _, c := range capsuleStream {
+		if c.typ != 0x00 {
+			WriteCapsule(&want, c.typ, c.payload)
+		}
+	}
+	if !bytes.Equal(forwarded, want.Bytes()) {
+		t.Fatalf("forwarded %x, want %x", forwarded, want.Bytes())
+	}
+}
+
+func TestCapsulesErrors(t *testing.T) {
+	stream := writeCapsules(t)
+	second := len(AppendFrameHeader(nil, 0, 14)) + 14
+	cases := []struct {
+		name   string
+		b      []byte
+		limit  uint64
+		want   error
+		yields int
+	}{
+		{"cut in header", stream[:second+1], 1000, io.ErrUnexpectedEOF, 1},
+		{"cut in value", stream[:second+3], 1000, io.ErrUnexpectedEOF, 1},
+		{"over limit", stream, 14, ErrLimitExceeded, 3},
+	}
+	for _, c := range cases {
+		seq, errf := Capsules(c.b, c.limit)
+		n := 0
+		for range seq {
+			n++
+		}
+		err := errf()
+		var offErr *OffsetError
+		if !errors.Is(err, c.want) || !errors.As(err, &offErr) || n != c.yields {
+			t.Fatalf("%s: %d capsules, error = %v; want %d then %v", c.name, n, err, c.yields, c.want)
+		}
+	}
+
+	// Stopping early is not an error
+	seq, errf := Capsules(stream[:second+1], 1000)
+	for range seq {
+		break
+	}
+	if err := errf(); err != nil {
+		t.Fatalf("error after stopping early = %v", err)
+	}
+}
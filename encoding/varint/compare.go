@@ -0,0 +1,58 @@
+package varint
+
+import (
+	"io"
+)
+
+// Compare compares the varints at the start of a and b numerically without
+// decoding them, returning -1, 0 or +1 along with the number of bytes each
+// occupies. Canonical encodings order by width first, but a non-minimal
+// encoding can be wider than a larger value's, so Compare lines up the two
+// payloads and compares them byte by byte from the most significant end. It
+// returns io.EOF if either buffer is empty and io.ErrUnexpectedEOF if either
+// ends inside its varint
+func Compare(a, b []byte) (cmp, na, nb int, err error) {
+	if na, err = checkedLen(a); err != nil {
+		return 0, 0, 0, err
+	}
+	if nb, err = checkedLen(b); err != nil {
+		return 0, 0, 0, err
+	}
+	for p := MaxLen - max(na, nb); p < MaxLen; p++ {
+		x, y := payloadByte(a, na, p), payloadByte(b, nb, p)
+		if x != y {
+			if x < y {
+				return -1, na, nb, nil
+			}
+			return 1, na, nb, nil
+		}
+	}
+	return 0, na, nb, nil
+}
+
+// checkedLen returns the encoded length of the varint at the start of b,
+// failing if b does not hold all of it
+func checkedLen(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, io.EOF
+	}
+	n := EncodedLen(b[0])
+	if len(b) < n {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return n, nil
+}
+
+// payloadByte returns byte p, counting from the most significant, of the
+// 8-byte big-endian value of the n-byte varint at the start of b
+func payloadByte(b []byte, n, p int) byte {
+	i := p - (MaxLen - n)
+	switch {
+	case i < 0:
+		return 0
+	case i == 0:
+		return b[0] & 0x3f
+	default:
+		return b[i]
+	}
+}
@@ -0,0 +1,77 @@
+package varint
+
+import (
+	"cmp"
+	"io"
+	"math/rand/v2"
+	"testing"
+)
+
+// -------------------------
+// Compare
+// -------------------------
+
+// appendWidth appends v encoded in exactly width bytes, which may be wider
+// than its minimal encoding
+func appendWidth(dst []byte, v uint64, width int) []byte {
+	dst, err := AppendWithLen(dst, v, width)
+	if err != nil {
+		panic(err)
+	}
+	return dst
+}
+
+func TestCompareProperty(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	widths := []int{1, 2, 4, 8}
+	randValue := func() uint64 {
+		// Bias towards small values so that widths and values often collide
+		return rng.Uint64N(Max+1) >> rng.UintN(62)
+	}
+	for i := 0; i < 100000; i++ {
+		x, y := randValue(), randValue()
+		if i%4 == 0 {
+			y = x
+		}
+		wx := widths[rng.IntN(4)]
+		wy := widths[rng.IntN(4)]
+		wx, wy = max(wx, Len(x)), max(wy, Len(y))
+		a := appendWidth(nil, x, wx)
+		b := appendWidth(nil, y, wy)
+		a = append(a, 0xff) // trailing bytes must be ignored
+		got, na, nb, err := Compare(a, b)
+		if err != nil || got != cmp.Compare(x, y) || na != wx || nb != wy {
+			t.Fatalf("Compare(%x, %x) = %d, %d, %d, %v; want %d, %d, %d", a, b, got, na, nb, err, cmp.Compare(x, y), wx, wy)
+		}
+	}
+}
+
+func TestCompareWiderSmaller(t *testing.T) {
+	// 5 padded to 8 bytes against 300 in its minimal 2 bytes
+	a := appendWidth(nil, 5, 8)
+	b := Append(nil, 300)
+	if got, _, _, _ := Compare(a, b); got != -1 {
+		t.Fatalf("Compare(wide 5, 300) = %d, want -1", got)
+	}
+	if got, _, _, _ := Compare(b, a); got != 1 {
+		t.Fatalf("Compare(300, wide 5) = %d, want 1", got)
+	}
+}
+
+func TestCompareErrors(t *testing.T) {
+	ok := Append(nil, 1)
+	cases := []struct {
+		a, b []byte
+		want error
+	}{
+		{nil, ok, io.EOF},
+		{ok, nil, io.EOF},
+		{[]byte{0x40}, ok, io.ErrUnexpectedEOF},
+		{ok, []byte{0xc0, 0}, io.ErrUnexpectedEOF},
+	}
+	for _, c := range cases {
+		if _, _, _, err := Compare(c.a, c.b); err != c.want {
+			t.Fatalf("Compare(%x, %x) error = %v, want %v", c.a, c.b, err, c.want)
+		}
+	}
+}
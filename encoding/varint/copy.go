@@ -0,0 +1,38 @@
+package varint
+
+import (
+	"io"
+)
+
+// CopyN copies n varints from src to dst without decoding them, checking only
+// that each value is complete. It returns the number of values and bytes
+// written. If src ends cleanly between two values before n were copied the
+// error is io.EOF; if it ends partway through a value, leaving a corrupt
+// stream, the error is io.ErrUnexpectedEOF and the partial value is not
+// written. Each value is written with a single Write call
+func CopyN(dst io.Writer, src io.ByteReader, n int) (values int, bytes int64, err error) {
+	var buf [MaxLen]byte
+	for values < n {
+		first, err := src.ReadByte()
+		if err != nil {
+			return values, bytes, err
+		}
+		buf[0] = first
+		length := EncodedLen(first)
+		for i := 1; i < length; i++ {
+			if buf[i], err = src.ReadByte(); err != nil {
+				if err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				return values, bytes, err
+			}
+		}
+		m, err := dst.Write(buf[:length])
+		bytes += int64(m)
+		if err != nil {
+			return values, bytes, err
+		}
+		values++
+	}
+	return values, bytes, nil
+}
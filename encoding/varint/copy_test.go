@@ -0,0 +1,96 @@
+package varint
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// -------------------------
+// CopyN
+// -------------------------
+
+func TestCopyN(t *testing.T) {
+	stream := AppendMany(nil, testValues...)
+	src := bytes.NewReader(append(stream, 0x05))
+	var dst bytes.Buffer
+	values, n, err := CopyN(&dst, src, len(testValues))
+	if err != nil || values != len(testValues) || n != int64(len(stream)) {
+		t.Fatalf("CopyN = %d, %d, %v; want %d, %d, nil", values, n, err, len(testValues), len(stream))
+	}
+	if !bytes.Equal(dst.Bytes(), stream) {
+		t.Fatalf("CopyN wrote %x, want %x", dst.Bytes(), stream)
+	}
+	if src.Len() != 1 {
+		t.Fatalf("CopyN read past the requested values")
+	}
+}
+
+func TestCopyNEnds(t *testing.T) {
+	stream := AppendMany(nil, 1, 300, Max)
+	var dst bytes.Buffer
+
+	values, n, err := CopyN(&dst, bytes.NewReader(stream), 5)
+	if err != io.EOF || values != 3 || n != int64(len(stream)) {
+		t.Fatalf("CopyN(clean end) = %d, %d, %v; want 3, %d, io.EOF", values, n, err, len(stream))
+	}
+
+	dst.Reset()
+	values, n, err = CopyN(&dst, bytes.NewReader(stream[:len(stream)-1]), 5)
+	if err != io.ErrUnexpectedEOF || values != 2 || n != 3 {
+		t.Fatalf("CopyN(mid-value end) = %d, %d, %v; want 2, 3, io.ErrUnexpectedEOF", values, n, err)
+	}
+	if dst.Len() != 3 {
+		t.Fatalf("CopyN wrote a partial value: %x", dst.Bytes())
+	}
+}
+
+func TestCopyNWriteError(t *testing.T) {
+	errBroken := errors.New("broken")
+	w := &limitedWriter{limit: 3, err: errBroken}
+	values, n, err := CopyN(w, bytes.NewReader(AppendMany(nil, 1, 300, 5)), 3)
+	if err != errBroken || values != 2 || n != 3 {
+		t.Fatalf("CopyN = %d, %d, %v; want 2, 3, %v", values, n, err, errBroken)
+	}
+}
+
+func TestCopyNAllocs(t *testing.T) {
+	stream := AppendMany(nil, corpusValues(1000)...)
+	src := bytes.NewReader(stream)
+	allocs := testing.AllocsPerRun(10, func() {
+		src.Reset(stream)
+		if _, _, err := CopyN(io.Discard, src, 1000); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs > 1 {
+		t.Fatalf("CopyN allocated %v times for 1000 values", allocs)
+	}
+}
+
+// limitedWriter accepts limit bytes and then fails with err
+type limitedWriter struct {
+	limit int
+	err   error
+	buf   []byte
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if len(w.buf)+len(p) > w.limit {
+		n := w.limit - len(w.buf)
+		w.buf = append(w.buf, p[:n]...)
+		return n, w.err
+	}
+	w.buf = append(w.buf, p...)
+	return len(p), nil
+}
+
+// corpusValues returns n values cycling through testValues
+func corpusValues(n int) []uint64 {
+	vs := make([]uint64, n)
+	for i := range vs {
+		vs[i] = testValues[i%len(testValues)]
+	}
+	return vs
+}
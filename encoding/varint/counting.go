@@ -0,0 +1,132 @@
+package varint
+
+import (
+	"io"
+)
+
+// CountingReader wraps a reader and counts the bytes taken from it, so
+// callers decoding with Read or ReadFrom can track absolute stream offsets.
+// It implements both io.Reader and io.ByteReader. Bytes are counted as they
+// are returned by the underlying reader, so the count stays exact when a read
+// fails partway through a value
+type CountingReader struct {
+	r   io.Reader
+	br  io.ByteReader
+	one [1]byte
+	n   int64
+}
+
+// NewCountingReader returns a CountingReader reading from r. If r also
+// implements io.ByteReader, ReadByte calls go straight to it
+func NewCountingReader(r io.Reader) *CountingReader {
+	c := &CountingReader{r: r}
+	c.br, _ = r.(io.ByteReader)
+	return c
+}
+
+// NewCountingByteReader returns a CountingReader reading from a reader that
+// only implements io.ByteReader
+func NewCountingByteReader(r io.ByteReader) *CountingReader {
+	return &CountingReader{br: r}
+}
+
+// Read implements io.Reader
+func (c *CountingReader) Read(p []byte) (int, error) {
+	if c.r == nil {
+		for i := range p {
+			b, err := c.br.ReadByte()
+			if err != nil {
+				return i, err
+			}
+			p[i] = b
+			c.n++
+		}
+		return len(p), nil
+	}
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ReadByte implements io.ByteReader
+func (c *CountingReader) ReadByte() (byte, error) {
+	if c.br != nil {
+		b, err := c.br.ReadByte()
+		if err == nil {
+			c.n++
+		}
+		return b, err
+	}
+	if _, err := io.ReadFull(c.r, c.one[:]); err != nil {
+		return 0, err
+	}
+	c.n++
+	return c.one[0], nil
+}
+
+// BytesRead returns the number of bytes read so far
+func (c *CountingReader) BytesRead() int64 {
+	return c.n
+}
+
+// CountingWriter wraps a writer and counts the bytes accepted by it. It
+// implements both io.Writer and io.ByteWriter
+type CountingWriter struct {
+	w   io.Writer
+	bw  io.ByteWriter
+	one [1]byte
+	n   int64
+}
+
+// NewCountingWriter returns a CountingWriter writing to w. If w also
+// implements io.ByteWriter, WriteByte calls go straight to it
+func NewCountingWriter(w io.Writer) *CountingWriter {
+	c := &CountingWriter{w: w}
+	c.bw, _ = w.(io.ByteWriter)
+	return c
+}
+
+// NewCountingByteWriter returns a CountingWriter writing to a writer that
+// only implements io.ByteWriter
+func NewCountingByteWriter(w io.ByteWriter) *CountingWriter {
+	return &CountingWriter{bw: w}
+}
+
+// Write implements io.Writer
+func (c *CountingWriter) Write(p []byte) (int, error) {
+	if c.w == nil {
+		for i, b := range p {
+			if err := c.bw.WriteByte(b); err != nil {
+				return i, err
+			}
+			c.n++
+		}
+		return len(p), nil
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// WriteByte implements io.ByteWriter
+func (c *CountingWriter) WriteByte(b byte) error {
+	if c.bw != nil {
+		if err := c.bw.WriteByte(b); err != nil {
+			return err
+		}
+		c.n++
+		return nil
+	}
+	c.one[0] = b
+	n, err := c.w.Write(c.one[:])
+	c.n += int64(n)
+	if err == nil && n != 1 {
+		err = io.ErrShortWrite
+	}
+	return err
+}
+
+// BytesWritten returns the number of bytes written so far
+func (c *CountingWriter) BytesWritten() int64 {
+	return c.n
+}
@@ -0,0 +1,149 @@
+package varint
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+// -------------------------
+// CountingReader
+// -------------------------
+
+func TestCountingReader(t *testing.T) {
+	stream := AppendMany(nil, testValues...)
+	readers := map[string]*CountingReader{
+		"ByteReader": NewCountingReader(bytes.NewReader(stream)),
+		"Reader":     NewCountingReader(readerOnly{bytes.NewReader(stream)}),
+		"ByteOnly":   NewCountingByteReader(bytes.NewBuffer(stream)),
+	}
+	for name, c := range readers {
+		want := int64(0)
+		for i, v := range testValues {
+			var got uint64
+			var err error
+			if i%2 == 0 {
+				got, err = Read(c)
+			} else {
+				got, _, err = ReadFrom(c)
+			}
+			if err != nil || got != v {
+				t.Fatalf("%s: read %d, %v; want %d", name, got, err, v)
+			}
+			want += int64(Len(v))
+			if c.BytesRead() != want {
+				t.Fatalf("%s: BytesRead = %d, want %d", name, c.BytesRead(), want)
+			}
+		}
+	}
+}
+
+func TestCountingReaderTruncated(t *testing.T) {
+	enc := Append(nil, Max)
+	for cut := 1; cut < len(enc); cut++ {
+		for name, c := range map[string]*CountingReader{
+			"ReadLen":  NewCountingReader(bytes.NewReader(enc[:cut])),
+			"ReadFrom": NewCountingReader(iotest.OneByteReader(bytes.NewReader(enc[:cut]))),
+		} {
+			var n int
+			var err error
+			if name == "ReadLen" {
+				_, n, err = ReadLen(c)
+			} else {
+				_, n, err = ReadFrom(c)
+			}
+			if err == nil || c.BytesRead() != int64(cut) || n != cut {
+				t.Fatalf("%s(%x): n = %d, BytesRead = %d, %v; want %d", name, enc[:cut], n, c.BytesRead(), err, cut)
+			}
+		}
+	}
+}
+
+// -------------------------
+// CountingWriter
+// -------------------------
+
+func TestCountingWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writers := map[string]*CountingWriter{
+		"ByteWriter": NewCountingWriter(&buf),
+		"Writer":     NewCountingWriter(writerOnly{&buf}),
+		"ByteOnly":   NewCountingByteWriter(&buf),
+	}
+	for name, c := range writers {
+		buf.Reset()
+		want := int64(0)
+		for i, v := range testValues {
+			var err error
+			if i%2 == 0 {
+				err = Write(c, v)
+			} else {
+				_, err = WriteTo(c, v)
+			}
+			if err != nil {
+				t.Fatalf("%s: write %d: %v", name, v, err)
+			}
+			want += int64(Len(v))
+			if c.BytesWritten() != want {
+				t.Fatalf("%s: BytesWritten = %d, want %d", name, c.BytesWritten(), want)
+			}
+		}
+		if !bytes.Equal(buf.Bytes(), AppendMany(nil, testValues...)) {
+			t.Fatalf("%s: wrote %x", name, buf.Bytes())
+		}
+	}
+}
+
+func TestCountingWriterFailure(t *testing.T) {
+	errBroken := errors.New("broken")
+	c := NewCountingByteWriter(&failingByteWriter{limit: 3, err: errBroken})
+	if err := Write(c, Max); err != errBroken {
+		t.Fatalf("Write error = %v, want %v", err, errBroken)
+	}
+	if c.BytesWritten() != 3 {
+		t.Fatalf("BytesWritten = %d, want 3", c.BytesWritten())
+	}
+}
+
+func TestCountingNoAllocs(t *testing.T) {
+	r := bytes.NewReader(nil)
+	cr := NewCountingReader(readerOnly{r})
+	cw := NewCountingWriter(io.Discard)
+	enc := Append(nil, Max)
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Reset(enc)
+		if _, err := Read(cr); err != nil {
+			t.Fatal(err)
+		}
+		if err := Write(cw, Max); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("counting wrappers allocated %v times per value", allocs)
+	}
+}
+
+// writerOnly hides any io.ByteWriter implementation of the wrapped writer
+type writerOnly struct {
+	w io.Writer
+}
+
+func (w writerOnly) Write(p []byte) (int, error) { return w.w.Write(p) }
+
+// failingByteWriter accepts limit bytes and then fails with err
+type failingByteWriter struct {
+	limit int
+	err   error
+	buf   []byte
+}
+
+func (w *failingByteWriter) WriteByte(c byte) error {
+	if len(w.buf) == w.limit {
+		return w.err
+	}
+	w.buf = append(w.buf, c)
+	return nil
+}
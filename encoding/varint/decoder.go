@@ -0,0 +1,141 @@
+package varint
+
+import (
+	"io"
+
+	"flux/encoding/zigzag"
+)
+
+// Decoder reads a sequence of fields from a byte slice. Errors are sticky:
+// after the first failure every method is a no-op returning zero values, so a
+// frame can be decoded as straight-line code with a single Err or Finish check
+// at the end. Failures are reported as an *OffsetError carrying the offset of
+// the field that could not be decoded
+type Decoder struct {
+	buf    []byte
+	off    int
+	err    error
+	strict bool
+}
+
+// NewDecoder returns a Decoder reading from b
+func NewDecoder(b []byte) *Decoder {
+	return &Decoder{buf: b}
+}
+
+// Reset makes the Decoder read from b, discarding its offset and any error.
+// The strict setting is kept
+func (d *Decoder) Reset(b []byte) {
+	*d = Decoder{buf: b, strict: d.strict}
+}
+
+// SetStrict makes the Decoder reject non-minimal encodings of varints and
+// length prefixes with ErrNonCanonical, as ParseCanonical does
+func (d *Decoder) SetStrict(strict bool) {
+	d.strict = strict
+}
+
+func (d *Decoder) parse() (uint64, int, error) {
+	if d.strict {
+		return ParseCanonical(d.buf[d.off:])
+	}
+	return Parse(d.buf[d.off:])
+}
+
+func (d *Decoder) fail(err error) {
+	d.err = &OffsetError{Offset: d.off, Err: err}
+}
+
+// Uint64 decodes the next varint
+func (d *Decoder) Uint64() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n, err := d.parse()
+	if err != nil {
+		d.fail(err)
+		return 0
+	}
+	d.off += n
+	return v
+}
+
+// Int64 decodes the next zigzag-encoded varint
+func (d *Decoder) Int64() int64 {
+	if d.err != nil {
+		return 0
+	}
+	u, n, err := d.parse()
+	if err != nil {
+		d.fail(err)
+		return 0
+	}
+	d.off += n
+	return zigzag.Decode(u)
+}
+
+// Bytes decodes the next length-prefixed byte slice, failing if its declared
+// length exceeds maxLen. The result aliases the Decoder's input
+func (d *Decoder) Bytes(maxLen int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if d.strict {
+		if _, _, err := ParseCanonical(d.buf[d.off:]); err != nil {
+			d.fail(err)
+			return nil
+		}
+	}
+	p, n, err := ParseBytesMax(d.buf[d.off:], uint64(max(maxLen, 0)))
+	if err != nil {
+		d.fail(err)
+		return nil
+	}
+	d.off += n
+	return p
+}
+
+// String decodes the next length-prefixed string, failing if its declared
+// length exceeds maxLen. The result is a copy
+func (d *Decoder) String(maxLen int) string {
+	return string(d.Bytes(maxLen))
+}
+
+// Skip advances past the next n varints without decoding them. If input runs
+// out, the error carries the offset of the first varint that could not be
+// skipped
+func (d *Decoder) Skip(n int) {
+	if d.err != nil {
+		return
+	}
+	off, err := Skip(d.buf[d.off:], n)
+	d.off += off
+	if err != nil {
+		d.fail(io.ErrUnexpectedEOF)
+	}
+}
+
+// Offset returns the number of bytes consumed so far
+func (d *Decoder) Offset() int {
+	return d.off
+}
+
+// Remaining returns the number of bytes not yet consumed
+func (d *Decoder) Remaining() int {
+	return len(d.buf) - d.off
+}
+
+// Err returns the first error encountered, if any
+func (d *Decoder) Err() error {
+	return d.err
+}
+
+// Finish returns the first error encountered or, if there was none, an
+// *OffsetError wrapping ErrTrailingBytes when input remains. It is meant for
+// frames whose extent is known to be exactly the Decoder's input
+func (d *Decoder) Finish() error {
+	if d.err == nil && d.off != len(d.buf) {
+		d.fail(ErrTrailingBytes)
+	}
+	return d.err
+}
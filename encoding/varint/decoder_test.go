@@ -0,0 +1,147 @@
+package varint
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// -------------------------
+// Decoder
+// -------------------------
+
+// frame is a sample frame of every field kind the Decoder supports
+func frame() []byte {
+	var b []byte
+	b = Append(b, 7)
+	b = AppendInt(b, -300)
+	b = AppendBytes(b, []byte("payload"))
+	b = AppendString(b, "name")
+	b = AppendMany(b, 1, 2, 3)
+	return Append(b, Max)
+}
+
+func TestDecoder(t *testing.T) {
+	b := frame()
+	d := NewDecoder(b)
+	typ := d.Uint64()
+	delta := d.Int64()
+	payload := d.Bytes(16)
+	name := d.String(16)
+	d.Skip(3)
+	last := d.Uint64()
+	if err := d.Finish(); err != nil {
+		t.Fatalf("Finish error = %v", err)
+	}
+	if typ != 7 || delta != -300 || !bytes.Equal(payload, []byte("payload")) || name != "name" || last != Max {
+		t.Fatalf("decoded %d, %d, %q, %q, %d", typ, delta, payload, name, last)
+	}
+	if d.Offset() != len(b) || d.Remaining() != 0 {
+		t.Fatalf("Offset, Remaining = %d, %d; want %d, 0", d.Offset(), d.Remaining(), len(b))
+	}
+}
+
+func TestDecoderTruncatedAtEachField(t *testing.T) {
+	b := frame()
+	// Cutting the last byte of each field fails at the start of that field,
+	// or of the varint being skipped
+	fields := []struct{ end, failAt int }{
+		{1, 0}, {3, 1}, {11, 3}, {16, 11}, {19, 18}, {27, 19},
+	}
+	for i, f := range fields {
+		d := NewDecoder(b[:f.end-1])
+		d.Uint64()
+		d.Int64()
+		d.Bytes(16)
+		d.String(16)
+		d.Skip(3)
+		if v := d.Uint64(); v != 0 {
+			t.Fatalf("field %d: Uint64 after failure = %d, want 0", i, v)
+		}
+		err := d.Err()
+		if !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+			t.Fatalf("field %d: Err = %v, want a truncation error", i, err)
+		}
+		var offErr *OffsetError
+		if !errors.As(err, &offErr) || offErr.Offset != f.failAt {
+			t.Fatalf("field %d: Err = %v, want an *OffsetError at %d", i, err, f.failAt)
+		}
+		if d.Finish() != err {
+			t.Fatalf("field %d: Finish = %v, want the sticky error %v", i, d.Finish(), err)
+		}
+	}
+}
+
+func TestDecoderStrict(t *testing.T) {
+	cases := []struct {
+		name   string
+		buf    []byte
+		decode func(d *Decoder)
+		failAt int
+	}{
+		{"Uint64", []byte{0x40, 7}, func(d *Decoder) { d.Uint64() }, 0},
+		{"Bytes", append([]byte{1, 0x80, 0, 0, 4}, "name"...), func(d *Decoder) { d.Uint64(); d.Bytes(16) }, 1},
+		{"Int64", []byte{1, 2, 0xc0, 0, 0, 0, 0, 0, 0, 1}, func(d *Decoder) { d.Uint64(); d.Int64(); d.Int64() }, 2},
+	}
+	for _, c := range cases {
+		d := NewDecoder(c.buf)
+		c.decode(d)
+		if err := d.Finish(); err != nil {
+			t.Fatalf("%s: lenient Finish = %v", c.name, err)
+		}
+
+		d.SetStrict(true)
+		d.Reset(c.buf)
+		c.decode(d)
+		var offErr *OffsetError
+		if err := d.Err(); !errors.Is(err, ErrNonCanonical) || !errors.As(err, &offErr) || offErr.Offset != c.failAt {
+			t.Fatalf("%s: strict Err = %v, want ErrNonCanonical at %d", c.name, err, c.failAt)
+		}
+	}
+}
+
+func TestDecoderSticky(t *testing.T) {
+	b := AppendBytes(nil, []byte("too long"))
+	b = Append(b, 5)
+	d := NewDecoder(b)
+	if p := d.Bytes(4); p != nil {
+		t.Fatalf("Bytes over the limit = %q, want nil", p)
+	}
+	first := d.Err()
+	if !errors.Is(first, ErrLimitExceeded) {
+		t.Fatalf("Err = %v, want ErrLimitExceeded", first)
+	}
+	if v := d.Uint64(); v != 0 || d.Err() != first || d.Offset() != 0 {
+		t.Fatalf("Uint64 after failure = %d at %d, %v", v, d.Offset(), d.Err())
+	}
+	if s := d.String(100); s != "" {
+		t.Fatalf("String after failure = %q", s)
+	}
+	d.Skip(1)
+	if d.Offset() != 0 {
+		t.Fatalf("Skip after failure moved to %d", d.Offset())
+	}
+}
+
+func TestDecoderFinishTrailing(t *testing.T) {
+	d := NewDecoder(AppendMany(nil, 1, 2))
+	d.Uint64()
+	err := d.Finish()
+	var offErr *OffsetError
+	if !errors.Is(err, ErrTrailingBytes) || !errors.As(err, &offErr) || offErr.Offset != 1 {
+		t.Fatalf("Finish = %v, want ErrTrailingBytes at 1", err)
+	}
+}
+
+func TestDecoderReset(t *testing.T) {
+	d := NewDecoder([]byte{0x40})
+	d.Uint64()
+	if d.Err() == nil {
+		t.Fatal("expected a truncation error")
+	}
+	d.Reset([]byte{0x05})
+	if v := d.Uint64(); v != 5 || d.Finish() != nil {
+		t.Fatalf("Uint64 after Reset = %d, %v", v, d.Err())
+	}
+}
@@ -0,0 +1,53 @@
+package varint
+
+import (
+	"slices"
+)
+
+// AppendDeltas encodes a non-decreasing sequence by writing the first value
+// as-is and every following value as the difference from its predecessor, so
+// dense ascending IDs mostly take one byte each. It returns ErrNotSorted if vs
+// decreases anywhere and a *ValueTooLargeError if a value exceeds Max; dst is
+// returned unchanged on error
+func AppendDeltas(dst []byte, vs []uint64) ([]byte, error) {
+	var prev uint64
+	total := 0
+	for _, v := range vs {
+		if v < prev {
+			return dst, ErrNotSorted
+		}
+		if v > Max {
+			return dst, &ValueTooLargeError{Num: v}
+		}
+		total += Len(v - prev)
+		prev = v
+	}
+	dst = slices.Grow(dst, total)
+	prev = 0
+	for _, v := range vs {
+		dst = Append(dst, v-prev)
+		prev = v
+	}
+	return dst, nil
+}
+
+// ParseDeltas decodes a buffer written by AppendDeltas, appending the
+// reconstructed values to dst. A delta that pushes the running sum past Max
+// is reported as an *OffsetError wrapping ErrOverflow, as is truncation of
+// the last value with io.ErrUnexpectedEOF
+func ParseDeltas(b []byte, dst []uint64) ([]uint64, error) {
+	var sum uint64
+	for off := 0; off < len(b); {
+		d, n, err := Parse(b[off:])
+		if err != nil {
+			return dst, &OffsetError{Offset: off, Err: err}
+		}
+		if d > Max-sum {
+			return dst, &OffsetError{Offset: off, Err: ErrOverflow}
+		}
+		sum += d
+		dst = append(dst, sum)
+		off += n
+	}
+	return dst, nil
+}
@@ -0,0 +1,63 @@
+package varint
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+)
+
+// -------------------------
+// AppendDeltas / ParseDeltas
+// -------------------------
+
+func TestDeltasRoundTrip(t *testing.T) {
+	inputs := [][]uint64{
+		nil,
+		{0},
+		{Max},
+		{5, 5, 5},
+		{1, 2, 3, 100, 1000, 1 << 40, Max},
+		testValues,
+	}
+	for _, vs := range inputs {
+		b, err := AppendDeltas(nil, vs)
+		if err != nil {
+			t.Fatalf("AppendDeltas(%v) error = %v", vs, err)
+		}
+		got, err := ParseDeltas(b, nil)
+		if err != nil || !slices.Equal(got, vs) {
+			t.Fatalf("ParseDeltas(AppendDeltas(%v)) = %v, %v", vs, got, err)
+		}
+	}
+}
+
+func TestAppendDeltasErrors(t *testing.T) {
+	dst := []byte{0xAA}
+	if got, err := AppendDeltas(dst, []uint64{1, 3, 2}); err != ErrNotSorted || !bytes.Equal(got, dst) {
+		t.Fatalf("AppendDeltas(unsorted) = %x, %v; want unchanged dst, ErrNotSorted", got, err)
+	}
+	if got, err := AppendDeltas(dst, []uint64{1, Max + 1}); !errors.Is(err, ErrValueTooLarge) || !bytes.Equal(got, dst) {
+		t.Fatalf("AppendDeltas(Max+1) = %x, %v; want unchanged dst, ErrValueTooLarge", got, err)
+	}
+}
+
+func TestParseDeltasOverflow(t *testing.T) {
+	b := AppendMany(nil, Max-1, 1, 1)
+	got, err := ParseDeltas(b, nil)
+	var offErr *OffsetError
+	if !errors.Is(err, ErrOverflow) || !errors.As(err, &offErr) || offErr.Offset != 9 {
+		t.Fatalf("ParseDeltas error = %v, want ErrOverflow at byte 9", err)
+	}
+	if !slices.Equal(got, []uint64{Max - 1, Max}) {
+		t.Fatalf("ParseDeltas = %v, want the values before the overflow", got)
+	}
+}
+
+func TestParseDeltasTruncated(t *testing.T) {
+	b, _ := AppendDeltas(nil, []uint64{10, 1 << 20})
+	if _, err := ParseDeltas(b[:len(b)-1], nil); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("ParseDeltas error = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
@@ -0,0 +1,45 @@
+package varint
+
+import (
+	"io"
+)
+
+// DiscardN reads and throws away n varints from r without decoding them. If
+// the stream ends first it returns a *SkipError wrapping io.ErrUnexpectedEOF
+// that records how many values were skipped and how many bytes were consumed;
+// other read errors are wrapped the same way
+func DiscardN(r io.ByteReader, n int) error {
+	var consumed int
+	for i := 0; i < n; i++ {
+		first, err := r.ReadByte()
+		if err != nil {
+			return discardError(i, consumed, err)
+		}
+		length := EncodedLen(first)
+		for j := 1; j < length; j++ {
+			if _, err := r.ReadByte(); err != nil {
+				return discardError(i, consumed+j, err)
+			}
+		}
+		consumed += length
+	}
+	return nil
+}
+
+func discardError(skipped, consumed int, err error) error {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return &SkipError{Skipped: skipped, Offset: consumed, Err: err}
+}
+
+// DiscardBytes reads and throws away n bytes from r, e.g. the payload of an
+// unknown length-prefixed field on a stream that can not seek. It returns the
+// number of bytes discarded and io.ErrUnexpectedEOF if r ends early
+func DiscardBytes(r io.Reader, n int64) (int64, error) {
+	discarded, err := io.CopyN(io.Discard, r, n)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return discarded, err
+}
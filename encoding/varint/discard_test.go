@@ -0,0 +1,73 @@
+package varint
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// -------------------------
+// DiscardN / DiscardBytes
+// -------------------------
+
+func TestDiscardN(t *testing.T) {
+	stream := AppendMany(nil, testValues...)
+	stream = Append(stream, 42)
+	r := bytes.NewReader(stream)
+	if err := DiscardN(r, len(testValues)); err != nil {
+		t.Fatalf("DiscardN error = %v", err)
+	}
+	if v, err := Read(r); err != nil || v != 42 {
+		t.Fatalf("Read after DiscardN = %d, %v; want 42", v, err)
+	}
+	if err := DiscardN(r, 0); err != nil {
+		t.Fatalf("DiscardN(0) error = %v", err)
+	}
+}
+
+func TestDiscardNShort(t *testing.T) {
+	stream := AppendMany(nil, 1, 300, Max)
+	cases := []struct {
+		buf      []byte
+		n        int
+		skipped  int
+		consumed int
+	}{
+		{stream, 4, 3, 11},                // ended between values
+		{stream[:len(stream)-2], 3, 2, 9}, // ended mid-value
+		{nil, 1, 0, 0},
+	}
+	for _, c := range cases {
+		err := DiscardN(bytes.NewReader(c.buf), c.n)
+		var skipErr *SkipError
+		if !errors.As(err, &skipErr) || !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("DiscardN(%x, %d) error = %v, want *SkipError", c.buf, c.n, err)
+		}
+		if skipErr.Skipped != c.skipped || skipErr.Offset != c.consumed {
+			t.Fatalf("DiscardN(%x, %d) = %v; want %d skipped, %d consumed", c.buf, c.n, err, c.skipped, c.consumed)
+		}
+	}
+}
+
+func TestDiscardBytes(t *testing.T) {
+	// Skip an unknown length-prefixed field on a stream
+	var stream []byte
+	stream = AppendBytes(stream, bytes.Repeat([]byte{1}, 5000))
+	stream = Append(stream, 7)
+	br := bufio.NewReader(bytes.NewReader(stream))
+	length, err := Read(br)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := DiscardBytes(br, int64(length)); err != nil || n != 5000 {
+		t.Fatalf("DiscardBytes = %d, %v; want 5000", n, err)
+	}
+	if v, err := Read(br); err != nil || v != 7 {
+		t.Fatalf("Read after DiscardBytes = %d, %v; want 7", v, err)
+	}
+	if n, err := DiscardBytes(bytes.NewReader(make([]byte, 10)), 11); err != io.ErrUnexpectedEOF || n != 10 {
+		t.Fatalf("DiscardBytes(short) = %d, %v; want 10, io.ErrUnexpectedEOF", n, err)
+	}
+}
@@ -0,0 +1,152 @@
+package varint
+
+import (
+	"slices"
+	"sync"
+)
+
+// Encoder builds a sequence of fields in a growing byte slice. Its methods
+// return the Encoder so a frame can be written as a chain mirroring its
+// definition. Errors are sticky: the first out-of-range value is recorded,
+// every later call is a no-op, and the error surfaces from Err or Finish
+// instead of panicking mid-chain. The zero value is ready to use
+type Encoder struct {
+	buf   []byte
+	err   error
+	arena *Arena // where buf grows, if set
+}
+
+// NewEncoder returns an Encoder appending to buf
+func NewEncoder(buf []byte) *Encoder {
+	return &Encoder{buf: buf}
+}
+
+// ResetArena makes e encode into memory from a, starting with room for n
+// bytes, and discards any encoded bytes and error. When a frame outgrows its
+// space it moves to a larger allocation from a, so the Encoder never allocates
+// on its own. Its output is only valid until a is Reset
+func (e *Encoder) ResetArena(a *Arena, n int) {
+	*e = Encoder{buf: a.Alloc(n)[:0], arena: a}
+}
+
+// reserve makes room for n more bytes in the arena, if the Encoder has one
+func (e *Encoder) reserve(n int) {
+	if e.arena == nil || cap(e.buf)-len(e.buf) >= n {
+		return
+	}
+	buf := e.arena.Alloc(max(2*cap(e.buf), len(e.buf)+n))
+	e.buf = buf[:copy(buf, e.buf)]
+}
+
+// Uint64 appends v as a varint
+func (e *Encoder) Uint64(v uint64) *Encoder {
+	if e.err != nil {
+		return e
+	}
+	if v > Max {
+		e.err = &ValueTooLargeError{Num: v}
+		return e
+	}
+	e.reserve(MaxLen)
+	e.buf = Append(e.buf, v)
+	return e
+}
+
+// Int64 appends v as a zigzag-encoded varint
+func (e *Encoder) Int64(v int64) *Encoder {
+	if e.err != nil {
+		return e
+	}
+	if v < MinSigned || v > MaxSigned {
+		e.err = &SignedRangeError{Num: v}
+		return e
+	}
+	e.reserve(MaxLen)
+	e.buf = AppendInt(e.buf, v)
+	return e
+}
+
+// Bytes appends p prefixed with its length
+func (e *Encoder) Bytes(p []byte) *Encoder {
+	if e.err != nil {
+		return e
+	}
+	e.reserve(MaxLen + len(p))
+	e.buf = AppendBytes(e.buf, p)
+	return e
+}
+
+// String appends s prefixed with its length
+func (e *Encoder) String(s string) *Encoder {
+	if e.err != nil {
+		return e
+	}
+	e.reserve(MaxLen + len(s))
+	e.buf = AppendString(e.buf, s)
+	return e
+}
+
+// Grow ensures there is room for at least n more bytes without reallocating
+func (e *Encoder) Grow(n int) {
+	e.reserve(n)
+	e.buf = slices.Grow(e.buf, n)
+}
+
+// Len returns the number of bytes encoded so far
+func (e *Encoder) Len() int {
+	return len(e.buf)
+}
+
+// Encoded returns the bytes encoded so far. The slice aliases the Encoder's
+// buffer and is only valid until the next call that modifies it
+func (e *Encoder) Encoded() []byte {
+	return e.buf
+}
+
+// Reset discards the encoded bytes and any error so the Encoder can be reused.
+// An Encoder over an Arena keeps its space in the Arena, so after resetting
+// the Arena use ResetArena instead
+func (e *Encoder) Reset() {
+	e.buf = e.buf[:0]
+	e.err = nil
+}
+
+// Err returns the first error encountered, if any
+func (e *Encoder) Err() error {
+	return e.err
+}
+
+// Finish returns the encoded bytes, or nil and the first error encountered
+func (e *Encoder) Finish() ([]byte, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.buf, nil
+}
+
+// maxPooledCap keeps PutEncoder from pinning unusually large buffers
+const maxPooledCap = 64 << 10
+
+var encoderPool = sync.Pool{
+	New: func() any { return new(Encoder) },
+}
+
+// GetEncoder returns an empty Encoder from a shared pool. Return it with
+// PutEncoder once its output is no longer referenced
+func GetEncoder() *Encoder {
+	return encoderPool.Get().(*Encoder)
+}
+
+// PutEncoder resets e, including any sticky error, and returns it to the pool
+// used by GetEncoder. The bytes e produced must not be used afterwards
+func PutEncoder(e *Encoder) {
+	if cap(e.buf) > maxPooledCap {
+		return
+	}
+	if e.arena != nil {
+		// The arena's memory must not outlive it in the pool
+		*e = Encoder{}
+	}
+	e.Reset()
+	encoderPool.Put(e)
+}
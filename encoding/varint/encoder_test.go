@@ -0,0 +1,88 @@
+package varint
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// -------------------------
+// Encoder
+// -------------------------
+
+func TestEncoder(t *testing.T) {
+	e := NewEncoder(nil)
+	e.Uint64(7).Int64(-300).Bytes([]byte("payload")).String("name")
+	for _, v := range []uint64{1, 2, 3} {
+		e.Uint64(v)
+	}
+	got, err := e.Uint64(Max).Finish()
+	if err != nil {
+		t.Fatalf("Finish error = %v", err)
+	}
+	if want := frame(); !bytes.Equal(got, want) {
+		t.Fatalf("Encoder produced %x, want %x", got, want)
+	}
+	if e.Len() != len(got) || !bytes.Equal(e.Encoded(), got) {
+		t.Fatalf("Len, Encoded = %d, %x", e.Len(), e.Encoded())
+	}
+}
+
+func TestEncoderSticky(t *testing.T) {
+	var e Encoder
+	e.Uint64(1).Uint64(Max + 1).Uint64(2).String("ignored")
+	if !errors.Is(e.Err(), ErrValueTooLarge) {
+		t.Fatalf("Err = %v, want ErrValueTooLarge", e.Err())
+	}
+	if e.Len() != 1 {
+		t.Fatalf("Len after failure = %d, want 1", e.Len())
+	}
+	if b, err := e.Finish(); b != nil || err != e.Err() {
+		t.Fatalf("Finish = %x, %v", b, err)
+	}
+
+	e.Reset()
+	e.Int64(MinSigned - 1)
+	var rangeErr *SignedRangeError
+	if !errors.As(e.Err(), &rangeErr) {
+		t.Fatalf("Err = %v, want *SignedRangeError", e.Err())
+	}
+}
+
+func TestEncoderReset(t *testing.T) {
+	e := NewEncoder(make([]byte, 0, 64))
+	e.Uint64(Max + 1)
+	e.Reset()
+	got, err := e.Uint64(5).Finish()
+	if err != nil || !bytes.Equal(got, []byte{5}) {
+		t.Fatalf("Finish after Reset = %x, %v", got, err)
+	}
+	if cap(got) != 64 {
+		t.Fatalf("Reset dropped the buffer capacity: %d", cap(got))
+	}
+}
+
+func TestEncoderGrow(t *testing.T) {
+	var e Encoder
+	e.Uint64(1)
+	e.Grow(100)
+	if cap(e.Encoded())-e.Len() < 100 {
+		t.Fatalf("Grow(100) left room for %d bytes", cap(e.Encoded())-e.Len())
+	}
+	if !bytes.Equal(e.Encoded(), []byte{1}) {
+		t.Fatalf("Grow changed the contents: %x", e.Encoded())
+	}
+}
+
+func TestEncoderPool(t *testing.T) {
+	e := GetEncoder()
+	e.Uint64(1).Uint64(Max + 1)
+	PutEncoder(e)
+	for i := 0; i < 4; i++ {
+		e := GetEncoder()
+		if e.Err() != nil || e.Len() != 0 {
+			t.Fatalf("pooled Encoder not reset: %d bytes, %v", e.Len(), e.Err())
+		}
+		PutEncoder(e)
+	}
+}
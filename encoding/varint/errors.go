@@ -0,0 +1,213 @@
+package varint
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Failures are reported with the sentinels below so that callers can branch
+// with errors.Is, whether the error is returned bare or wrapped in an
+// *OffsetError, a *StreamOffsetError or one of the other typed errors:
+//
+//   - io.EOF: input ended cleanly before a value started
+//   - ErrTruncated: input ended partway through a value or frame
+//   - ErrNonCanonical: strict parsing met a non-minimal encoding
+//   - ErrValueTooLarge: a value to encode exceeds Max
+//   - ErrOutOfRange: a decoded value does not fit the requested type
+//   - ErrOverflow: a derived quantity, such as a delta sum, exceeds Max
+//   - ErrLimitExceeded: a declared length or count exceeds the caller's limit
+//   - ErrTrailingBytes: input remains where it must be consumed exactly
+
+// ErrTruncated is reported when input ends partway through a value or frame.
+// It is io.ErrUnexpectedEOF itself, so comparisons against either keep working
+var ErrTruncated = io.ErrUnexpectedEOF
+
+// ErrValueTooLarge is reported when a value exceeds Max
+var ErrValueTooLarge = errors.New("value too big to fit in 62 bits")
+
+// ErrNonCanonical is reported by strict parsers when a value is encoded with
+// more bytes than necessary
+var ErrNonCanonical = errors.New("non-canonical varint encoding")
+
+// ErrInvalidLength is reported when an explicit encoded length is not one of
+// 1, 2, 4 or 8
+var ErrInvalidLength = errors.New("invalid varint length")
+
+// ErrLengthTooShort is reported when a value does not fit in an explicitly
+// requested encoded length
+var ErrLengthTooShort = errors.New("value does not fit in the requested varint length")
+
+// ErrOutOfRange is reported when a decoded value does not fit the requested
+// Go type
+var ErrOutOfRange = errors.New("value out of range")
+
+// ErrOverflow is reported when a decoded quantity, such as a running sum of
+// deltas, exceeds Max
+var ErrOverflow = errors.New("decoded value overflows 62 bits")
+
+// ErrNotSorted is reported when a sequence that must be non-decreasing is not
+var ErrNotSorted = errors.New("values are not in non-decreasing order")
+
+// ErrDuplicateKey is reported when an encoded map repeats a key
+var ErrDuplicateKey = errors.New("duplicate map key")
+
+// ErrLimitExceeded is reported when a declared length or count, or the size
+// of a stream, exceeds the limit the caller is willing to accept
+var ErrLimitExceeded = errors.New("declared length exceeds limit")
+
+// ErrInvalidUTF8 is reported when a string field is not valid UTF-8
+var ErrInvalidUTF8 = errors.New("string is not valid UTF-8")
+
+// ErrTimeRange is reported when a time is outside the range AppendTime can
+// represent
+var ErrTimeRange = errors.New("time out of varint range")
+
+// ErrTrailingBytes is reported when input remains after a value or frame that
+// must consume it exactly
+var ErrTrailingBytes = errors.New("trailing bytes after varint data")
+
+// ErrPoisoned is reported by a MessageConn after an earlier failure left the
+// stream without recoverable frame boundaries
+var ErrPoisoned = errors.New("connection poisoned by an earlier partial frame")
+
+// ErrInvalidOffset is reported when a decode offset lies outside the buffer
+var ErrInvalidOffset = errors.New("offset out of range")
+
+// ErrEmptyRun is reported when a run-length encoded section declares no values
+var ErrEmptyRun = errors.New("run-length section with no values")
+
+// ErrNoSyncMarker is reported when a sync record does not start with
+// SyncMarker
+var ErrNoSyncMarker = errors.New("sync record does not start with a marker")
+
+// ErrBadEscape is reported when the escape byte inside a sync record is
+// followed by anything other than the literal escape code
+var ErrBadEscape = errors.New("invalid escape in sync record")
+
+// ValueTooLargeError is returned (or used as the panic value) when a value
+// exceeding Max is passed to an encoding function. It matches
+// ErrValueTooLarge under errors.Is
+type ValueTooLargeError struct {
+	Num uint64
+}
+
+// Value returns the offending value
+func (e *ValueTooLargeError) Value() uint64 {
+	return e.Num
+}
+
+func (e *ValueTooLargeError) Error() string {
+	return fmt.Sprintf("value too big to fit in 62 bits: %d", e.Num)
+}
+
+func (e *ValueTooLargeError) Is(target error) bool {
+	return target == ErrValueTooLarge
+}
+
+// OffsetError records the byte offset at which decoding a buffer failed
+type OffsetError struct {
+	Offset int
+	Err    error
+}
+
+func (e *OffsetError) Error() string {
+	return fmt.Sprintf("varint at byte %d: %v", e.Offset, e.Err)
+}
+
+func (e *OffsetError) Unwrap() error {
+	return e.Err
+}
+
+// StreamOffsetError records the position in a stream at which decoding
+// failed. It is OffsetError for readers and files, whose offsets can exceed
+// the range of int on 32-bit platforms
+type StreamOffsetError struct {
+	Offset int64
+	Err    error
+}
+
+func (e *StreamOffsetError) Error() string {
+	return fmt.Sprintf("varint at byte %d: %v", e.Offset, e.Err)
+}
+
+func (e *StreamOffsetError) Unwrap() error {
+	return e.Err
+}
+
+// SkipError is returned by Skip when b runs out before the requested number of
+// varints has been skipped
+type SkipError struct {
+	Skipped int // number of complete varints skipped
+	Offset  int // offset just past the last complete varint
+	Err     error
+}
+
+func (e *SkipError) Error() string {
+	return fmt.Sprintf("skipped %d varints up to byte %d: %v", e.Skipped, e.Offset, e.Err)
+}
+
+func (e *SkipError) Unwrap() error {
+	return e.Err
+}
+
+// OutOfRangeError is returned when a decoded value exceeds the limit of a
+// narrower type. It matches ErrOutOfRange under errors.Is
+type OutOfRangeError struct {
+	Value uint64
+	Limit uint64
+}
+
+func (e *OutOfRangeError) Error() string {
+	return fmt.Sprintf("value %d out of range (limit %d)", e.Value, e.Limit)
+}
+
+func (e *OutOfRangeError) Is(target error) bool {
+	return target == ErrOutOfRange
+}
+
+// SignedRangeError is returned (or used as the panic value) when a signed
+// value outside [MinSigned, MaxSigned] is passed to an encoding function. It
+// matches ErrValueTooLarge under errors.Is
+type SignedRangeError struct {
+	Num int64
+}
+
+func (e *SignedRangeError) Error() string {
+	return fmt.Sprintf("signed value out of 62-bit zigzag range: %d", e.Num)
+}
+
+func (e *SignedRangeError) Is(target error) bool {
+	return target == ErrValueTooLarge
+}
+
+// FrameSizeError is returned by every length-enforcing path (ReadMessage,
+// ParseBytesMax, Decoder.Bytes, Reader.Bytes and friends) when a declared
+// length exceeds the caller's limit. It is returned before anything is
+// allocated or read for the payload, so a server can log the declared size and
+// answer with a protocol error. It matches ErrLimitExceeded under errors.Is
+type FrameSizeError struct {
+	Declared uint64
+	Limit    uint64
+}
+
+func (e *FrameSizeError) Error() string {
+	return fmt.Sprintf("declared length %d exceeds limit %d", e.Declared, e.Limit)
+}
+
+func (e *FrameSizeError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// IncompleteError is returned by ParseFrameHeader when b holds only the start
+// of a header. Need is the smallest total number of bytes, counted from the
+// start of b, that could complete it given what has arrived; it may grow
+// once more bytes reveal the widths of the fields. An incremental parser
+// reads until it has Need bytes and tries again
+type IncompleteError struct {
+	Need int
+}
+
+func (e *IncompleteError) Error() string {
+	return fmt.Sprintf("incomplete frame header: need at least %d bytes", e.Need)
+}
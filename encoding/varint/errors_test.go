@@ -0,0 +1,158 @@
+package varint
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"testing"
+)
+
+// -------------------------
+// ValueTooLargeError
+// -------------------------
+
+func TestValueTooLargeErrorPanics(t *testing.T) {
+	fns := map[string]func(){
+		"Len":    func() { Len(Max + 1) },
+		"Append": func() { Append(nil, Max+1) },
+		"Put":    func() { Put(make([]byte, 8), Max+1) },
+	}
+	for name, fn := range fns {
+		func() {
+			defer func() {
+				err, ok := recover().(error)
+				if !ok {
+					t.Fatalf("%s: recovered value is not an error", name)
+				}
+				if !errors.Is(err, ErrValueTooLarge) {
+					t.Fatalf("%s: errors.Is(%v, ErrValueTooLarge) = false", name, err)
+				}
+				var vErr *ValueTooLargeError
+				if !errors.As(err, &vErr) {
+					t.Fatalf("%s: errors.As(%v) failed", name, err)
+				}
+				if vErr.Value() != Max+1 {
+					t.Fatalf("%s: Value() = %d, want %d", name, vErr.Value(), Max+1)
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestWriteValueTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, math.MaxUint64)
+	var vErr *ValueTooLargeError
+	if !errors.As(err, &vErr) || vErr.Value() != math.MaxUint64 {
+		t.Fatalf("Write(MaxUint64) error = %v, want *ValueTooLargeError", err)
+	}
+	if !errors.Is(err, ErrValueTooLarge) {
+		t.Fatalf("errors.Is(%v, ErrValueTooLarge) = false", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("Write(MaxUint64) wrote %d bytes", buf.Len())
+	}
+}
+
+// -------------------------
+// FrameSizeError
+// -------------------------
+
+func TestFrameSizeErrorPaths(t *testing.T) {
+	frame := AppendBytes(nil, bytes.Repeat([]byte{1}, 100))
+	paths := map[string]func() error{
+		"ParseBytesMax": func() error {
+			_, _, err := ParseBytesMax(frame, 10)
+			return err
+		},
+		"ParseString": func() error {
+			_, _, err := ParseString(frame, 10)
+			return err
+		},
+		"ReadMessage": func() error {
+			_, err := ReadMessage(bytes.NewReader(frame), 10, nil)
+			return err
+		},
+		"ReadBytes": func() error {
+			_, err := ReadBytes(bytes.NewReader(frame), 10)
+			return err
+		},
+		"Decoder.Bytes": func() error {
+			d := NewDecoder(frame)
+			d.Bytes(10)
+			return d.Err()
+		},
+		"Reader.Bytes": func() error {
+			_, err := NewReader(bytes.NewReader(frame), 0).Bytes(10)
+			return err
+		},
+	}
+	for name, fn := range paths {
+		err := fn()
+		var sizeErr *FrameSizeError
+		if !errors.As(err, &sizeErr) {
+			t.Fatalf("%s error = %v, want *FrameSizeError", name, err)
+		}
+		if sizeErr.Declared != 100 || sizeErr.Limit != 10 {
+			t.Fatalf("%s error = %+v, want declared 100 and limit 10", name, sizeErr)
+		}
+		if !errors.Is(err, ErrLimitExceeded) {
+			t.Fatalf("%s: errors.Is(%v, ErrLimitExceeded) = false", name, err)
+		}
+	}
+}
+
+// -------------------------
+// Sentinels
+// -------------------------
+
+func TestErrorTaxonomy(t *testing.T) {
+	truncated := Append(nil, Max)[:5]
+	padded := []byte{0x40, 1}
+	big := Append(nil, 1<<40)
+	cases := []struct {
+		name string
+		fn   func() error
+		want error
+	}{
+		{"Parse/empty", func() error { _, _, err := Parse(nil); return err }, io.EOF},
+		{"Parse", func() error { _, _, err := Parse(truncated); return err }, ErrTruncated},
+		{"ParseAll", func() error { _, err := ParseAll(truncated); return err }, ErrTruncated},
+		{"Read", func() error { _, err := Read(bytes.NewReader(truncated)); return err }, ErrTruncated},
+		{"ReadFrom", func() error { _, _, err := ReadFrom(bytes.NewReader(truncated)); return err }, ErrTruncated},
+		{"Decoder", func() error {
+			d := NewDecoder(truncated)
+			d.Uint64()
+			return d.Err()
+		}, ErrTruncated},
+		{"Validate", func() error { return Validate(truncated) }, ErrTruncated},
+		{"ParseCanonical", func() error { _, _, err := ParseCanonical(padded); return err }, ErrNonCanonical},
+		{"ValidateCanonical", func() error { return ValidateCanonical(padded) }, ErrNonCanonical},
+		{"Decoder/strict", func() error {
+			d := NewDecoder(padded)
+			d.SetStrict(true)
+			d.Uint64()
+			return d.Err()
+		}, ErrNonCanonical},
+		{"AppendChecked", func() error { _, err := AppendChecked(nil, Max+1); return err }, ErrValueTooLarge},
+		{"ParseUint32", func() error { _, _, err := ParseUint32(big); return err }, ErrOutOfRange},
+		{"ParseDeltas", func() error {
+			_, err := ParseDeltas(AppendMany(nil, Max, 1), nil)
+			return err
+		}, ErrOverflow},
+		{"ParseBytesMax", func() error { _, _, err := ParseBytesMax(AppendBytes(nil, big), 4); return err }, ErrLimitExceeded},
+		{"ParseUint64Slice", func() error { _, _, err := ParseUint64Slice(AppendMany(nil, 2, 1, 1), 1); return err }, ErrLimitExceeded},
+		{"ParseExact", func() error { _, err := ParseExact(append(padded, 0)); return err }, ErrTrailingBytes},
+		{"Value.UnmarshalBinary", func() error { var v Value; return v.UnmarshalBinary([]byte{1, 2}) }, ErrTrailingBytes},
+	}
+	for _, c := range cases {
+		if err := c.fn(); !errors.Is(err, c.want) {
+			t.Fatalf("%s error = %v, want %v", c.name, err, c.want)
+		}
+	}
+	if !errors.Is(ErrTruncated, io.ErrUnexpectedEOF) {
+		t.Fatal("ErrTruncated does not match io.ErrUnexpectedEOF")
+	}
+}
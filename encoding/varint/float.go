@@ -0,0 +1,57 @@
+package varint
+
+import (
+	"io"
+	"math"
+)
+
+// Floats are first mapped to an order-preserving 64-bit key: the sign bit is
+// flipped for non-negative values and all bits are flipped for negative ones,
+// so comparing keys as unsigned integers matches numeric ordering (with -0
+// sorting just below +0 and NaNs sorting beyond the infinities of their sign).
+// As a key needs all 64 bits, it is carried as two varints: key>>2, which
+// always fits in 62 bits, followed by key&3 in a single byte. Comparing the
+// pair lexicographically by value preserves the ordering, and an encoded
+// float takes at most 9 bytes
+
+// AppendFloat64 encodes f and appends it to dst
+func AppendFloat64(dst []byte, f float64) []byte {
+	key := floatKey(f)
+	dst = Append(dst, key>>2)
+	return append(dst, byte(key&3))
+}
+
+// ParseFloat64 reads a float encoded by AppendFloat64 from b and returns the
+// value and bytes consumed. NaN payloads round-trip exactly
+func ParseFloat64(b []byte) (float64, int, error) {
+	hi, n, err := Parse(b)
+	if err != nil {
+		return 0, 0, err
+	}
+	lo, m, err := Parse(b[n:])
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, 0, err
+	}
+	if lo > 3 {
+		return 0, 0, &OutOfRangeError{Value: lo, Limit: 3}
+	}
+	return keyFloat(hi<<2 | lo), n + m, nil
+}
+
+func floatKey(f float64) uint64 {
+	u := math.Float64bits(f)
+	if u>>63 != 0 {
+		return ^u
+	}
+	return u | 1<<63
+}
+
+func keyFloat(key uint64) float64 {
+	if key>>63 != 0 {
+		return math.Float64frombits(key &^ (1 << 63))
+	}
+	return math.Float64frombits(^key)
+}
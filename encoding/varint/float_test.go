@@ -0,0 +1,91 @@
+package varint
+
+import (
+	"cmp"
+	"io"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// -------------------------
+// AppendFloat64 / ParseFloat64
+// -------------------------
+
+func TestFloat64RoundTrip(t *testing.T) {
+	values := []float64{
+		0, math.Copysign(0, -1), 1, -1, 0.1, -0.1,
+		math.MaxFloat64, -math.MaxFloat64,
+		math.SmallestNonzeroFloat64, -math.SmallestNonzeroFloat64,
+		math.Inf(1), math.Inf(-1),
+	}
+	for _, f := range values {
+		b := AppendFloat64(nil, f)
+		got, n, err := ParseFloat64(b)
+		if err != nil || math.Float64bits(got) != math.Float64bits(f) || n != len(b) {
+			t.Fatalf("ParseFloat64(AppendFloat64(%v)) = %v, %d, %v", f, got, n, err)
+		}
+		if len(b) > 9 {
+			t.Fatalf("AppendFloat64(%v) took %d bytes", f, len(b))
+		}
+	}
+}
+
+func TestFloat64NaN(t *testing.T) {
+	for _, bits := range []uint64{
+		math.Float64bits(math.NaN()),
+		0x7FF0000000000001, // signalling NaN
+		0xFFF8000000000000, // negative quiet NaN
+		0xFFFFFFFFFFFFFFFF, // negative NaN, all payload bits set
+	} {
+		b := AppendFloat64(nil, math.Float64frombits(bits))
+		got, _, err := ParseFloat64(b)
+		if err != nil || math.Float64bits(got) != bits {
+			t.Fatalf("NaN %#x round-tripped to %#x, %v", bits, math.Float64bits(got), err)
+		}
+	}
+}
+
+func TestFloat64Ordering(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	special := []float64{0, math.Copysign(0, -1), math.Inf(1), math.Inf(-1), math.MaxFloat64, -math.SmallestNonzeroFloat64}
+	randomFloat := func() float64 {
+		if rng.IntN(10) == 0 {
+			return special[rng.IntN(len(special))]
+		}
+		return math.Float64frombits(rng.Uint64())
+	}
+	for i := 0; i < 100000; i++ {
+		a, b := randomFloat(), randomFloat()
+		if math.IsNaN(a) || math.IsNaN(b) || a == b {
+			continue
+		}
+		if got, want := compareEncodedFloats(t, a, b), cmp.Compare(a, b); got != want {
+			t.Fatalf("encoded order of %v and %v = %d, want %d", a, b, got, want)
+		}
+	}
+	if compareEncodedFloats(t, math.Copysign(0, -1), 0) != -1 {
+		t.Fatal("-0 does not sort below +0")
+	}
+}
+
+// compareEncodedFloats compares the (key>>2, key&3) varint pairs of a and b
+func compareEncodedFloats(t *testing.T, a, b float64) int {
+	t.Helper()
+	ea, eb := AppendFloat64(nil, a), AppendFloat64(nil, b)
+	ha, na, _ := Parse(ea)
+	hb, nb, _ := Parse(eb)
+	return cmp.Or(cmp.Compare(ha, hb), cmp.Compare(ea[na], eb[nb]))
+}
+
+func TestParseFloat64Errors(t *testing.T) {
+	b := AppendFloat64(nil, 1.5)
+	if _, _, err := ParseFloat64(b[:len(b)-1]); err != io.ErrUnexpectedEOF {
+		t.Fatalf("ParseFloat64(truncated) error = %v, want io.ErrUnexpectedEOF", err)
+	}
+	b[len(b)-1] = 4
+	checkOutOfRange(t, "ParseFloat64", func([]byte) error {
+		_, _, err := ParseFloat64(b)
+		return err
+	}, 3)
+}
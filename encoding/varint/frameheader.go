@@ -0,0 +1,32 @@
+package varint
+
+// MaxFrameHeaderLen is the longest frame header: two 8-byte varints
+const MaxFrameHeaderLen = 2 * MaxLen
+
+// AppendFrameHeader appends the envelope of an HTTP/3 frame (RFC 9114 section
+// 7.1), a type and a payload length as varints, to dst. It panics with a
+// *ValueTooLargeError if either exceeds Max
+func AppendFrameHeader(dst []byte, typ, length uint64) []byte {
+	return Append(Append(dst, typ), length)
+}
+
+// ParseFrameHeader reads a frame header written by AppendFrameHeader from the
+// start of b and returns the type, the payload length and the size of the
+// header. If b holds less than a whole header, including when it is empty,
+// it fails with an *IncompleteError reporting how many bytes are needed
+func ParseFrameHeader(b []byte) (typ, length uint64, n int, err error) {
+	if len(b) == 0 {
+		return 0, 0, 0, &IncompleteError{Need: 2}
+	}
+	typLen := EncodedLen(b[0])
+	if len(b) <= typLen {
+		return 0, 0, 0, &IncompleteError{Need: typLen + 1}
+	}
+	n = typLen + EncodedLen(b[typLen])
+	if len(b) < n {
+		return 0, 0, 0, &IncompleteError{Need: n}
+	}
+	typ, _, _ = Parse(b[:typLen])
+	length, _, _ = Parse(b[typLen:n])
+	return typ, length, n, nil
+}
@@ -0,0 +1,75 @@
+package varint
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// -------------------------
+// Frame headers
+// -------------------------
+
+func TestFrameHeaderVectors(t *testing.T) {
+	// Frame types from RFC 9114 sections 7.2 and 7.2.8, and lengths from the
+	// sample varints of RFC 9000 appendix A.1
+	cases := []struct {
+		name        string
+		typ, length uint64
+		want        []byte
+	}{
+		{"empty DATA", 0x00, 0, []byte{0x00, 0x00}},
+		{"HEADERS of 37", 0x01, 37, []byte{0x01, 0x25}},
+		{"SETTINGS of 15293", 0x04, 15293, []byte{0x04, 0x7b, 0xbd}},
+		{"GOAWAY of 494878333", 0x07, 494878333, []byte{0x07, 0x9d, 0x7f, 0x3e, 0x7d}},
+		{"reserved type 0x21", 0x21, 151288809941952652,
+			[]byte{0x21, 0xc2, 0x19, 0x7c, 0x5e, 0xff, 0x14, 0xe8, 0x8c}},
+		{"reserved type 0x1f*64+0x21", 0x1f*64 + 0x21, 0,
+			[]byte{0x47, 0xe1, 0x00}},
+	}
+	for _, c := range cases {
+		got := AppendFrameHeader(nil, c.typ, c.length)
+		if !bytes.Equal(got, c.want) {
+			t.Fatalf("%s: AppendFrameHeader = %x, want %x", c.name, got, c.want)
+		}
+		typ, length, n, err := ParseFrameHeader(append(c.want, 0xff, 0xff))
+		if typ != c.typ || length != c.length || n != len(c.want) || err != nil {
+			t.Fatalf("%s: ParseFrameHeader = %#x, %d, %d, %v", c.name, typ, length, n, err)
+		}
+	}
+}
+
+func TestFrameHeaderIncomplete(t *testing.T) {
+	// A 2-byte type and a 4-byte length
+	hdr := AppendFrameHeader(nil, 0x100, 1<<20)
+	wantNeed := []int{2, 3, 3, 6, 6, 6}
+	for cut, want := range wantNeed {
+		_, _, n, err := ParseFrameHeader(hdr[:cut])
+		var inc *IncompleteError
+		if !errors.As(err, &inc) || inc.Need != want || n != 0 {
+			t.Fatalf("ParseFrameHeader(%x) = %d, %v; want to need %d bytes", hdr[:cut], n, err, want)
+		}
+		if inc.Need <= cut {
+			t.Fatalf("ParseFrameHeader(%x) asks for %d bytes, no more than it has", hdr[:cut], inc.Need)
+		}
+	}
+	if _, _, n, err := ParseFrameHeader(hdr); n != len(hdr) || err != nil {
+		t.Fatalf("ParseFrameHeader(%x) = %d, %v", hdr, n, err)
+	}
+
+	// Feeding a byte at a time, each answer is a safe amount to wait for
+	stream := AppendFrameHeader(nil, Max, Max)
+	have := 0
+	for {
+		_, _, n, err := ParseFrameHeader(stream[:have])
+		var inc *IncompleteError
+		if errors.As(err, &inc) {
+			have = inc.Need
+			continue
+		}
+		if err != nil || n != MaxFrameHeaderLen {
+			t.Fatalf("ParseFrameHeader(%x) = %d, %v", stream[:have], n, err)
+		}
+		break
+	}
+}
@@ -0,0 +1,60 @@
+package varint
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// The full-range format extends the varint to every uint64. Values up to Max
+// are written exactly as Append writes them; larger ones are written as the
+// two bytes fullEscape followed by the value as 8 bytes big-endian, 10 bytes
+// in all. The escape is the 2-byte encoding of zero, which Append never
+// produces, so ParseCanonical and the other strict decoders reject it with
+// ErrNonCanonical rather than misreading it. Lenient decoders such as Parse
+// would read a zero followed by junk, so a field must be documented as
+// full-range and only ever be read with ParseFull.
+//
+// A 9-byte escape would need a first byte of its own, and every first byte
+// already begins some canonical varint
+
+// fullEscape introduces a value above Max in the full-range format
+const fullEscape = "\x40\x00"
+
+// MaxFullLen is the longest full-range encoding
+const MaxFullLen = len(fullEscape) + 8
+
+// FullLen returns the number of bytes AppendFull writes for v
+func FullLen(v uint64) int {
+	if v > Max {
+		return MaxFullLen
+	}
+	return Len(v)
+}
+
+// AppendFull appends v to dst in the full-range format. Unlike Append it
+// accepts every uint64
+func AppendFull(dst []byte, v uint64) []byte {
+	if v <= Max {
+		return Append(dst, v)
+	}
+	dst = append(dst, fullEscape...)
+	return binary.BigEndian.AppendUint64(dst, v)
+}
+
+// ParseFull decodes a value written by AppendFull and returns it with the
+// number of bytes consumed. Plain varints are accepted as Parse accepts them.
+// An escaped value that is not above Max is ErrNonCanonical, so each large
+// value has a single encoding; an escape cut short is io.ErrUnexpectedEOF
+func ParseFull(b []byte) (value uint64, consumed int, err error) {
+	if len(b) < len(fullEscape) || string(b[:len(fullEscape)]) != fullEscape {
+		return Parse(b)
+	}
+	if len(b) < MaxFullLen {
+		return 0, 0, io.ErrUnexpectedEOF
+	}
+	v := binary.BigEndian.Uint64(b[len(fullEscape):])
+	if v <= Max {
+		return 0, 0, ErrNonCanonical
+	}
+	return v, MaxFullLen, nil
+}
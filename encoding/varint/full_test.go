@@ -0,0 +1,86 @@
+package varint
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+)
+
+// -------------------------
+// Full-range format
+// -------------------------
+
+func TestFull(t *testing.T) {
+	cases := []struct {
+		v    uint64
+		want []byte
+	}{
+		{0, []byte{0}},
+		{63, []byte{0x3f}},
+		{64, []byte{0x40, 0x40}},
+		{Max, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{Max + 1, []byte{0x40, 0x00, 0x40, 0, 0, 0, 0, 0, 0, 0}},
+		{math.MaxUint64, []byte{0x40, 0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+	}
+	for _, c := range cases {
+		got := AppendFull(nil, c.v)
+		if !bytes.Equal(got, c.want) || FullLen(c.v) != len(got) {
+			t.Fatalf("AppendFull(%#x) = %x, FullLen %d; want %x", c.v, got, FullLen(c.v), c.want)
+		}
+		v, n, err := ParseFull(append(got, 0xee))
+		if err != nil || v != c.v || n != len(got) {
+			t.Fatalf("ParseFull(%x) = %#x, %d, %v; want %#x", got, v, n, err, c.v)
+		}
+	}
+
+	// Values up to Max are plain varints in both directions
+	for _, v := range testValues {
+		if got := AppendFull(nil, v); !bytes.Equal(got, Append(nil, v)) {
+			t.Fatalf("AppendFull(%d) = %x, want Append's %x", v, got, Append(nil, v))
+		}
+	}
+}
+
+func TestFullEscapeNotBaseFormat(t *testing.T) {
+	// A strict base decoder refuses the escape instead of misreading it
+	esc := AppendFull(nil, Max+1)
+	if _, _, err := ParseCanonical(esc); err != ErrNonCanonical {
+		t.Fatalf("ParseCanonical(%x) error = %v, want ErrNonCanonical", esc, err)
+	}
+	for _, v := range bulkCorpus(10000) {
+		if enc := Append(nil, v); bytes.HasPrefix(enc, []byte(fullEscape)) {
+			t.Fatalf("Append(%d) = %x starts with the escape", v, enc)
+		}
+	}
+}
+
+func TestParseFullErrors(t *testing.T) {
+	esc := AppendFull(nil, math.MaxUint64)
+	for cut := 1; cut < len(esc); cut++ {
+		if _, _, err := ParseFull(esc[:cut]); err != io.ErrUnexpectedEOF {
+			t.Fatalf("ParseFull(%x) error = %v, want io.ErrUnexpectedEOF", esc[:cut], err)
+		}
+	}
+	if _, _, err := ParseFull(nil); err != io.EOF {
+		t.Fatalf("ParseFull(nil) error = %v, want io.EOF", err)
+	}
+	small := append([]byte(fullEscape), 0x3f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+	if _, _, err := ParseFull(small); err != ErrNonCanonical {
+		t.Fatalf("ParseFull(escaped Max) error = %v, want ErrNonCanonical", err)
+	}
+}
+
+func FuzzFull(f *testing.F) {
+	f.Add(uint64(0))
+	f.Add(uint64(Max))
+	f.Add(uint64(Max + 1))
+	f.Add(uint64(math.MaxUint64))
+	f.Fuzz(func(t *testing.T, v uint64) {
+		b := AppendFull(nil, v)
+		got, n, err := ParseFull(b)
+		if err != nil || got != v || n != len(b) {
+			t.Fatalf("round trip of %#x via %x = %#x, %d, %v", v, b, got, n, err)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+// Package spec is a deliberately plain implementation of the RFC 9000
+// variable-length integer encoding. It favours being obviously correct over
+// being fast and serves as the oracle that the optimized code paths in package
+// varint are tested and fuzzed against. Do not use it outside of tests
+package spec
+
+import (
+	"errors"
+	"io"
+)
+
+// Max is the largest encodable value, 2^62-1
+const Max = 4611686018427387903
+
+// ErrTooLarge is returned by Append for values above Max
+var ErrTooLarge = errors.New("value exceeds 2^62-1")
+
+// Length returns the number of bytes the shortest encoding of v takes, or 0 if
+// v exceeds Max
+func Length(v uint64) int {
+	switch {
+	case v <= 63:
+		return 1
+	case v <= 16383:
+		return 2
+	case v <= 1073741823:
+		return 4
+	case v <= Max:
+		return 8
+	default:
+		return 0
+	}
+}
+
+// Append appends the shortest encoding of v to dst
+func Append(dst []byte, v uint64) ([]byte, error) {
+	length := Length(v)
+	if length == 0 {
+		return dst, ErrTooLarge
+	}
+	out := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		out[i] = byte(v % 256)
+		v /= 256
+	}
+	// The two high bits of the first byte hold log2 of the length
+	prefix := map[int]byte{1: 0, 2: 1, 4: 2, 8: 3}[length]
+	out[0] += prefix * 64
+	return append(dst, out...), nil
+}
+
+// Parse decodes the varint at the start of b and returns its value and the
+// number of bytes it occupies. It returns io.EOF for an empty b and
+// io.ErrUnexpectedEOF if b ends inside the value
+func Parse(b []byte) (uint64, int, error) {
+	if len(b) == 0 {
+		return 0, 0, io.EOF
+	}
+	length := []int{1, 2, 4, 8}[b[0]/64]
+	if len(b) < length {
+		return 0, 0, io.ErrUnexpectedEOF
+	}
+	v := uint64(b[0] % 64)
+	for i := 1; i < length; i++ {
+		v = v*256 + uint64(b[i])
+	}
+	return v, length, nil
+}
+
+// Canonical reports whether the varint at the start of b uses the shortest
+// possible encoding for its value
+func Canonical(b []byte) bool {
+	v, n, err := Parse(b)
+	return err == nil && n == Length(v)
+}
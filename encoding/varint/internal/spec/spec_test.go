@@ -0,0 +1,45 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// Examples from RFC 9000, Appendix A.1
+var rfcExamples = []struct {
+	enc string
+	v   uint64
+}{
+	{"c2197c5eff14e88c", 151288809941952652},
+	{"9d7f3e7d", 494878333},
+	{"7bbd", 15293},
+	{"25", 37},
+}
+
+func TestRFCExamples(t *testing.T) {
+	for _, ex := range rfcExamples {
+		enc, _ := hex.DecodeString(ex.enc)
+		v, n, err := Parse(enc)
+		if err != nil || v != ex.v || n != len(enc) {
+			t.Fatalf("Parse(%s) = %d, %d, %v; want %d", ex.enc, v, n, err, ex.v)
+		}
+		got, err := Append(nil, ex.v)
+		if err != nil || !bytes.Equal(got, enc) {
+			t.Fatalf("Append(%d) = %x, %v; want %s", ex.v, got, err, ex.enc)
+		}
+	}
+	// 37 may also be sent as a two-byte encoding
+	if v, n, err := Parse([]byte{0x40, 0x25}); err != nil || v != 37 || n != 2 {
+		t.Fatalf("Parse(4025) = %d, %d, %v; want 37, 2", v, n, err)
+	}
+	if Canonical([]byte{0x40, 0x25}) {
+		t.Fatal("Canonical(4025) = true")
+	}
+}
+
+func TestAppendTooLarge(t *testing.T) {
+	if _, err := Append(nil, Max+1); err != ErrTooLarge {
+		t.Fatalf("Append(Max+1) error = %v, want ErrTooLarge", err)
+	}
+}
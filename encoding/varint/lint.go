@@ -0,0 +1,109 @@
+package varint
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Finding describes one problem reported by Lint
+type Finding struct {
+	// Offset is the position of the varint's first byte
+	Offset int
+	// Width is the number of bytes the encoding takes, as given by its
+	// length bits
+	Width int
+	// MinWidth is the number of bytes the minimal encoding of Value takes.
+	// It is zero for a truncated value
+	MinWidth int
+	// Value is the decoded value, or zero for a truncated value
+	Value uint64
+	// Truncated is set when b ends inside the varint
+	Truncated bool
+}
+
+func (f Finding) String() string {
+	if f.Truncated {
+		return fmt.Sprintf("byte %d: truncated %d-byte varint", f.Offset, f.Width)
+	}
+	return fmt.Sprintf("byte %d: value %d encoded in %d bytes, minimal is %d", f.Offset, f.Value, f.Width, f.MinWidth)
+}
+
+// Lint scans a buffer of back-to-back varints and reports every value that is
+// not minimally encoded, in order. A truncated final value is reported as a
+// Finding rather than an error. It returns nil for a clean buffer
+func Lint(b []byte) []Finding {
+	var findings []Finding
+	scan(b, func(f Finding) bool {
+		if f.Truncated || f.MinWidth < f.Width {
+			findings = append(findings, f)
+		}
+		return true
+	})
+	return findings
+}
+
+// scan describes every varint in b to yield as a Finding, clean or not,
+// stopping after a truncated value or when yield returns false
+func scan(b []byte, yield func(Finding) bool) {
+	for off := 0; off < len(b); {
+		v, n, err := Parse(b[off:])
+		if err != nil {
+			yield(Finding{Offset: off, Width: EncodedLen(b[off]), Truncated: true})
+			return
+		}
+		if !yield(Finding{Offset: off, Width: n, MinWidth: Len(v), Value: v}) {
+			return
+		}
+		off += n
+	}
+}
+
+// Explain renders b, a buffer of back-to-back varints, one value per line,
+// for debugging. See ExplainTo for the format
+func Explain(b []byte) string {
+	var sb strings.Builder
+	ExplainTo(&sb, b)
+	return sb.String()
+}
+
+// ExplainTo writes the rendering of b to w and returns the number of bytes
+// written. Each line holds the offset right-aligned in 6 columns, the encoded
+// bytes in hex padded to 23 columns, the width and the decoded value,
+// followed by a note when the encoding is longer than necessary. These are
+// two lines exactly as written:
+//
+//	"     0  40 05                    2  5  non-minimal, 1 byte needed\n"
+//	"     2  41 2c                    2  300\n"
+//
+// A truncated value ends the rendering with a line holding the bytes present
+// and how many were expected. The format is stable, so the output can be
+// compared against golden files
+func ExplainTo(w io.Writer, b []byte) (int64, error) {
+	var total int64
+	var err error
+	scan(b, func(f Finding) bool {
+		var n int
+		if f.Truncated {
+			n, err = fmt.Fprintf(w, "%6d  %-23s  %d  truncated, %d of %d bytes present\n",
+				f.Offset, fmt.Sprintf("% x", b[f.Offset:]), f.Width, len(b)-f.Offset, f.Width)
+		} else {
+			note := ""
+			if f.MinWidth < f.Width {
+				note = fmt.Sprintf("  non-minimal, %d byte%s needed", f.MinWidth, plural(f.MinWidth))
+			}
+			n, err = fmt.Fprintf(w, "%6d  %-23s  %d  %d%s\n",
+				f.Offset, fmt.Sprintf("% x", b[f.Offset:f.Offset+f.Width]), f.Width, f.Value, note)
+		}
+		total += int64(n)
+		return err == nil
+	})
+	return total, err
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
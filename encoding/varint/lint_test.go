@@ -0,0 +1,83 @@
+package varint
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// -------------------------
+// Lint
+// -------------------------
+
+func TestLint(t *testing.T) {
+	if got := Lint(AppendMany(nil, testValues...)); got != nil {
+		t.Fatalf("Lint(canonical) = %v, want nil", got)
+	}
+	var b []byte
+	b = append(b, 0x40, 0x05)             // 5 in 2 bytes
+	b = Append(b, 300)                    // canonical
+	b = append(b, 0x80, 0x00, 0x01, 0x2c) // 300 in 4 bytes
+	b = append(b, 0xc0, 0, 0, 0, 0, 0, 0) // truncated 8-byte value
+	want := []Finding{
+		{Offset: 0, Width: 2, MinWidth: 1, Value: 5},
+		{Offset: 4, Width: 4, MinWidth: 2, Value: 300},
+		{Offset: 8, Width: 8, Truncated: true},
+	}
+	if got := Lint(b); !slices.Equal(got, want) {
+		t.Fatalf("Lint = %v, want %v", got, want)
+	}
+}
+
+func TestFindingString(t *testing.T) {
+	cases := []struct {
+		f    Finding
+		want string
+	}{
+		{Finding{Offset: 4, Width: 4, MinWidth: 2, Value: 300}, "byte 4: value 300 encoded in 4 bytes, minimal is 2"},
+		{Finding{Offset: 8, Width: 8, Truncated: true}, "byte 8: truncated 8-byte varint"},
+	}
+	for _, c := range cases {
+		if got := c.f.String(); got != c.want {
+			t.Fatalf("String() = %q, want %q", got, c.want)
+		}
+	}
+}
+
+// -------------------------
+// Explain
+// -------------------------
+
+func TestExplain(t *testing.T) {
+	var b []byte
+	b = append(b, 0x40, 0x05)             // 5 in 2 bytes
+	b = Append(b, 300)                    // canonical
+	b = append(b, 0x80, 0x00, 0x01, 0x2c) // 300 in 4 bytes
+	b = Append(b, Max)
+	b = append(b, 0xc0, 0, 0, 0, 0, 0, 0) // truncated 8-byte value
+	want := "" +
+		"     0  40 05                    2  5  non-minimal, 1 byte needed\n" +
+		"     2  41 2c                    2  300\n" +
+		"     4  80 00 01 2c              4  300  non-minimal, 2 bytes needed\n" +
+		"     8  ff ff ff ff ff ff ff ff  8  4611686018427387903\n" +
+		"    16  c0 00 00 00 00 00 00     8  truncated, 7 of 8 bytes present\n"
+	if got := Explain(b); got != want {
+		t.Fatalf("Explain =\n%s\nwant\n%s", got, want)
+	}
+	if got := Explain(nil); got != "" {
+		t.Fatalf("Explain(nil) = %q, want empty", got)
+	}
+}
+
+func TestExplainToError(t *testing.T) {
+	b := AppendMany(nil, testValues...)
+	full := Explain(b)
+	limit := strings.Index(full, "\n") + 5 // partway into the second line
+	boom := errors.New("boom")
+	w := &limitedWriter{limit: limit, err: boom}
+	n, err := ExplainTo(w, b)
+	if err != boom || n != int64(limit) || string(w.buf) != full[:limit] {
+		t.Fatalf("ExplainTo = %d, %v, wrote %q; want %d bytes then boom", n, err, w.buf, limit)
+	}
+}
@@ -0,0 +1,64 @@
+package varint
+
+import (
+	"io"
+	"maps"
+	"slices"
+)
+
+// AppendUint64Map appends m to dst as a varint entry count followed by
+// key/value pairs in ascending key order, so equal maps always encode to the
+// same bytes. Like Append it panics with a *ValueTooLargeError if any key or
+// value exceeds Max
+func AppendUint64Map(dst []byte, m map[uint64]uint64) []byte {
+	keys := slices.Sorted(maps.Keys(m))
+	total := Len(uint64(len(keys)))
+	for _, k := range keys {
+		total += Len(k) + Len(m[k])
+	}
+	dst = slices.Grow(dst, total)
+	dst = Append(dst, uint64(len(keys)))
+	for _, k := range keys {
+		dst = Append(dst, k)
+		dst = Append(dst, m[k])
+	}
+	return dst
+}
+
+// ParseUint64Map reads a map written by AppendUint64Map and returns it with
+// the number of bytes consumed. The entry count is checked against maxEntries,
+// failing with a *FrameSizeError, and against the bytes left in b, failing
+// with io.ErrUnexpectedEOF, before the map is allocated. Entries need not be
+// sorted, but a repeated key fails with an *OffsetError wrapping
+// ErrDuplicateKey at the offset of the second occurrence
+func ParseUint64Map(b []byte, maxEntries uint64) (map[uint64]uint64, int, error) {
+	count, off, err := Parse(b)
+	if err != nil {
+		return nil, 0, err
+	}
+	if count > maxEntries {
+		return nil, 0, &FrameSizeError{Declared: count, Limit: maxEntries}
+	}
+	// Every entry takes at least two bytes
+	if count > uint64(len(b)-off)/2 {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	m := make(map[uint64]uint64, count)
+	for range count {
+		k, n, err := Parse(b[off:])
+		if err != nil {
+			return nil, 0, &OffsetError{Offset: off, Err: err}
+		}
+		if _, dup := m[k]; dup {
+			return nil, 0, &OffsetError{Offset: off, Err: ErrDuplicateKey}
+		}
+		off += n
+		v, n, err := Parse(b[off:])
+		if err != nil {
+			return nil, 0, &OffsetError{Offset: off, Err: err}
+		}
+		m[k] = v
+		off += n
+	}
+	return m, off, nil
+}
@@ -0,0 +1,72 @@
+package varint
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"maps"
+	"testing"
+)
+
+// -------------------------
+// AppendUint64Map / ParseUint64Map
+// -------------------------
+
+func TestUint64MapRoundTrip(t *testing.T) {
+	large := make(map[uint64]uint64)
+	for i := uint64(0); i < 5000; i++ {
+		large[i*7919] = Max - i
+	}
+	for _, m := range []map[uint64]uint64{{}, {0: 0}, {Max: 1, 1: Max}, large} {
+		b := AppendUint64Map(nil, m)
+		got, n, err := ParseUint64Map(b, uint64(len(m)))
+		if err != nil || n != len(b) || !maps.Equal(got, m) {
+			t.Fatalf("round trip of %d entries = %d entries, %d bytes, %v", len(m), len(got), n, err)
+		}
+	}
+}
+
+func TestAppendUint64MapDeterministic(t *testing.T) {
+	want := AppendUint64Map(nil, map[uint64]uint64{3: 30, 1: 10, 2: 20, 1 << 40: 0})
+	if !bytes.Equal(want, AppendMany(nil, 4, 1, 10, 2, 20, 3, 30, 1<<40, 0)) {
+		t.Fatalf("AppendUint64Map = %x, want keys in ascending order", want)
+	}
+	for i := 0; i < 50; i++ {
+		// Rebuild the map each time so that it gets a fresh iteration order
+		m := map[uint64]uint64{1 << 40: 0, 2: 20, 3: 30, 1: 10}
+		if got := AppendUint64Map(nil, m); !bytes.Equal(got, want) {
+			t.Fatalf("run %d: AppendUint64Map = %x, want %x", i, got, want)
+		}
+	}
+}
+
+func TestParseUint64MapDuplicateKey(t *testing.T) {
+	b := AppendMany(nil, 3, 1, 10, 70, 20, 1, 30)
+	_, _, err := ParseUint64Map(b, 10)
+	var offErr *OffsetError
+	if !errors.Is(err, ErrDuplicateKey) || !errors.As(err, &offErr) || offErr.Offset != 6 {
+		t.Fatalf("ParseUint64Map(duplicate) error = %v, want ErrDuplicateKey at byte 6", err)
+	}
+}
+
+func TestParseUint64MapLimits(t *testing.T) {
+	b := AppendUint64Map(nil, map[uint64]uint64{1: 1, 2: 2, 3: 3})
+	_, _, err := ParseUint64Map(b, 2)
+	var sizeErr *FrameSizeError
+	if !errors.As(err, &sizeErr) || sizeErr.Declared != 3 {
+		t.Fatalf("ParseUint64Map(maxEntries 2) error = %v, want *FrameSizeError", err)
+	}
+
+	huge := append(Append(nil, 1_000_000_000), 1, 2, 3, 4)
+	allocs := testing.AllocsPerRun(10, func() {
+		_, _, err = ParseUint64Map(huge, Max)
+	})
+	if err != io.ErrUnexpectedEOF || allocs != 0 {
+		t.Fatalf("ParseUint64Map(huge count) = %v with %v allocs, want io.ErrUnexpectedEOF without allocating", err, allocs)
+	}
+
+	_, _, err = ParseUint64Map(b[:len(b)-1], 10)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("ParseUint64Map(truncated) error = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
@@ -0,0 +1,172 @@
+package varint
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net"
+	"slices"
+)
+
+// payloadChunk is how much of a payload is allocated ahead of the bytes that
+// have arrived when the caller's buffer is too small to hold it
+const payloadChunk = 64 << 10
+
+// MessageOption adjusts how WriteMessage and MessageConn write frames
+type MessageOption func(*messageOptions)
+
+type messageOptions struct {
+	vectored bool
+}
+
+// Vectored makes frames go out as net.Buffers{header, payload}. Connections
+// from the net package then issue a single writev, so the frame leaves in one
+// system call without its payload being copied; any other writer sees the
+// same sequential writes as without the option
+func Vectored() MessageOption {
+	return func(o *messageOptions) { o.vectored = true }
+}
+
+func applyMessageOptions(opts []MessageOption) messageOptions {
+	var o messageOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WriteMessage writes p to w prefixed with its length as a varint, using at
+// most two Write calls
+func WriteMessage(w io.Writer, p []byte, opts ...MessageOption) error {
+	_, err := writeMessage(w, p, applyMessageOptions(opts))
+	return err
+}
+
+// writeMessage writes one frame and reports how many of its bytes reached w,
+// so that callers can tell a failure before the frame started from one
+// partway through it
+func writeMessage(w io.Writer, p []byte, o messageOptions) (int64, error) {
+	hdr, n := Encode(uint64(len(p)))
+	if o.vectored && len(p) > 0 {
+		bufs := net.Buffers{hdr[:n], p}
+		return bufs.WriteTo(w)
+	}
+	written, err := w.Write(hdr[:n])
+	if err != nil || len(p) == 0 {
+		return int64(written), err
+	}
+	m, err := w.Write(p)
+	return int64(written + m), err
+}
+
+// ReadMessage reads a message written by WriteMessage. The declared length is
+// checked against max before anything is allocated or read, failing with a
+// *FrameSizeError; max is capped at math.MaxInt, the most a slice can hold.
+// The payload is read into buf when it has enough capacity and into a new
+// slice otherwise. It returns io.EOF only if r ends before the length prefix,
+// and io.ErrUnexpectedEOF if it ends anywhere after that
+func ReadMessage(r io.Reader, max uint64, buf []byte) ([]byte, error) {
+	max = min(max, math.MaxInt)
+	length, _, err := ReadFrom(r)
+	if err != nil {
+		return nil, err
+	}
+	if length > max {
+		return nil, &FrameSizeError{Declared: length, Limit: max}
+	}
+	return readPayload(r, int(length), buf)
+}
+
+// ReadMessageZeroCopy is like ReadMessage but, when the whole frame fits in
+// br's buffer, returns a view of the payload inside that buffer instead of a
+// copy. The view is only valid until the next read from br; callers that keep
+// it longer must copy it. Frames larger than br.Size() are read into a newly
+// allocated slice
+func ReadMessageZeroCopy(br *bufio.Reader, max uint64) ([]byte, error) {
+	max = min(max, math.MaxInt)
+	first, err := br.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	l := EncodedLen(first[0])
+	hdr, err := br.Peek(l)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	length, _, _ := Parse(hdr)
+	if length > max {
+		return nil, &FrameSizeError{Declared: length, Limit: max}
+	}
+	if total := uint64(l) + length; total <= uint64(br.Size()) {
+		frame, err := br.Peek(int(total))
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		br.Discard(int(total))
+		return frame[l:], nil
+	}
+	br.Discard(l)
+	return readPayload(br, int(length), nil)
+}
+
+// readPayload reads a length-byte payload from r into buf when it has the
+// capacity. Otherwise the payload is allocated payloadChunk bytes ahead of
+// what has arrived, so a length prefix declaring far more than r delivers
+// neither reserves that memory nor makes make panic
+func readPayload(r io.Reader, length int, buf []byte) ([]byte, error) {
+	if cap(buf) >= length {
+		buf = buf[:length]
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		return buf, nil
+	}
+	p := make([]byte, 0, min(length, payloadChunk))
+	for len(p) < length {
+		if len(p) == cap(p) {
+			p = slices.Grow(p, min(length-len(p), max(len(p), payloadChunk)))
+		}
+		n, err := io.ReadFull(r, p[len(p):min(cap(p), length)])
+		p = p[:len(p)+n]
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+	}
+	return p, nil
+}
+
+// unexpectedEOF maps io.EOF to io.ErrUnexpectedEOF for reads that stop
+// partway through a frame
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// SplitFunc returns a bufio.SplitFunc that yields the payloads of messages
+// written by WriteMessage, with the length prefix stripped. A frame declaring
+// more than max bytes stops the scan with a *FrameSizeError, and a stream
+// ending inside a frame with io.ErrUnexpectedEOF. The Scanner's buffer must
+// hold max+MaxLen bytes, see bufio.Scanner.Buffer
+func SplitFunc(max uint64) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if len(data) == 0 {
+			return 0, nil, nil
+		}
+		l := EncodedLen(data[0])
+		if len(data) >= l {
+			length, _, _ := Parse(data)
+			if length > max {
+				return 0, nil, &FrameSizeError{Declared: length, Limit: max}
+			}
+			if end := uint64(l) + length; uint64(len(data)) >= end {
+				return int(end), data[l:end], nil
+			}
+		}
+		if atEOF {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+		return 0, nil, nil
+	}
+}
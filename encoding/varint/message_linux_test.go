@@ -0,0 +1,76 @@
+//go:build linux
+
+package varint
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+// packetPair returns the two ends of a SOCK_SEQPACKET socketpair. Such a
+// socket keeps the boundaries of every write, so each Read on the peer
+// returns exactly what one system call sent
+func packetPair(t *testing.T) (net.Conn, net.Conn) {
+	t.Helper()
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_SEQPACKET|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		t.Skipf("socketpair unavailable: %v", err)
+	}
+	conns := make([]net.Conn, 2)
+	for i, fd := range fds {
+		f := os.NewFile(uintptr(fd), "packet")
+		c, err := net.FileConn(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { c.Close() })
+		conns[i] = c
+	}
+	return conns[0], conns[1]
+}
+
+// recvRecords reads from c until want bytes have arrived and returns them
+// with the number of records, and so of sending writes, they came in
+func recvRecords(t *testing.T, c net.Conn, want int) ([]byte, int) {
+	t.Helper()
+	var got []byte
+	records := 0
+	buf := make([]byte, 64<<10)
+	for len(got) < want {
+		n, err := c.Read(buf)
+		if err != nil {
+			t.Fatalf("Read after %d bytes: %v", len(got), err)
+		}
+		got = append(got, buf[:n]...)
+		records++
+	}
+	return got, records
+}
+
+func TestMessageConnVectoredWritev(t *testing.T) {
+	payload := bytes.Repeat([]byte("frame"), 1000)
+	frame := AppendBytes(nil, payload)
+	for _, tc := range []struct {
+		opts []MessageOption
+		want int
+	}{
+		{nil, 2},
+		{[]MessageOption{Vectored()}, 1},
+	} {
+		a, b := packetPair(t)
+		if err := NewMessageConn(a, tc.opts...).Send(payload); err != nil {
+			t.Fatalf("Send error = %v", err)
+		}
+		got, records := recvRecords(t, b, len(frame))
+		if !bytes.Equal(got, frame) {
+			t.Fatalf("%d options: peer received %d bytes, want the %d-byte frame", len(tc.opts), len(got), len(frame))
+		}
+		if records != tc.want {
+			t.Fatalf("%d options: frame arrived in %d writes, want %d", len(tc.opts), records, tc.want)
+		}
+	}
+}
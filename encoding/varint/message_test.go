@@ -0,0 +1,286 @@
+package varint
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"testing/iotest"
+)
+
+// -------------------------
+// WriteMessage / ReadMessage
+// -------------------------
+
+func TestMessageRoundTrip(t *testing.T) {
+	messages := [][]byte{
+		{},
+		[]byte("hello"),
+		bytes.Repeat([]byte{0xAB}, 63),
+		bytes.Repeat([]byte{0xCD}, 64),
+		bytes.Repeat([]byte{0xEF}, 70000),
+	}
+	var stream bytes.Buffer
+	for _, m := range messages {
+		if err := WriteMessage(&stream, m); err != nil {
+			t.Fatalf("WriteMessage(%d bytes) error = %v", len(m), err)
+		}
+	}
+	r := iotest.HalfReader(&stream)
+	for _, m := range messages {
+		got, err := ReadMessage(r, 70000, nil)
+		if err != nil || !bytes.Equal(got, m) {
+			t.Fatalf("ReadMessage = %d bytes, %v; want %d bytes", len(got), err, len(m))
+		}
+	}
+	if _, err := ReadMessage(r, 70000, nil); err != io.EOF {
+		t.Fatalf("ReadMessage at end error = %v, want io.EOF", err)
+	}
+}
+
+func TestWriteMessageWrites(t *testing.T) {
+	cw := &countingWriter{}
+	WriteMessage(cw, []byte("hello"))
+	if cw.calls != 2 {
+		t.Fatalf("WriteMessage issued %d writes, want 2", cw.calls)
+	}
+	cw = &countingWriter{}
+	WriteMessage(cw, nil)
+	if cw.calls != 1 || !bytes.Equal(cw.buf, []byte{0}) {
+		t.Fatalf("WriteMessage(empty) wrote %x in %d calls", cw.buf, cw.calls)
+	}
+}
+
+func TestWriteMessageVectoredFallback(t *testing.T) {
+	// Writers outside the net package get the header and payload in turn
+	cw := &countingWriter{}
+	if err := WriteMessage(cw, []byte("hello"), Vectored()); err != nil {
+		t.Fatalf("WriteMessage error = %v", err)
+	}
+	if want := AppendBytes(nil, []byte("hello")); cw.calls != 2 || !bytes.Equal(cw.buf, want) {
+		t.Fatalf("WriteMessage(Vectored) wrote %x in %d calls, want %x in 2", cw.buf, cw.calls, want)
+	}
+	cw = &countingWriter{}
+	WriteMessage(cw, nil, Vectored())
+	if cw.calls != 1 || !bytes.Equal(cw.buf, []byte{0}) {
+		t.Fatalf("WriteMessage(empty, Vectored) wrote %x in %d calls", cw.buf, cw.calls)
+	}
+}
+
+func TestReadMessageReusesBuf(t *testing.T) {
+	var stream bytes.Buffer
+	WriteMessage(&stream, []byte("hello"))
+	WriteMessage(&stream, []byte("a longer message"))
+	buf := make([]byte, 0, 8)
+	got, err := ReadMessage(&stream, 100, buf)
+	if err != nil || string(got) != "hello" || &got[:1][0] != &buf[:1][0] {
+		t.Fatalf("ReadMessage = %q, %v; want hello in buf", got, err)
+	}
+	got, err = ReadMessage(&stream, 100, buf)
+	if err != nil || string(got) != "a longer message" || cap(got) == cap(buf) {
+		t.Fatalf("ReadMessage = %q, %v; want a new slice", got, err)
+	}
+}
+
+func TestReadMessageLimit(t *testing.T) {
+	var stream bytes.Buffer
+	WriteMessage(&stream, bytes.Repeat([]byte{1}, 100))
+	data := stream.Bytes()
+
+	if got, err := ReadMessage(bytes.NewReader(data), 100, nil); err != nil || len(got) != 100 {
+		t.Fatalf("ReadMessage(exactly max) = %d bytes, %v", len(got), err)
+	}
+	r := bytes.NewReader(data)
+	_, err := ReadMessage(r, 99, nil)
+	var sizeErr *FrameSizeError
+	if !errors.As(err, &sizeErr) || sizeErr.Declared != 100 || sizeErr.Limit != 99 {
+		t.Fatalf("ReadMessage(over max) error = %v, want *FrameSizeError", err)
+	}
+	if r.Len() != 100 {
+		t.Fatalf("ReadMessage consumed %d payload bytes before failing", 100-r.Len())
+	}
+}
+
+func TestReadMessageShort(t *testing.T) {
+	var stream bytes.Buffer
+	WriteMessage(&stream, bytes.Repeat([]byte{1}, 100))
+	data := stream.Bytes()
+	for _, cut := range []int{1, 2, 50, len(data) - 1} {
+		if _, err := ReadMessage(bytes.NewReader(data[:cut]), 1000, nil); err != io.ErrUnexpectedEOF {
+			t.Fatalf("ReadMessage(%d of %d bytes) error = %v, want io.ErrUnexpectedEOF", cut, len(data), err)
+		}
+	}
+}
+
+func TestReadMessageHugeLength(t *testing.T) {
+	// The prefix declares Max bytes and the stream ends right after it.
+	// Without a cap on max this would either panic in make or reserve far
+	// more memory than ever arrives
+	data := Append(nil, Max)
+	want := error(io.ErrUnexpectedEOF)
+	if uint64(math.MaxInt) < Max {
+		want = ErrLimitExceeded
+	}
+	reads := map[string]func() error{
+		"ReadMessage": func() error {
+			_, err := ReadMessage(bytes.NewReader(data), Max, nil)
+			return err
+		},
+		"ReadMessageZeroCopy": func() error {
+			_, err := ReadMessageZeroCopy(bufio.NewReader(bytes.NewReader(data)), Max)
+			return err
+		},
+		"ReadBytes": func() error {
+			_, err := ReadBytes(bytes.NewReader(data), Max)
+			return err
+		},
+	}
+	for name, read := range reads {
+		if err := read(); !errors.Is(err, want) {
+			t.Errorf("%s(max = Max) error = %v, want %v", name, err, want)
+		}
+	}
+}
+
+func TestReadMessageGrowsLargePayload(t *testing.T) {
+	payload := make([]byte, 3*payloadChunk+5)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+	var stream bytes.Buffer
+	WriteMessage(&stream, payload)
+	got, err := ReadMessage(iotest.HalfReader(&stream), Max, make([]byte, 0, 8))
+	if err != nil || !bytes.Equal(got, payload) {
+		t.Fatalf("ReadMessage = %d bytes, %v; want the %d byte payload", len(got), err, len(payload))
+	}
+}
+
+// -------------------------
+// ReadMessageZeroCopy
+// -------------------------
+
+func TestReadMessageZeroCopy(t *testing.T) {
+	sizes := []int{0, 1, 63, 64, 100, 4000, 4094, 4095, 4096, 5000, 70000}
+	var stream bytes.Buffer
+	for i, n := range sizes {
+		WriteMessage(&stream, bytes.Repeat([]byte{byte(i + 1)}, n))
+	}
+	// Deliver the stream one byte per Read so frames straddle refills
+	br := bufio.NewReaderSize(iotest.OneByteReader(&stream), 4096)
+	for i, n := range sizes {
+		got, err := ReadMessageZeroCopy(br, 1<<20)
+		if err != nil {
+			t.Fatalf("frame %d (%d bytes) error = %v", i, n, err)
+		}
+		if !bytes.Equal(got, bytes.Repeat([]byte{byte(i + 1)}, n)) {
+			t.Fatalf("frame %d (%d bytes) payload mismatch", i, n)
+		}
+	}
+	if _, err := ReadMessageZeroCopy(br, 1<<20); err != io.EOF {
+		t.Fatalf("ReadMessageZeroCopy at end = %v, want io.EOF", err)
+	}
+}
+
+func TestReadMessageZeroCopyAliasing(t *testing.T) {
+	var stream bytes.Buffer
+	WriteMessage(&stream, []byte("small"))
+	WriteMessage(&stream, bytes.Repeat([]byte{7}, 64))
+	br := bufio.NewReaderSize(&stream, 16)
+
+	got, err := ReadMessageZeroCopy(br, 100)
+	if err != nil || string(got) != "small" {
+		t.Fatalf("ReadMessageZeroCopy = %q, %v", got, err)
+	}
+	// A frame fitting the buffer is a view directly followed by the
+	// still-buffered bytes of the next frame
+	next, _ := br.Peek(1)
+	if cap(got) == len(got) || &got[:len(got)+1][len(got)] != &next[0] {
+		t.Fatal("small frame was copied, want a view into the bufio buffer")
+	}
+	got, err = ReadMessageZeroCopy(br, 100)
+	if err != nil || !bytes.Equal(got, bytes.Repeat([]byte{7}, 64)) {
+		t.Fatalf("ReadMessageZeroCopy(larger than buffer) = %q, %v", got, err)
+	}
+}
+
+func TestReadMessageZeroCopyErrors(t *testing.T) {
+	var stream bytes.Buffer
+	WriteMessage(&stream, bytes.Repeat([]byte{1}, 100))
+	data := stream.Bytes()
+
+	_, err := ReadMessageZeroCopy(bufio.NewReader(bytes.NewReader(data)), 99)
+	var sizeErr *FrameSizeError
+	if !errors.As(err, &sizeErr) || sizeErr.Declared != 100 {
+		t.Fatalf("ReadMessageZeroCopy(over max) error = %v, want *FrameSizeError", err)
+	}
+	for _, size := range []int{16, 4096} {
+		for _, cut := range []int{1, 50, len(data) - 1} {
+			br := bufio.NewReaderSize(bytes.NewReader(data[:cut]), size)
+			if _, err := ReadMessageZeroCopy(br, 1000); err != io.ErrUnexpectedEOF {
+				t.Fatalf("size %d, %d of %d bytes: error = %v, want io.ErrUnexpectedEOF", size, cut, len(data), err)
+			}
+		}
+	}
+}
+
+// -------------------------
+// SplitFunc
+// -------------------------
+
+func TestSplitFunc(t *testing.T) {
+	var stream bytes.Buffer
+	var want [][]byte
+	for i := 0; i < 5000; i++ {
+		p := bytes.Repeat([]byte{byte(i)}, (i*37)%300)
+		want = append(want, p)
+		WriteMessage(&stream, p)
+	}
+	data := stream.Bytes()
+	readers := map[string]func() io.Reader{
+		"whole":   func() io.Reader { return bytes.NewReader(data) },
+		"onebyte": func() io.Reader { return iotest.OneByteReader(bytes.NewReader(data)) },
+		"halves":  func() io.Reader { return iotest.HalfReader(bytes.NewReader(data)) },
+	}
+	for name, r := range readers {
+		s := bufio.NewScanner(r())
+		s.Split(SplitFunc(300))
+		i := 0
+		for ; s.Scan(); i++ {
+			if i >= len(want) || !bytes.Equal(s.Bytes(), want[i]) {
+				t.Fatalf("%s: frame %d mis-framed", name, i)
+			}
+		}
+		if err := s.Err(); err != nil || i != len(want) {
+			t.Fatalf("%s: scanned %d of %d frames, err = %v", name, i, len(want), err)
+		}
+	}
+}
+
+func TestSplitFuncErrors(t *testing.T) {
+	var stream bytes.Buffer
+	WriteMessage(&stream, []byte("ok"))
+	WriteMessage(&stream, bytes.Repeat([]byte{1}, 100))
+	data := stream.Bytes()
+
+	s := bufio.NewScanner(bytes.NewReader(data))
+	s.Split(SplitFunc(99))
+	if !s.Scan() || s.Text() != "ok" {
+		t.Fatalf("first frame = %q, want ok", s.Text())
+	}
+	var sizeErr *FrameSizeError
+	if s.Scan() || !errors.As(s.Err(), &sizeErr) || sizeErr.Declared != 100 {
+		t.Fatalf("oversized frame error = %v, want *FrameSizeError", s.Err())
+	}
+
+	for _, cut := range []int{4, 5, 50, len(data) - 1} {
+		s := bufio.NewScanner(bytes.NewReader(data[:cut]))
+		s.Split(SplitFunc(1000))
+		for s.Scan() {
+		}
+		if s.Err() != io.ErrUnexpectedEOF {
+			t.Fatalf("stream cut at %d error = %v, want io.ErrUnexpectedEOF", cut, s.Err())
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package varint
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+)
+
+// MessageConn exchanges discrete messages over a net.Conn, each framed as in
+// WriteMessage. Send and Recv may be called concurrently; concurrent Sends
+// never interleave. Deadlines set on the underlying conn apply as usual, and
+// errors from it, including timeouts, are returned unwrapped the first time.
+//
+// A Send that fails after part of a frame reached the wire leaves the peer
+// unable to find frame boundaries, so every later Send fails with an error
+// wrapping ErrPoisoned. The same applies to Recv once a frame has been partly
+// consumed
+type MessageConn struct {
+	conn net.Conn
+	opts messageOptions
+
+	sendMu  sync.Mutex
+	sendErr error
+
+	recvMu  sync.Mutex
+	br      *bufio.Reader
+	recvErr error
+}
+
+// NewMessageConn returns a MessageConn over conn, sending every message with
+// the given options
+func NewMessageConn(conn net.Conn, opts ...MessageOption) *MessageConn {
+	return &MessageConn{conn: conn, opts: applyMessageOptions(opts), br: bufio.NewReader(conn)}
+}
+
+// Conn returns the underlying connection, e.g. for setting deadlines
+func (c *MessageConn) Conn() net.Conn {
+	return c.conn
+}
+
+// Send writes p as one message. A failure before any byte of the frame was
+// written, such as a deadline that has already expired, leaves the
+// MessageConn usable
+func (c *MessageConn) Send(p []byte) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.sendErr != nil {
+		return c.sendErr
+	}
+	if n, err := writeMessage(c.conn, p, c.opts); err != nil {
+		if n > 0 {
+			c.sendErr = fmt.Errorf("%w: %w", ErrPoisoned, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// Recv reads the next message, failing with a *FrameSizeError if it declares
+// more than max bytes. A failure before any byte of a frame was read, such as
+// a deadline expiring while idle, leaves the MessageConn usable
+func (c *MessageConn) Recv(max uint64) ([]byte, error) {
+	max = min(max, math.MaxInt)
+	c.recvMu.Lock()
+	defer c.recvMu.Unlock()
+	if c.recvErr != nil {
+		return nil, c.recvErr
+	}
+	length, n, err := ReadFrom(c.br)
+	if err != nil {
+		if n > 0 {
+			c.poisonRecv(err)
+		}
+		return nil, err
+	}
+	if length > max {
+		err := &FrameSizeError{Declared: length, Limit: max}
+		c.poisonRecv(err)
+		return nil, err
+	}
+	p, err := readPayload(c.br, int(length), nil)
+	if err != nil {
+		c.poisonRecv(err)
+		return nil, err
+	}
+	return p, nil
+}
+
+func (c *MessageConn) poisonRecv(err error) {
+	c.recvErr = fmt.Errorf("%w: %w", ErrPoisoned, err)
+}
+
+// Close closes the underlying connection
+func (c *MessageConn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,195 @@
+package varint
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// -------------------------
+// MessageConn
+// -------------------------
+
+// loopback returns both ends of a TCP connection on the loopback interface
+func loopback(t *testing.T) (net.Conn, net.Conn) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("loopback unavailable: %v", err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		c, _ := ln.Accept()
+		accepted <- c
+	}()
+	client, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := <-accepted
+	if server == nil {
+		t.Fatal("accept failed")
+	}
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client, server
+}
+
+func TestMessageConnConcurrentSenders(t *testing.T) {
+	client, server := loopback(t)
+	tx, rx := NewMessageConn(client), NewMessageConn(server)
+
+	const senders, perSender = 8, 200
+	var wg sync.WaitGroup
+	for s := 0; s < senders; s++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perSender; i++ {
+				msg := binary.BigEndian.AppendUint32(nil, uint32(s))
+				msg = binary.BigEndian.AppendUint32(msg, uint32(i))
+				msg = append(msg, bytes.Repeat([]byte{byte(s)}, i)...)
+				if err := tx.Send(msg); err != nil {
+					t.Errorf("Send error = %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	next := make([]uint32, senders)
+	for n := 0; n < senders*perSender; n++ {
+		msg, err := rx.Recv(1 << 16)
+		if err != nil {
+			t.Fatalf("Recv error = %v", err)
+		}
+		s, i := binary.BigEndian.Uint32(msg), binary.BigEndian.Uint32(msg[4:])
+		if i != next[s] {
+			t.Fatalf("sender %d: got message %d, want %d", s, i, next[s])
+		}
+		if want := bytes.Repeat([]byte{byte(s)}, int(i)); !bytes.Equal(msg[8:], want) {
+			t.Fatalf("sender %d message %d corrupted", s, i)
+		}
+		next[s]++
+	}
+	wg.Wait()
+}
+
+func TestMessageConnDeadlineMidFrame(t *testing.T) {
+	client, server := loopback(t)
+	rx := NewMessageConn(server)
+
+	// Announce 100 bytes but send only 10
+	partial := append(Append(nil, 100), make([]byte, 10)...)
+	if _, err := client.Write(partial); err != nil {
+		t.Fatal(err)
+	}
+	server.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	_, err := rx.Recv(1000)
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("Recv error = %v, want a net.Error with Timeout()", err)
+	}
+	// The frame was partly consumed, so the stream is unusable
+	server.SetReadDeadline(time.Time{})
+	if _, err := rx.Recv(1000); !errors.Is(err, ErrPoisoned) {
+		t.Fatalf("Recv after partial frame error = %v, want ErrPoisoned", err)
+	}
+}
+
+func TestMessageConnIdleTimeout(t *testing.T) {
+	client, server := loopback(t)
+	tx, rx := NewMessageConn(client), NewMessageConn(server)
+
+	server.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+	if _, err := rx.Recv(1000); err == nil {
+		t.Fatal("Recv succeeded with nothing sent")
+	}
+	// Nothing was consumed, so the connection is still usable
+	server.SetReadDeadline(time.Time{})
+	if err := tx.Send([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if msg, err := rx.Recv(1000); err != nil || string(msg) != "hello" {
+		t.Fatalf("Recv after idle timeout = %q, %v", msg, err)
+	}
+}
+
+func TestMessageConnSendExpiredDeadline(t *testing.T) {
+	client, server := loopback(t)
+	tx, rx := NewMessageConn(client), NewMessageConn(server)
+	client.SetWriteDeadline(time.Now().Add(-time.Second))
+	err := tx.Send([]byte("hello"))
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("Send error = %v, want a net.Error with Timeout()", err)
+	}
+	// Nothing reached the wire, so the connection is still usable
+	client.SetWriteDeadline(time.Time{})
+	if err := tx.Send([]byte("again")); err != nil {
+		t.Fatalf("Send after expired deadline = %v", err)
+	}
+	if msg, err := rx.Recv(1000); err != nil || string(msg) != "again" {
+		t.Fatalf("Recv = %q, %v; want again", msg, err)
+	}
+}
+
+// partialConn passes on the first limit bytes written to it and fails every
+// write after that
+type partialConn struct {
+	net.Conn
+	limit int
+}
+
+var errPartial = errors.New("connection reset mid-write")
+
+func (c *partialConn) Write(p []byte) (int, error) {
+	n := min(len(p), c.limit)
+	c.limit -= n
+	if n < len(p) {
+		return n, errPartial
+	}
+	return n, nil
+}
+
+func TestMessageConnSendPoisoned(t *testing.T) {
+	for _, vectored := range []bool{false, true} {
+		client, _ := loopback(t)
+		var opts []MessageOption
+		if vectored {
+			opts = append(opts, Vectored())
+		}
+		tx := NewMessageConn(&partialConn{Conn: client, limit: 3}, opts...)
+		if err := tx.Send([]byte("hello")); err != errPartial {
+			t.Fatalf("vectored %v: Send error = %v, want errPartial", vectored, err)
+		}
+		for i := 0; i < 2; i++ {
+			if err := tx.Send([]byte("again")); !errors.Is(err, ErrPoisoned) || !errors.Is(err, errPartial) {
+				t.Fatalf("vectored %v: Send after partial frame error = %v, want ErrPoisoned", vectored, err)
+			}
+		}
+	}
+}
+
+func TestMessageConnRecvLimit(t *testing.T) {
+	client, server := loopback(t)
+	tx, rx := NewMessageConn(client), NewMessageConn(server)
+	if err := tx.Send(make([]byte, 100)); err != nil {
+		t.Fatal(err)
+	}
+	_, err := rx.Recv(99)
+	var sizeErr *FrameSizeError
+	if !errors.As(err, &sizeErr) || sizeErr.Declared != 100 {
+		t.Fatalf("Recv error = %v, want *FrameSizeError", err)
+	}
+	if _, err := rx.Recv(1000); !errors.Is(err, ErrPoisoned) {
+		t.Fatalf("Recv after oversized frame error = %v, want ErrPoisoned", err)
+	}
+}
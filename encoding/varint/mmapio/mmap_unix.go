@@ -0,0 +1,21 @@
+//go:build unix
+
+package mmapio
+
+import (
+	"os"
+	"syscall"
+)
+
+func init() {
+	mapWindow = mmapWindow
+}
+
+// mmapWindow maps n bytes of f read-only from off
+func mmapWindow(f *os.File, off int64, n int) ([]byte, func() error, error) {
+	data, err := syscall.Mmap(int(f.Fd()), off, n, syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, os.NewSyscallError("mmap", err)
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
@@ -0,0 +1,284 @@
+package mmapio
+
+import (
+	"io"
+	"iter"
+	"os"
+
+	"flux/encoding/varint"
+	"flux/encoding/zigzag"
+)
+
+// DefaultWindow is how many bytes of a file a File maps at a time unless Open
+// is given another size. Files larger than the window are mapped piece by
+// piece, so a scan that takes no views with Bytes uses bounded address space
+// however large the file is
+const DefaultWindow = 1 << 30
+
+// mapWindow returns n bytes of f starting at off, a multiple of the page
+// size, along with a function that releases them. It is replaced by a
+// memory-mapping implementation where one is available
+var mapWindow = readWindow
+
+// readWindow is the portable mapWindow: it reads the bytes into memory
+func readWindow(f *os.File, off int64, n int) ([]byte, func() error, error) {
+	data := make([]byte, n)
+	if _, err := f.ReadAt(data, off); err != nil && !(err == io.EOF && len(data) == n) {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
+
+// File decodes varint fields from a file through a memory-mapped window,
+// without copying them through an io.Reader. Its methods mirror
+// varint.Decoder: errors are sticky, and failures are reported as a
+// *varint.StreamOffsetError carrying the file offset of the field. Where
+// memory mapping is unavailable the window is read into memory instead.
+//
+// Slices returned by Bytes are views into the window and stay valid until
+// Close, which unmaps them; touching one after that can crash the program.
+// A window that has handed out views is kept mapped when the File moves on,
+// so reading byte fields holds address space in proportion to the part of
+// the file read. Callers that keep the bytes past Close must copy them
+type File struct {
+	f       *os.File
+	size    int64
+	window  int
+	data    []byte         // the current window
+	base    int64          // file offset of data[0]
+	release func() error   // releases data
+	lent    bool           // Bytes has returned a view into data
+	retired []func() error // release windows moved off while lent
+	off     int64          // read position in the file
+	err     error
+	strict  bool
+}
+
+// Open opens the named file for decoding, mapping window bytes at a time. A
+// non-positive window selects DefaultWindow; other sizes are rounded up to a
+// multiple of the page size
+func Open(name string, window int) (*File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	page := os.Getpagesize()
+	window = (window + page - 1) / page * page
+	return &File{f: f, size: fi.Size(), window: window}, nil
+}
+
+// Close releases every window and closes the file. Views returned by Bytes
+// are invalid from then on. Every later call fails with os.ErrClosed
+func (f *File) Close() error {
+	if f.f == nil {
+		return os.ErrClosed
+	}
+	err := f.unmap()
+	for _, release := range f.retired {
+		if rerr := release(); err == nil {
+			err = rerr
+		}
+	}
+	f.retired = nil
+	if cerr := f.f.Close(); err == nil {
+		err = cerr
+	}
+	f.f = nil
+	f.err = os.ErrClosed
+	return err
+}
+
+func (f *File) unmap() error {
+	if f.release == nil {
+		return nil
+	}
+	err := f.release()
+	f.data, f.release, f.lent = nil, nil, false
+	return err
+}
+
+// retire moves off the current window, unmapping it unless Bytes has handed
+// out views into it, in which case it stays mapped until Close
+func (f *File) retire() error {
+	if !f.lent {
+		return f.unmap()
+	}
+	f.retired = append(f.retired, f.release)
+	f.data, f.release, f.lent = nil, nil, false
+	return nil
+}
+
+// view returns up to n bytes from the read position, fewer only at the end of
+// the file, moving the window if they are not all inside it
+func (f *File) view(n int) ([]byte, error) {
+	end := min(f.off+int64(n), f.size)
+	if end <= f.off {
+		return nil, nil
+	}
+	if f.off < f.base || end > f.base+int64(len(f.data)) {
+		if err := f.retire(); err != nil {
+			return nil, err
+		}
+		base := f.off &^ int64(os.Getpagesize()-1)
+		size := min(max(int64(f.window), end-base), f.size-base)
+		data, release, err := mapWindow(f.f, base, int(size))
+		if err != nil {
+			return nil, err
+		}
+		f.data, f.base, f.release = data, base, release
+	}
+	return f.data[f.off-f.base : end-f.base], nil
+}
+
+// SetStrict makes the File reject non-minimal encodings of varints and length
+// prefixes with varint.ErrNonCanonical
+func (f *File) SetStrict(strict bool) {
+	f.strict = strict
+}
+
+func (f *File) fail(err error) {
+	f.err = &varint.StreamOffsetError{Offset: f.off, Err: err}
+}
+
+// next decodes the varint at the read position without consuming it
+func (f *File) next() (uint64, int, error) {
+	b, err := f.view(varint.MaxLen)
+	if err != nil {
+		return 0, 0, err
+	}
+	if f.strict {
+		return varint.ParseCanonical(b)
+	}
+	return varint.Parse(b)
+}
+
+// Uint64 decodes the next varint
+func (f *File) Uint64() uint64 {
+	if f.err != nil {
+		return 0
+	}
+	v, n, err := f.next()
+	if err != nil {
+		f.fail(err)
+		return 0
+	}
+	f.off += int64(n)
+	return v
+}
+
+// Int64 decodes the next zigzag-encoded varint
+func (f *File) Int64() int64 {
+	return zigzag.Decode(f.Uint64())
+}
+
+// Bytes decodes the next length-prefixed byte slice, failing if its declared
+// length exceeds maxLen. The result is a view into the window; see File
+func (f *File) Bytes(maxLen int) []byte {
+	if f.err != nil {
+		return nil
+	}
+	start := f.off
+	length, n, err := f.next()
+	if err != nil {
+		f.fail(err)
+		return nil
+	}
+	if limit := uint64(max(maxLen, 0)); length > limit {
+		f.fail(&varint.FrameSizeError{Declared: length, Limit: limit})
+		return nil
+	}
+	if length > uint64(f.size-start-int64(n)) {
+		f.fail(io.ErrUnexpectedEOF)
+		return nil
+	}
+	f.off += int64(n)
+	p, err := f.view(int(length))
+	if err != nil {
+		f.off = start
+		f.fail(err)
+		return nil
+	}
+	f.off += int64(length)
+	f.lent = f.lent || len(p) > 0
+	return p[:len(p):len(p)]
+}
+
+// String decodes the next length-prefixed string, failing if its declared
+// length exceeds maxLen. The result is a copy
+func (f *File) String(maxLen int) string {
+	return string(f.Bytes(maxLen))
+}
+
+// Skip advances past the next n varints without decoding them. If input runs
+// out, the error carries the offset of the first varint that could not be
+// skipped
+func (f *File) Skip(n int) {
+	for ; n > 0 && f.err == nil; n-- {
+		b, err := f.view(1)
+		if err == nil && len(b) == 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			f.fail(err)
+			return
+		}
+		l := int64(varint.EncodedLen(b[0]))
+		if l > f.size-f.off {
+			f.fail(io.ErrUnexpectedEOF)
+			return
+		}
+		f.off += l
+	}
+}
+
+// All returns an iterator over the remaining varints, yielding the file
+// offset of each and the value. It stops at the end of the file or at the
+// first error, which Err then reports
+func (f *File) All() iter.Seq2[int64, uint64] {
+	return func(yield func(int64, uint64) bool) {
+		for f.err == nil && f.off < f.size {
+			off := f.off
+			v := f.Uint64()
+			if f.err != nil || !yield(off, v) {
+				return
+			}
+		}
+	}
+}
+
+// Offset returns the number of bytes consumed so far
+func (f *File) Offset() int64 {
+	return f.off
+}
+
+// Remaining returns the number of bytes not yet consumed
+func (f *File) Remaining() int64 {
+	return f.size - f.off
+}
+
+// Size returns the size of the file
+func (f *File) Size() int64 {
+	return f.size
+}
+
+// Err returns the first error encountered, if any
+func (f *File) Err() error {
+	return f.err
+}
+
+// Finish returns the first error encountered or, if there was none, an
+// *varint.StreamOffsetError wrapping varint.ErrTrailingBytes when input remains
+func (f *File) Finish() error {
+	if f.err == nil && f.off != f.size {
+		f.fail(varint.ErrTrailingBytes)
+	}
+	return f.err
+}
@@ -0,0 +1,273 @@
+package mmapio
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"flux/encoding/varint"
+)
+
+// writeFile writes b to a temporary file and returns its name
+func writeFile(t *testing.T, b []byte) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(name, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+// withMappers runs f once with each available mapWindow implementation
+func withMappers(t *testing.T, f func(t *testing.T)) {
+	mappers := map[string]func(*os.File, int64, int) ([]byte, func() error, error){
+		"default": mapWindow,
+		"read":    readWindow,
+	}
+	for name, m := range mappers {
+		t.Run(name, func(t *testing.T) {
+			saved := mapWindow
+			mapWindow = m
+			defer func() { mapWindow = saved }()
+			f(t)
+		})
+	}
+}
+
+// stream holds enough fields to span many page-sized windows, with varints
+// and payloads straddling window boundaries and one payload larger than a
+// window
+func stream() []byte {
+	var b []byte
+	for i := range 5000 {
+		b = varint.Append(b, uint64(i)*uint64(i)*7919%varint.Max)
+		b = varint.AppendInt(b, -int64(i))
+		b = varint.AppendBytes(b, bytes.Repeat([]byte{byte(i)}, i%37))
+	}
+	return varint.AppendBytes(b, bytes.Repeat([]byte{0xaa}, 3*os.Getpagesize()))
+}
+
+// -------------------------
+// File
+// -------------------------
+
+func TestFileMatchesDecoder(t *testing.T) {
+	b := stream()
+	name := writeFile(t, b)
+	withMappers(t, func(t *testing.T) {
+		f, err := Open(name, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if f.window != os.Getpagesize() || len(b) < 10*f.window {
+			t.Fatalf("%d bytes in windows of %d do not exercise windowing", len(b), f.window)
+		}
+		d := varint.NewDecoder(b)
+		for i := range 5000 {
+			if got, want := f.Uint64(), d.Uint64(); got != want {
+				t.Fatalf("field %d: Uint64 = %d, want %d", i, got, want)
+			}
+			if got, want := f.Int64(), d.Int64(); got != want {
+				t.Fatalf("field %d: Int64 = %d, want %d", i, got, want)
+			}
+			if got, want := f.Bytes(100), d.Bytes(100); !bytes.Equal(got, want) {
+				t.Fatalf("field %d: Bytes = %x, want %x", i, got, want)
+			}
+		}
+		if got, want := f.Bytes(1<<20), d.Bytes(1<<20); !bytes.Equal(got, want) {
+			t.Fatalf("Bytes larger than the window = %d bytes, want %d", len(got), len(want))
+		}
+		if err := f.Finish(); err != nil || f.Offset() != int64(len(b)) || f.Remaining() != 0 {
+			t.Fatalf("Finish = %v at offset %d", err, f.Offset())
+		}
+	})
+}
+
+func TestFileAll(t *testing.T) {
+	var want []uint64
+	for i := range 20000 {
+		want = append(want, uint64(i)<<(i%62)&varint.Max)
+	}
+	b := varint.AppendMany(nil, want...)
+	name := writeFile(t, append(b, 0x80))
+	withMappers(t, func(t *testing.T) {
+		f, err := Open(name, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		i, wantOff := 0, int64(0)
+		for off, v := range f.All() {
+			if v != want[i] || off != wantOff {
+				t.Fatalf("All yielded (%d, %d) for value %d, want (%d, %d)", off, v, i, wantOff, want[i])
+			}
+			wantOff += int64(varint.Len(v))
+			i++
+		}
+		var offErr *varint.StreamOffsetError
+		if i != len(want) || !errors.As(f.Err(), &offErr) || offErr.Offset != int64(len(b)) || !errors.Is(f.Err(), io.ErrUnexpectedEOF) {
+			t.Fatalf("All stopped after %d values with %v", i, f.Err())
+		}
+	})
+}
+
+func TestFileSkipAndErrors(t *testing.T) {
+	b := varint.AppendMany(nil, 1, 300, varint.Max)
+	b = varint.AppendBytes(b, []byte("abc"))
+	name := writeFile(t, b)
+	withMappers(t, func(t *testing.T) {
+		f, _ := Open(name, 0)
+		defer f.Close()
+		f.Skip(3)
+		if p := f.Bytes(2); p != nil {
+			t.Fatalf("Bytes over the limit = %q", p)
+		}
+		if !errors.Is(f.Err(), varint.ErrLimitExceeded) {
+			t.Fatalf("Bytes over the limit error = %v", f.Err())
+		}
+
+		f2, _ := Open(writeFile(t, b[:len(b)-1]), 0)
+		defer f2.Close()
+		f2.Skip(3)
+		f2.Bytes(10)
+		var offErr *varint.StreamOffsetError
+		if !errors.As(f2.Err(), &offErr) || offErr.Offset != 11 || !errors.Is(f2.Err(), io.ErrUnexpectedEOF) {
+			t.Fatalf("Bytes(truncated) error = %v, want io.ErrUnexpectedEOF at 11", f2.Err())
+		}
+
+		f3, _ := Open(name, 0)
+		defer f3.Close()
+		// The payload "abc" reads as two 2-byte varints, the second cut short
+		f3.Skip(6)
+		if !errors.As(f3.Err(), &offErr) || offErr.Offset != 14 || !errors.Is(f3.Err(), io.ErrUnexpectedEOF) {
+			t.Fatalf("Skip past the end error = %v, want io.ErrUnexpectedEOF at 14", f3.Err())
+		}
+	})
+}
+
+func TestFileStrict(t *testing.T) {
+	f, _ := Open(writeFile(t, []byte{0x40, 0x05}), 0)
+	defer f.Close()
+	f.SetStrict(true)
+	f.Uint64()
+	if !errors.Is(f.Err(), varint.ErrNonCanonical) {
+		t.Fatalf("strict Uint64 error = %v, want ErrNonCanonical", f.Err())
+	}
+}
+
+func TestFileEmpty(t *testing.T) {
+	f, err := Open(writeFile(t, nil), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := f.Finish(); err != nil {
+		t.Fatalf("Finish on an empty file = %v", err)
+	}
+	f.Uint64()
+	if !errors.Is(f.Err(), io.EOF) {
+		t.Fatalf("Uint64 on an empty file error = %v, want io.EOF", f.Err())
+	}
+}
+
+func TestFileViewsOutliveWindow(t *testing.T) {
+	b := stream()
+	name := writeFile(t, b)
+	withMappers(t, func(t *testing.T) {
+		f, err := Open(name, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := varint.NewDecoder(b)
+		// Keep a view from every field while reading on across many window
+		// moves; each must still hold its bytes at the end
+		var got, want [][]byte
+		for range 5000 {
+			f.Uint64()
+			f.Int64()
+			d.Uint64()
+			d.Int64()
+			got = append(got, f.Bytes(100))
+			want = append(want, d.Bytes(100))
+		}
+		if err := f.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if len(f.retired) == 0 {
+			t.Fatal("reading never moved the window")
+		}
+		for i := range got {
+			if !bytes.Equal(got[i], want[i]) {
+				t.Fatalf("view %d = %x after moving on, want %x", i, got[i], want[i])
+			}
+		}
+		if err := f.Close(); err != nil || f.retired != nil {
+			t.Fatalf("Close = %v with %d windows still mapped", err, len(f.retired))
+		}
+	})
+}
+
+func TestFileScanUnmapsWindows(t *testing.T) {
+	// Without views nothing needs to stay mapped
+	b := varint.AppendMany(nil, make([]uint64, 5*os.Getpagesize())...)
+	f, err := Open(writeFile(t, b), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for range f.All() {
+	}
+	if err := f.Finish(); err != nil || len(f.retired) != 0 {
+		t.Fatalf("Finish = %v with %d windows kept", err, len(f.retired))
+	}
+}
+
+func TestFileOffsetPast4GiB(t *testing.T) {
+	// Offsets are int64 even where int is 32 bits
+	f, err := Open(writeFile(t, []byte{1}), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.off = 5 << 30
+	f.fail(io.ErrUnexpectedEOF)
+	var offErr *varint.StreamOffsetError
+	if !errors.As(f.Err(), &offErr) || offErr.Offset != 5<<30 {
+		t.Fatalf("error = %v, want an offset of %d", f.Err(), int64(5<<30))
+	}
+}
+
+func TestFileClose(t *testing.T) {
+	// Views are documented as invalid after Close, which unmaps them; the
+	// File itself refuses every call from then on
+	f, err := Open(writeFile(t, stream()), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Uint64()
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close = %v", err)
+	}
+	if f.data != nil || f.release != nil {
+		t.Fatal("Close left the window mapped")
+	}
+	if v := f.Uint64(); v != 0 || f.Err() != os.ErrClosed {
+		t.Fatalf("Uint64 after Close = %d, %v; want os.ErrClosed", v, f.Err())
+	}
+	if p := f.Bytes(10); p != nil {
+		t.Fatalf("Bytes after Close = %x", p)
+	}
+	if err := f.Close(); err != os.ErrClosed {
+		t.Fatalf("second Close = %v, want os.ErrClosed", err)
+	}
+}
+
+func TestOpenMissing(t *testing.T) {
+	if _, err := Open(filepath.Join(t.TempDir(), "missing"), 0); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Open(missing) error = %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package varint
+
+import (
+	"math"
+)
+
+// ParseUint16 is like Parse but returns an *OutOfRangeError if the value does
+// not fit in a uint16. Bytes consumed are reported even when out of range
+func ParseUint16(b []byte) (uint16, int, error) {
+	v, n, err := parseMax(b, math.MaxUint16)
+	return uint16(v), n, err
+}
+
+// ParseUint32 is like Parse but returns an *OutOfRangeError if the value does
+// not fit in a uint32. Bytes consumed are reported even when out of range
+func ParseUint32(b []byte) (uint32, int, error) {
+	v, n, err := parseMax(b, math.MaxUint32)
+	return uint32(v), n, err
+}
+
+// ParseInt is like Parse but returns an *OutOfRangeError if the value does not
+// fit in the platform int, which can only happen where int is 32 bits
+func ParseInt(b []byte) (int, int, error) {
+	v, n, err := parseMax(b, math.MaxInt)
+	return int(v), n, err
+}
+
+// Unsigned is the set of unsigned integer types accepted by AppendOf and
+// ParseOf
+type Unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// AppendOf is Append for any unsigned integer type. It panics with a
+// *ValueTooLargeError if v exceeds Max
+func AppendOf[T Unsigned](dst []byte, v T) []byte {
+	return Append(dst, uint64(v))
+}
+
+// ParseOf is Parse for any unsigned integer type, returning an
+// *OutOfRangeError if the value does not fit in T
+func ParseOf[T Unsigned](b []byte) (T, int, error) {
+	v, n, err := parseMax(b, uint64(^T(0)))
+	return T(v), n, err
+}
+
+func parseMax(b []byte, limit uint64) (uint64, int, error) {
+	v, n, err := Parse(b)
+	if err != nil {
+		return 0, n, err
+	}
+	if v > limit {
+		return 0, n, &OutOfRangeError{Value: v, Limit: limit}
+	}
+	return v, n, nil
+}
@@ -0,0 +1,103 @@
+package varint
+
+import (
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"testing"
+)
+
+// -------------------------
+// ParseUint16 / ParseUint32 / ParseInt
+// -------------------------
+
+func TestParseUint16(t *testing.T) {
+	for _, v := range []uint64{0, 63, 64, math.MaxUint16} {
+		got, n, err := ParseUint16(Append(nil, v))
+		if err != nil || uint64(got) != v || n != Len(v) {
+			t.Fatalf("ParseUint16(%d) = %d, %d, %v", v, got, n, err)
+		}
+	}
+	checkOutOfRange(t, "ParseUint16", func(b []byte) error {
+		_, _, err := ParseUint16(b)
+		return err
+	}, math.MaxUint16)
+}
+
+func TestParseUint32(t *testing.T) {
+	for _, v := range []uint64{0, 16384, 1 << 30, math.MaxUint32} {
+		got, n, err := ParseUint32(Append(nil, v))
+		if err != nil || uint64(got) != v || n != Len(v) {
+			t.Fatalf("ParseUint32(%d) = %d, %d, %v", v, got, n, err)
+		}
+	}
+	checkOutOfRange(t, "ParseUint32", func(b []byte) error {
+		_, _, err := ParseUint32(b)
+		return err
+	}, math.MaxUint32)
+}
+
+func TestParseInt(t *testing.T) {
+	for _, v := range []uint64{0, 1 << 30, math.MaxInt32} {
+		got, n, err := ParseInt(Append(nil, v))
+		if err != nil || uint64(got) != v || n != Len(v) {
+			t.Fatalf("ParseInt(%d) = %d, %d, %v", v, got, n, err)
+		}
+	}
+	if strconv.IntSize == 32 {
+		checkOutOfRange(t, "ParseInt", func(b []byte) error {
+			_, _, err := ParseInt(b)
+			return err
+		}, math.MaxInt32)
+	} else if got, _, err := ParseInt(Append(nil, Max)); err != nil || uint64(got) != Max {
+		t.Fatalf("ParseInt(Max) = %d, %v", got, err)
+	}
+	if _, _, err := ParseInt([]byte{0x40}); err != io.ErrUnexpectedEOF {
+		t.Fatalf("ParseInt(truncated) error = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func checkOutOfRange(t *testing.T, name string, parse func([]byte) error, limit uint64) {
+	t.Helper()
+	err := parse(Append(nil, limit+1))
+	if !errors.Is(err, ErrOutOfRange) {
+		t.Fatalf("%s(%d) error = %v, want ErrOutOfRange", name, limit+1, err)
+	}
+	var rangeErr *OutOfRangeError
+	if !errors.As(err, &rangeErr) || rangeErr.Value != limit+1 || rangeErr.Limit != limit {
+		t.Fatalf("%s(%d) error = %#v, want value %d and limit %d", name, limit+1, err, limit+1, limit)
+	}
+}
+
+// -------------------------
+// AppendOf / ParseOf
+// -------------------------
+
+type port uint16
+
+func TestAppendOfParseOf(t *testing.T) {
+	b := AppendOf(nil, uint8(200))
+	if v, n, err := ParseOf[uint8](b); err != nil || v != 200 || n != 2 {
+		t.Fatalf("ParseOf[uint8] = %d, %d, %v", v, n, err)
+	}
+	b = AppendOf(nil, port(8080))
+	if v, _, err := ParseOf[port](b); err != nil || v != 8080 {
+		t.Fatalf("ParseOf[port] = %d, %v", v, err)
+	}
+	if v, _, err := ParseOf[uint64](Append(nil, Max)); err != nil || v != Max {
+		t.Fatalf("ParseOf[uint64](Max) = %d, %v", v, err)
+	}
+	checkOutOfRange(t, "ParseOf[uint8]", func(b []byte) error {
+		_, _, err := ParseOf[uint8](b)
+		return err
+	}, math.MaxUint8)
+	checkOutOfRange(t, "ParseOf[port]", func(b []byte) error {
+		_, _, err := ParseOf[port](b)
+		return err
+	}, math.MaxUint16)
+	checkOutOfRange(t, "ParseOf[uint32]", func(b []byte) error {
+		_, _, err := ParseOf[uint32](b)
+		return err
+	}, math.MaxUint32)
+}
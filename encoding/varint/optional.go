@@ -0,0 +1,61 @@
+package varint
+
+import (
+	"io"
+)
+
+// AppendOptional appends an optional value to dst as a presence byte, 0 for
+// absent and 1 for present, followed by the value as a varint when present.
+// An absent value always takes one byte, whatever v holds
+func AppendOptional(dst []byte, v uint64, present bool) []byte {
+	if !present {
+		return append(dst, 0)
+	}
+	return Append(append(dst, 1), v)
+}
+
+// ParseOptional reads a value written by AppendOptional. A presence byte
+// other than 0 or 1 is reported as an *OutOfRangeError
+func ParseOptional(b []byte) (v uint64, present bool, n int, err error) {
+	if len(b) == 0 {
+		return 0, false, 0, io.EOF
+	}
+	switch b[0] {
+	case 0:
+		return 0, false, 1, nil
+	case 1:
+		v, n, err := Parse(b[1:])
+		if err != nil {
+			return 0, false, 0, io.ErrUnexpectedEOF
+		}
+		return v, true, 1 + n, nil
+	default:
+		return 0, false, 0, &OutOfRangeError{Value: uint64(b[0]), Limit: 1}
+	}
+}
+
+// Optional is an unsigned value that may be absent, encoded as by
+// AppendOptional
+type Optional[T Unsigned] struct {
+	V     T
+	Valid bool
+}
+
+// AppendOptionalOf is AppendOptional for any unsigned integer type. It panics
+// with a *ValueTooLargeError if a present value exceeds Max
+func AppendOptionalOf[T Unsigned](dst []byte, o Optional[T]) []byte {
+	return AppendOptional(dst, uint64(o.V), o.Valid)
+}
+
+// ParseOptionalOf is ParseOptional for any unsigned integer type, returning an
+// *OutOfRangeError if a present value does not fit in T
+func ParseOptionalOf[T Unsigned](b []byte) (Optional[T], int, error) {
+	v, present, n, err := ParseOptional(b)
+	if err != nil {
+		return Optional[T]{}, 0, err
+	}
+	if limit := uint64(^T(0)); v > limit {
+		return Optional[T]{}, 0, &OutOfRangeError{Value: v, Limit: limit}
+	}
+	return Optional[T]{V: T(v), Valid: present}, n, nil
+}
@@ -0,0 +1,71 @@
+package varint
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+// -------------------------
+// AppendOptional / ParseOptional
+// -------------------------
+
+func TestOptionalRoundTrip(t *testing.T) {
+	for _, v := range testValues {
+		for _, present := range []bool{false, true} {
+			b := AppendOptional(nil, v, present)
+			got, gotPresent, n, err := ParseOptional(b)
+			if err != nil || gotPresent != present || n != len(b) {
+				t.Fatalf("ParseOptional(AppendOptional(%d, %v)) = %d, %v, %d, %v", v, present, got, gotPresent, n, err)
+			}
+			if present && got != v {
+				t.Fatalf("ParseOptional(AppendOptional(%d, true)) = %d", v, got)
+			}
+		}
+	}
+}
+
+func TestOptionalAbsentVsZero(t *testing.T) {
+	absent := AppendOptional(nil, 0, false)
+	zero := AppendOptional(nil, 0, true)
+	if string(absent) == string(zero) {
+		t.Fatalf("absent and present zero both encode as %x", absent)
+	}
+	if _, present, _, _ := ParseOptional(absent); present {
+		t.Fatal("absent value parsed as present")
+	}
+	if v, present, _, _ := ParseOptional(zero); !present || v != 0 {
+		t.Fatalf("present zero parsed as %d, %v", v, present)
+	}
+	if b := AppendOptional(nil, Max, false); len(b) != 1 {
+		t.Fatalf("absent value took %d bytes, want 1", len(b))
+	}
+}
+
+func TestParseOptionalErrors(t *testing.T) {
+	if _, _, _, err := ParseOptional(nil); err != io.EOF {
+		t.Fatalf("ParseOptional(nil) error = %v, want io.EOF", err)
+	}
+	if _, _, _, err := ParseOptional([]byte{1}); err != io.ErrUnexpectedEOF {
+		t.Fatalf("ParseOptional(flag only) error = %v, want io.ErrUnexpectedEOF", err)
+	}
+	_, _, _, err := ParseOptional([]byte{2, 0})
+	var rangeErr *OutOfRangeError
+	if !errors.As(err, &rangeErr) || rangeErr.Value != 2 {
+		t.Fatalf("ParseOptional(bad flag) error = %v, want *OutOfRangeError", err)
+	}
+}
+
+func TestOptionalOf(t *testing.T) {
+	for _, o := range []Optional[uint16]{{}, {V: 0, Valid: true}, {V: 65535, Valid: true}} {
+		b := AppendOptionalOf(nil, o)
+		got, n, err := ParseOptionalOf[uint16](b)
+		if err != nil || got != o || n != len(b) {
+			t.Fatalf("ParseOptionalOf(AppendOptionalOf(%+v)) = %+v, %d, %v", o, got, n, err)
+		}
+	}
+	b := AppendOptional(nil, 65536, true)
+	if _, _, err := ParseOptionalOf[uint16](b); !errors.Is(err, ErrOutOfRange) {
+		t.Fatalf("ParseOptionalOf[uint16](65536) error = %v, want ErrOutOfRange", err)
+	}
+}
@@ -0,0 +1,22 @@
+package varint
+
+import (
+	"bytes"
+)
+
+// Canonical encodings, as produced by Append, sort bytewise in numeric order:
+// the length bits grow with the width, and every value of a given width is
+// larger than every value of a narrower one, while within a width the payload
+// is big-endian. Because the encoding is also prefix-free, concatenations of
+// canonical varints sort element by element, so composite keys such as
+// Append(Append(nil, table), id) can be stored directly in ordered key-value
+// stores. The guarantee does not hold for non-minimal encodings; keys from
+// untrusted sources should be checked with ParseCanonical or
+// ValidateCanonical first
+
+// BytesCompare compares two keys made of canonical varints and returns -1, 0
+// or +1 exactly as comparing their decoded values, element by element, would.
+// It is bytes.Compare, exported to document that the ordering is supported
+func BytesCompare(a, b []byte) int {
+	return bytes.Compare(a, b)
+}
@@ -0,0 +1,48 @@
+package varint
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// -------------------------
+// BytesCompare
+// -------------------------
+
+func TestBytesCompareOrder(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	randValue := func() uint64 {
+		return rng.Uint64N(Max+1) >> rng.UintN(62)
+	}
+	boundaries := []uint64{0, 62, 63, 64, 65, 16382, 16383, 16384, 1<<30 - 1, 1 << 30, Max - 1, Max}
+	for i := 0; i < 100000; i++ {
+		x, y := randValue(), randValue()
+		if i < len(boundaries)*len(boundaries) {
+			x, y = boundaries[i/len(boundaries)], boundaries[i%len(boundaries)]
+		}
+		if got, want := BytesCompare(Append(nil, x), Append(nil, y)), cmp.Compare(x, y); got != want {
+			t.Fatalf("BytesCompare(%d, %d) = %d, want %d", x, y, got, want)
+		}
+	}
+}
+
+func TestBytesCompareCompositeKeys(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	keys := make([][]uint64, 2000)
+	for i := range keys {
+		keys[i] = []uint64{rng.Uint64N(4), rng.Uint64N(Max+1) >> rng.UintN(62)}
+	}
+	encoded := make([][]byte, len(keys))
+	for i, k := range keys {
+		encoded[i] = AppendMany(nil, k...)
+	}
+	slices.SortFunc(keys, slices.Compare)
+	slices.SortFunc(encoded, BytesCompare)
+	for i, k := range keys {
+		if got, _ := ParseAll(encoded[i]); !slices.Equal(got, k) {
+			t.Fatalf("key %d sorted as %v, want %v", i, got, k)
+		}
+	}
+}
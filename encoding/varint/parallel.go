@@ -0,0 +1,104 @@
+package varint
+
+import (
+	"encoding/binary"
+	"io"
+	"math/bits"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// parallelChunk is roughly how many input bytes DecodeAllParallel hands a
+// worker at a time. Inputs shorter than two chunks are decoded serially
+const parallelChunk = 1 << 20
+
+// span is a run of whole varints b[start:end] whose first value lands at
+// index pos of the output
+type span struct {
+	start, end, pos int
+}
+
+// DecodeAllParallel is like DecodeAll(nil, b) but spreads the decoding over up
+// to workers goroutines. A single serial pass over the length bits first cuts
+// b into chunks on value boundaries and counts the values in each, so the
+// result is allocated once and every chunk is decoded straight to its final
+// position. A non-positive workers uses GOMAXPROCS. Small inputs and a single
+// worker take the serial path. Results and errors are identical to DecodeAll
+func DecodeAllParallel(b []byte, workers int) ([]uint64, error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers == 1 || len(b) < 2*parallelChunk {
+		return DecodeAll(nil, b)
+	}
+	spans, total, err := splitSpans(b, parallelChunk)
+	out := make([]uint64, total)
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range min(workers, len(spans)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// DecodeAll owns the growth of its dst, so each worker decodes
+			// into its own scratch and copies the chunk into place. DecodeAll
+			// never grows dst past one slot per input byte, so a scratch as
+			// long as the longest span never regrows
+			scratch := make([]uint64, 0, parallelChunk+MaxLen)
+			for {
+				i := int(next.Add(1)) - 1
+				if i >= len(spans) {
+					return
+				}
+				s := spans[i]
+				scratch, _ = DecodeAll(scratch[:0], b[s.start:s.end])
+				copy(out[s.pos:], scratch)
+			}
+		}()
+	}
+	wg.Wait()
+	return out, err
+}
+
+// splitSpans cuts b into spans of whole varints of at least size bytes, bar
+// the last, and returns them with the total number of values. A truncated
+// final value ends the last span and is reported as DecodeAll would
+func splitSpans(b []byte, size int) (spans []span, total int, err error) {
+	for off := 0; off < len(b); {
+		limit := min(off+size, len(b))
+		n, end := countUntil(b, off, limit)
+		spans = append(spans, span{start: off, end: end, pos: total})
+		total += n
+		if end < limit {
+			return spans, total, &OffsetError{Offset: end, Err: io.ErrUnexpectedEOF}
+		}
+		off = end
+	}
+	return spans, total, nil
+}
+
+// countUntil counts the varints from b[off] on until reaching limit or a
+// value truncated by the end of b, returning the count and the offset it
+// stopped at. It steps like Count
+func countUntil(b []byte, off, limit int) (n, end int) {
+	for off < limit && off+MaxLen <= len(b) {
+		w := binary.BigEndian.Uint64(b[off : off+MaxLen])
+		if w&0xc000000000000000 != 0 {
+			off += 1 << (w >> 62)
+			n++
+			continue
+		}
+		ones := min(bits.LeadingZeros64(w&0xc0c0c0c0c0c0c0c0)>>3, limit-off)
+		off += ones
+		n += ones
+	}
+	for off < limit {
+		next := off + EncodedLen(b[off])
+		if next > len(b) {
+			break
+		}
+		off = next
+		n++
+	}
+	return n, off
+}
@@ -0,0 +1,58 @@
+package varint
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// -------------------------
+// DecodeAllParallel
+// -------------------------
+
+func TestDecodeAllParallel(t *testing.T) {
+	b := AppendMany(nil, append(bulkCorpus(1<<20), skewedValues(1<<21)...)...)
+	if len(b) < 3*parallelChunk {
+		t.Fatalf("corpus is only %d bytes, too short to split", len(b))
+	}
+	for _, cut := range []int{len(b), len(b) - 1, len(b) - 3} {
+		want, wantErr := DecodeAll(nil, b[:cut])
+		for _, workers := range []int{0, 1, 2, 3, 8} {
+			got, err := DecodeAllParallel(b[:cut], workers)
+			if !slices.Equal(got, want) {
+				t.Fatalf("cut %d, %d workers: DecodeAllParallel differs from DecodeAll", cut, workers)
+			}
+			if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+				t.Fatalf("cut %d, %d workers: error = %v, want %v", cut, workers, err, wantErr)
+			}
+		}
+	}
+	if got, err := DecodeAllParallel(nil, 4); len(got) != 0 || err != nil {
+		t.Fatalf("DecodeAllParallel(nil) = %v, %v", got, err)
+	}
+}
+
+func TestSplitSpans(t *testing.T) {
+	vs := append(bulkCorpus(3000), testValues...)
+	b := AppendMany(nil, vs...)
+	for _, size := range []int{1, 7, 100, len(b)} {
+		spans, total, err := splitSpans(b, size)
+		if err != nil || total != len(vs) {
+			t.Fatalf("size %d: splitSpans total = %d, %v; want %d", size, total, err, len(vs))
+		}
+		off := 0
+		for _, s := range spans {
+			got, err := ParseAll(b[s.start:s.end])
+			if s.start != off || err != nil || !slices.Equal(got, vs[s.pos:s.pos+len(got)]) {
+				t.Fatalf("size %d: span %+v does not hold whole values from its position", size, s)
+			}
+			if s.end-s.start < size && s.end != len(b) {
+				t.Fatalf("size %d: span %+v is short of the chunk size", size, s)
+			}
+			off = s.end
+		}
+		if off != len(b) {
+			t.Fatalf("size %d: spans end at %d, want %d", size, off, len(b))
+		}
+	}
+}
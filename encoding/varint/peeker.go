@@ -0,0 +1,89 @@
+package varint
+
+import (
+	"io"
+)
+
+// Peeker reads varints from an io.ByteReader and lets the caller look at the
+// next value before deciding whether to consume it. It buffers the bytes of
+// at most one value, avoiding the single-byte limit of io.ByteScanner. Peeker
+// itself implements io.ByteReader, so once a peeked value is left in place the
+// stream can be handed to Read or any other consumer unchanged
+type Peeker struct {
+	r   io.ByteReader
+	buf [MaxLen]byte
+	n   int // bytes buffered
+	off int // bytes of buf already returned by ReadByte
+}
+
+// NewPeeker returns a Peeker reading from r
+func NewPeeker(r io.ByteReader) *Peeker {
+	return &Peeker{r: r}
+}
+
+// fill buffers the next complete varint. After a failed read the bytes
+// received so far stay buffered, so a retry continues where it stopped
+func (p *Peeker) fill() error {
+	if p.off > 0 {
+		// ReadByte took part of the buffered value; shift what remains
+		p.n = copy(p.buf[:], p.buf[p.off:p.n])
+		p.off = 0
+	}
+	if p.n == 0 {
+		b, err := p.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		p.buf[0] = b
+		p.n = 1
+	}
+	for length := EncodedLen(p.buf[0]); p.n < length; p.n++ {
+		b, err := p.r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		p.buf[p.n] = b
+	}
+	return nil
+}
+
+// Peek returns the next varint without consuming it
+func (p *Peeker) Peek() (uint64, error) {
+	if err := p.fill(); err != nil {
+		return 0, err
+	}
+	v, _, err := Parse(p.buf[:p.n])
+	return v, err
+}
+
+// Consume returns the next varint and consumes it
+func (p *Peeker) Consume() (uint64, error) {
+	v, err := p.Peek()
+	if err != nil {
+		return 0, err
+	}
+	p.n, p.off = 0, 0
+	return v, nil
+}
+
+// ReadByte implements io.ByteReader, returning buffered bytes first
+func (p *Peeker) ReadByte() (byte, error) {
+	if p.off < p.n {
+		b := p.buf[p.off]
+		p.off++
+		if p.off == p.n {
+			p.n, p.off = 0, 0
+		}
+		return b, nil
+	}
+	return p.r.ReadByte()
+}
+
+// Buffered returns the number of bytes read from the underlying reader but
+// not yet consumed
+func (p *Peeker) Buffered() int {
+	return p.n - p.off
+}
@@ -0,0 +1,81 @@
+package varint
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"testing"
+)
+
+// -------------------------
+// Peeker
+// -------------------------
+
+func TestPeekerBufio(t *testing.T) {
+	stream := AppendMany(nil, Max, 1, 300)
+	p := NewPeeker(bufio.NewReader(bytes.NewReader(stream)))
+
+	// Peeking twice does not advance the stream
+	for i := 0; i < 2; i++ {
+		if v, err := p.Peek(); err != nil || v != Max {
+			t.Fatalf("Peek = %d, %v; want Max", v, err)
+		}
+	}
+	if p.Buffered() != 8 {
+		t.Fatalf("Buffered = %d, want 8", p.Buffered())
+	}
+	// The peeked value is still there for a normal Read
+	if v, n, err := ReadLen(p); err != nil || v != Max || n != 8 {
+		t.Fatalf("ReadLen after Peek = %d, %d, %v; want Max, 8", v, n, err)
+	}
+	if v, err := p.Peek(); err != nil || v != 1 {
+		t.Fatalf("Peek = %d, %v; want 1", v, err)
+	}
+	if v, err := p.Consume(); err != nil || v != 1 {
+		t.Fatalf("Consume = %d, %v; want 1", v, err)
+	}
+	if v, err := Read(p); err != nil || v != 300 {
+		t.Fatalf("Read = %d, %v; want 300", v, err)
+	}
+	if _, err := p.Peek(); err != io.EOF {
+		t.Fatalf("Peek at end error = %v, want io.EOF", err)
+	}
+}
+
+func TestPeekerPartialRead(t *testing.T) {
+	// A value read partially with ReadByte can still be peeked afterwards
+	stream := AppendMany(nil, 70000, 5)
+	p := NewPeeker(bytes.NewReader(stream))
+	if _, err := p.Peek(); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := p.ReadByte()
+	if b != stream[0] {
+		t.Fatalf("ReadByte = %#x, want %#x", b, stream[0])
+	}
+	for i := 1; i < 4; i++ {
+		if b, _ := p.ReadByte(); b != stream[i] {
+			t.Fatalf("ReadByte = %#x, want %#x", b, stream[i])
+		}
+	}
+	if v, err := p.Consume(); err != nil || v != 5 {
+		t.Fatalf("Consume = %d, %v; want 5", v, err)
+	}
+}
+
+func TestPeekerTruncated(t *testing.T) {
+	enc := Append(nil, Max)
+	r := bytes.NewReader(enc[:5])
+	p := NewPeeker(r)
+	if _, err := p.Peek(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("Peek error = %v, want io.ErrUnexpectedEOF", err)
+	}
+	if p.Buffered() != 5 {
+		t.Fatalf("Buffered = %d, want 5", p.Buffered())
+	}
+	// Once the rest arrives the value completes
+	r.Reset(enc[5:])
+	if v, err := p.Consume(); err != nil || v != Max {
+		t.Fatalf("Consume after retry = %d, %v; want Max", v, err)
+	}
+}
@@ -0,0 +1,165 @@
+package varint
+
+import (
+	"io"
+	"os"
+)
+
+const defaultPrefetchChunk = 64 << 10
+
+// PrefetchReader is a Reader that reads ahead on a background goroutine, so
+// that the latency of a slow source, such as a network stream or an object
+// store range read, overlaps with decoding instead of stalling every refill.
+// Two chunk buffers take turns: one is filled while the other is drained.
+//
+// An error from the source is reported only after every value read before it
+// has been returned, exactly as Reader does. A PrefetchReader is not safe for
+// concurrent use, and Close must be called to stop the goroutine
+type PrefetchReader struct {
+	rd  *Reader
+	pf  *prefetcher
+	err error // os.ErrClosed once closed
+}
+
+// NewPrefetchReader returns a PrefetchReader reading r ahead in chunks of
+// chunkSize bytes. A non-positive chunkSize selects a default of 64 KiB; tiny
+// sizes are raised to a small minimum. Reading starts immediately
+func NewPrefetchReader(r io.Reader, chunkSize int) *PrefetchReader {
+	if chunkSize <= 0 {
+		chunkSize = defaultPrefetchChunk
+	}
+	chunkSize = max(chunkSize, minReaderSize)
+	pf := &prefetcher{
+		src:    r,
+		full:   make(chan chunk, 2),
+		free:   make(chan []byte, 2),
+		done:   make(chan struct{}),
+		exited: make(chan struct{}),
+	}
+	pf.free <- make([]byte, chunkSize)
+	pf.free <- make([]byte, chunkSize)
+	go pf.run()
+	return &PrefetchReader{rd: NewReader(pf, chunkSize), pf: pf}
+}
+
+// Uvarint reads the next varint
+func (p *PrefetchReader) Uvarint() (uint64, error) {
+	if p.err != nil {
+		return 0, p.err
+	}
+	return p.rd.Uvarint()
+}
+
+// Varint reads the next zigzag-encoded varint
+func (p *PrefetchReader) Varint() (int64, error) {
+	if p.err != nil {
+		return 0, p.err
+	}
+	return p.rd.Varint()
+}
+
+// Bytes reads the next length-prefixed byte slice as Reader.Bytes does,
+// including the rule that a small result is only valid until the next call
+func (p *PrefetchReader) Bytes(maxLen int) ([]byte, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.rd.Bytes(maxLen)
+}
+
+// Offset returns the number of bytes consumed from the stream so far, not
+// counting bytes that were read ahead but not yet returned
+func (p *PrefetchReader) Offset() int64 {
+	return p.rd.Offset()
+}
+
+// Close stops the background goroutine, waiting for a read from the source
+// that is already in flight to return, and discards any data read ahead. It
+// reports the error the source failed with, if any, other than io.EOF, even
+// when buffered values kept it from being returned yet. Every later call
+// fails with os.ErrClosed
+func (p *PrefetchReader) Close() error {
+	if p.err != nil {
+		return p.err
+	}
+	p.err = os.ErrClosed
+	close(p.pf.done)
+	<-p.pf.exited
+	if err := p.pf.srcErr; err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// chunk is a filled buffer handed from the prefetching goroutine to the
+// consumer, with the error the source returned along with it
+type chunk struct {
+	b   []byte
+	err error
+}
+
+// prefetcher is the io.Reader behind a PrefetchReader. Its goroutine fills
+// buffers taken from free and passes them on through full; Read drains them
+// in order and returns each to free once used up
+type prefetcher struct {
+	src    io.Reader
+	full   chan chunk
+	free   chan []byte
+	done   chan struct{} // closed to stop the goroutine
+	exited chan struct{} // closed by the goroutine on return
+	srcErr error         // written by the goroutine before exited is closed
+
+	cur  []byte // unread part of the chunk being drained
+	held []byte // the whole buffer behind cur, to return to free
+	err  error  // error that came with the last chunk
+}
+
+// run reads the source until it fails or done is closed. Empty reads are
+// retried a bounded number of times, as in Reader
+func (pf *prefetcher) run() {
+	defer close(pf.exited)
+	for {
+		var buf []byte
+		select {
+		case buf = <-pf.free:
+		case <-pf.done:
+			return
+		}
+		n, err := 0, error(nil)
+		for i := 0; n == 0 && err == nil; i++ {
+			if i == 100 {
+				err = io.ErrNoProgress
+				break
+			}
+			n, err = pf.src.Read(buf[:cap(buf)])
+		}
+		if err != nil {
+			pf.srcErr = err
+		}
+		select {
+		case pf.full <- chunk{b: buf[:n], err: err}:
+		case <-pf.done:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (pf *prefetcher) Read(p []byte) (int, error) {
+	for len(pf.cur) == 0 {
+		if pf.err != nil {
+			return 0, pf.err
+		}
+		if pf.held != nil {
+			pf.free <- pf.held
+			pf.held = nil
+		}
+		c := <-pf.full
+		pf.cur, pf.held, pf.err = c.b, c.b, c.err
+	}
+	n := copy(p, pf.cur)
+	pf.cur = pf.cur[n:]
+	return n, nil
+}
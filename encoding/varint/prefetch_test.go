@@ -0,0 +1,161 @@
+package varint
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// -------------------------
+// PrefetchReader
+// -------------------------
+
+func TestPrefetchReader(t *testing.T) {
+	var stream []byte
+	stream = AppendMany(stream, bulkCorpus(5000)...)
+	stream = AppendInt(stream, -12345)
+	stream = AppendBytes(stream, []byte("small"))
+	stream = AppendBytes(stream, bytes.Repeat([]byte{7}, 100))
+
+	for _, size := range []int{0, 1, 37, 4096} {
+		r := NewPrefetchReader(iotest.HalfReader(bytes.NewReader(stream)), size)
+		for i, want := range bulkCorpus(5000) {
+			if v, err := r.Uvarint(); err != nil || v != want {
+				t.Fatalf("chunk %d: value %d = %d, %v; want %d", size, i, v, err, want)
+			}
+		}
+		if v, err := r.Varint(); err != nil || v != -12345 {
+			t.Fatalf("chunk %d: Varint = %d, %v; want -12345", size, v, err)
+		}
+		if p, err := r.Bytes(1000); err != nil || string(p) != "small" {
+			t.Fatalf("chunk %d: Bytes = %q, %v; want small", size, p, err)
+		}
+		if p, err := r.Bytes(1000); err != nil || !bytes.Equal(p, bytes.Repeat([]byte{7}, 100)) {
+			t.Fatalf("chunk %d: Bytes = %x, %v; want 100 sevens", size, p, err)
+		}
+		if r.Offset() != int64(len(stream)) {
+			t.Fatalf("chunk %d: Offset = %d, want %d", size, r.Offset(), len(stream))
+		}
+		if _, err := r.Uvarint(); err != io.EOF {
+			t.Fatalf("chunk %d: Uvarint at end error = %v, want io.EOF", size, err)
+		}
+		if err := r.Close(); err != nil {
+			t.Fatalf("chunk %d: Close = %v", size, err)
+		}
+	}
+}
+
+func TestPrefetchReaderErrorAfterData(t *testing.T) {
+	boom := errors.New("boom")
+	stream := AppendMany(nil, testValues...)
+
+	// The source fails along with the last of its data, which must all be
+	// returned before the failure
+	src := iotest.DataErrReader(io.MultiReader(bytes.NewReader(stream), iotest.ErrReader(boom)))
+	r := NewPrefetchReader(src, 1024)
+	for _, want := range testValues {
+		if v, err := r.Uvarint(); err != nil || v != want {
+			t.Fatalf("Uvarint = %d, %v; want %d", v, err, want)
+		}
+	}
+	if _, err := r.Uvarint(); err != boom {
+		t.Fatalf("Uvarint after data error = %v, want boom", err)
+	}
+	if err := r.Close(); err != boom {
+		t.Fatalf("Close = %v, want boom", err)
+	}
+
+	// Close reports the failure even while values are still buffered
+	r = NewPrefetchReader(iotest.DataErrReader(io.MultiReader(bytes.NewReader(stream), iotest.ErrReader(boom))), 1024)
+	if _, err := r.Uvarint(); err != nil {
+		t.Fatalf("first Uvarint = %v", err)
+	}
+	if err := r.Close(); err != boom {
+		t.Fatalf("Close with buffered values = %v, want boom", err)
+	}
+	if _, err := r.Uvarint(); err != os.ErrClosed {
+		t.Fatalf("Uvarint after Close = %v, want os.ErrClosed", err)
+	}
+	if err := r.Close(); err != os.ErrClosed {
+		t.Fatalf("second Close = %v, want os.ErrClosed", err)
+	}
+}
+
+func TestPrefetchReaderTruncated(t *testing.T) {
+	enc := Append(nil, Max)
+	r := NewPrefetchReader(iotest.OneByteReader(bytes.NewReader(enc[:5])), 0)
+	if _, err := r.Uvarint(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("Uvarint(%x) error = %v, want io.ErrUnexpectedEOF", enc[:5], err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close = %v", err)
+	}
+}
+
+func TestPrefetchReaderCloseEarly(t *testing.T) {
+	// The goroutine is blocked waiting for a free buffer and must still stop
+	r := NewPrefetchReader(&endlessReader{chunk: AppendMany(nil, testValues...)}, 64)
+	if _, err := r.Uvarint(); err != nil {
+		t.Fatalf("Uvarint = %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close = %v", err)
+	}
+}
+
+// latencyReader stalls for delay on every Read and returns at most step bytes
+type latencyReader struct {
+	r     io.Reader
+	delay time.Duration
+	step  int
+}
+
+func (l *latencyReader) Read(p []byte) (int, error) {
+	time.Sleep(l.delay)
+	return l.r.Read(p[:min(len(p), l.step)])
+}
+
+func TestPrefetchReaderLatency(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing test")
+	}
+	// Each 4 KiB round trip to the source takes 5ms and the consumer spends
+	// as long again working on each 4 KiB it decodes. Read-ahead should
+	// overlap the two and close to halve the total
+	const step, delay, rounds = 4096, 5 * time.Millisecond, 20
+	var stream []byte
+	for len(stream) < step*rounds {
+		stream = AppendMany(stream, testValues...)
+	}
+	stream = stream[:step*rounds]
+	consume := func(next func() (uint64, error), offset func() int64) time.Duration {
+		start := time.Now()
+		worked := int64(0)
+		for {
+			if _, err := next(); err != nil {
+				break
+			}
+			if off := offset(); off-worked >= step {
+				time.Sleep(delay)
+				worked = off
+			}
+		}
+		return time.Since(start)
+	}
+
+	plain := NewReader(&latencyReader{bytes.NewReader(stream), delay, step}, step)
+	direct := consume(plain.Uvarint, plain.Offset)
+
+	pre := NewPrefetchReader(&latencyReader{bytes.NewReader(stream), delay, step}, step)
+	defer pre.Close()
+	ahead := consume(pre.Uvarint, pre.Offset)
+
+	t.Logf("Reader %v, PrefetchReader %v", direct, ahead)
+	if ahead > direct*3/4 {
+		t.Fatalf("PrefetchReader took %v, not clearly faster than Reader's %v", ahead, direct)
+	}
+}
@@ -0,0 +1,153 @@
+package varint
+
+import (
+	"io"
+
+	"flux/encoding/zigzag"
+)
+
+const (
+	defaultReaderSize = 4096
+	minReaderSize     = 16
+)
+
+// Reader decodes varints from an io.Reader through its own buffer, saving
+// callers from composing bufio themselves. A value is only returned once all
+// of its bytes have arrived: if the stream ends partway through one the
+// result is io.ErrUnexpectedEOF, never a silently shortened value
+type Reader struct {
+	rd   io.Reader
+	buf  []byte
+	r, w int   // read and write positions within buf
+	off  int64 // bytes consumed by the caller
+	err  error // sticky error from rd
+}
+
+// NewReader returns a Reader over r with a buffer of bufSize bytes. A
+// non-positive bufSize selects a default of 4 KiB; tiny sizes are raised to a
+// small minimum
+func NewReader(r io.Reader, bufSize int) *Reader {
+	if bufSize <= 0 {
+		bufSize = defaultReaderSize
+	}
+	bufSize = max(bufSize, minReaderSize)
+	return &Reader{rd: r, buf: make([]byte, bufSize)}
+}
+
+// fill reads once from rd into the free space of buf, first moving unread
+// bytes to the front
+func (r *Reader) fill() {
+	if r.r > 0 {
+		r.w = copy(r.buf, r.buf[r.r:r.w])
+		r.r = 0
+	}
+	for i := 0; i < 100; i++ {
+		n, err := r.rd.Read(r.buf[r.w:])
+		r.w += n
+		if err != nil {
+			r.err = err
+			return
+		}
+		if n > 0 {
+			return
+		}
+	}
+	r.err = io.ErrNoProgress
+}
+
+// ensure buffers at least n <= len(buf) unread bytes. It returns io.EOF if
+// the stream ended with nothing buffered and io.ErrUnexpectedEOF if it ended
+// with fewer than n
+func (r *Reader) ensure(n int) error {
+	for r.w-r.r < n && r.err == nil {
+		r.fill()
+	}
+	if r.w-r.r >= n {
+		return nil
+	}
+	if r.err == io.EOF && r.w-r.r > 0 {
+		return io.ErrUnexpectedEOF
+	}
+	return r.err
+}
+
+// Uvarint reads the next varint
+func (r *Reader) Uvarint() (uint64, error) {
+	if r.w-r.r >= MaxLen {
+		// Parse decodes with a single 8-byte load
+		v, n, _ := Parse(r.buf[r.r:r.w])
+		r.r += n
+		r.off += int64(n)
+		return v, nil
+	}
+	if err := r.ensure(1); err != nil {
+		return 0, err
+	}
+	length := EncodedLen(r.buf[r.r])
+	if err := r.ensure(length); err != nil {
+		return 0, err
+	}
+	v, _, _ := Parse(r.buf[r.r : r.r+length])
+	r.r += length
+	r.off += int64(length)
+	return v, nil
+}
+
+// Varint reads the next zigzag-encoded varint
+func (r *Reader) Varint() (int64, error) {
+	u, err := r.Uvarint()
+	return zigzag.Decode(u), err
+}
+
+// Bytes reads the next length-prefixed byte slice, returning a *FrameSizeError
+// before reading any payload if its declared length exceeds maxLen. When the
+// payload fits in the Reader's buffer the result is a view into that buffer,
+// valid only until the next call on the Reader; larger payloads are returned
+// in a newly allocated slice. Callers that keep the bytes must copy them
+func (r *Reader) Bytes(maxLen int) ([]byte, error) {
+	length, err := r.Uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(max(maxLen, 0)) {
+		return nil, &FrameSizeError{Declared: length, Limit: uint64(max(maxLen, 0))}
+	}
+	n := int(length)
+	if n <= len(r.buf) {
+		if err := r.ensure(n); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		p := r.buf[r.r : r.r+n : r.r+n]
+		r.r += n
+		r.off += int64(n)
+		return p, nil
+	}
+	p := make([]byte, n)
+	copied := copy(p, r.buf[r.r:r.w])
+	r.r, r.w = 0, 0
+	m, err := io.ReadFull(r.rd, p[copied:])
+	r.off += int64(copied + m)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		r.err = err
+		return nil, err
+	}
+	return p, nil
+}
+
+// Offset returns the number of bytes consumed from the stream so far, not
+// counting bytes that are buffered but not yet returned
+func (r *Reader) Offset() int64 {
+	return r.off
+}
+
+// Buffered returns the number of bytes that can be consumed without reading
+// from the underlying reader
+func (r *Reader) Buffered() int {
+	return r.w - r.r
+}
@@ -0,0 +1,133 @@
+package varint
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+// -------------------------
+// Reader
+// -------------------------
+
+func TestReader(t *testing.T) {
+	var stream []byte
+	stream = AppendMany(stream, testValues...)
+	stream = AppendInt(stream, -12345)
+	stream = AppendBytes(stream, []byte("small"))
+	stream = AppendBytes(stream, bytes.Repeat([]byte{7}, 100))
+
+	sources := map[string]func() io.Reader{
+		"whole":   func() io.Reader { return bytes.NewReader(stream) },
+		"onebyte": func() io.Reader { return iotest.OneByteReader(bytes.NewReader(stream)) },
+		"half":    func() io.Reader { return iotest.HalfReader(bytes.NewReader(stream)) },
+		"dataerr": func() io.Reader { return iotest.DataErrReader(bytes.NewReader(stream)) },
+	}
+	for name, src := range sources {
+		r := NewReader(src(), minReaderSize)
+		for _, want := range testValues {
+			v, err := r.Uvarint()
+			if err != nil || v != want {
+				t.Fatalf("%s: Uvarint = %d, %v; want %d", name, v, err, want)
+			}
+		}
+		if v, err := r.Varint(); err != nil || v != -12345 {
+			t.Fatalf("%s: Varint = %d, %v; want -12345", name, v, err)
+		}
+		if p, err := r.Bytes(1000); err != nil || string(p) != "small" {
+			t.Fatalf("%s: Bytes = %q, %v; want small", name, p, err)
+		}
+		if p, err := r.Bytes(1000); err != nil || !bytes.Equal(p, bytes.Repeat([]byte{7}, 100)) {
+			t.Fatalf("%s: Bytes = %x, %v; want 100 sevens", name, p, err)
+		}
+		if r.Offset() != int64(len(stream)) {
+			t.Fatalf("%s: Offset = %d, want %d", name, r.Offset(), len(stream))
+		}
+		if _, err := r.Uvarint(); err != io.EOF {
+			t.Fatalf("%s: Uvarint at end error = %v, want io.EOF", name, err)
+		}
+	}
+}
+
+func TestReaderTruncated(t *testing.T) {
+	enc := Append(nil, Max)
+	for cut := 1; cut < len(enc); cut++ {
+		r := NewReader(iotest.OneByteReader(bytes.NewReader(enc[:cut])), 0)
+		if _, err := r.Uvarint(); err != io.ErrUnexpectedEOF {
+			t.Fatalf("Uvarint(%x) error = %v, want io.ErrUnexpectedEOF", enc[:cut], err)
+		}
+		if r.Offset() != 0 {
+			t.Fatalf("Uvarint(%x) consumed %d bytes of a partial value", enc[:cut], r.Offset())
+		}
+	}
+	// With 8 or more bytes buffered Uvarint takes a single-load path, which
+	// must not run past the end of the stream either
+	enc = AppendMany(nil, 1, 2, 3, 4, 5, 6, 7, Max)
+	r := NewReader(bytes.NewReader(enc[:len(enc)-1]), 0)
+	for want := uint64(1); want <= 7; want++ {
+		if v, err := r.Uvarint(); err != nil || v != want {
+			t.Fatalf("Uvarint = %d, %v; want %d", v, err, want)
+		}
+	}
+	if _, err := r.Uvarint(); err != io.ErrUnexpectedEOF || r.Offset() != 7 {
+		t.Fatalf("Uvarint(truncated) error = %v at offset %d, want io.ErrUnexpectedEOF at 7", err, r.Offset())
+	}
+	for _, size := range []int{5, 100} {
+		b := AppendBytes(nil, bytes.Repeat([]byte{1}, size))
+		r := NewReader(bytes.NewReader(b[:len(b)-1]), minReaderSize)
+		if _, err := r.Bytes(1000); err != io.ErrUnexpectedEOF {
+			t.Fatalf("Bytes(truncated %d) error = %v, want io.ErrUnexpectedEOF", size, err)
+		}
+	}
+}
+
+func TestReaderBytesLifetime(t *testing.T) {
+	var stream []byte
+	stream = AppendBytes(stream, []byte("first"))
+	stream = AppendBytes(stream, []byte("second"))
+	stream = AppendBytes(stream, bytes.Repeat([]byte{9}, 64))
+	r := NewReader(bytes.NewReader(stream), minReaderSize)
+
+	// Payloads that fit in the buffer are views, invalidated by later calls
+	first, _ := r.Bytes(100)
+	if &first[0] != &r.buf[1] {
+		t.Fatal("small payload was not a view into the buffer")
+	}
+	if _, err := r.Bytes(100); err != nil {
+		t.Fatal(err)
+	}
+	// Payloads larger than the buffer are fresh allocations
+	large, err := r.Bytes(100)
+	if err != nil || len(large) != 64 {
+		t.Fatalf("Bytes(large) = %d bytes, %v", len(large), err)
+	}
+	large[0] = 0
+	if r.buf[0] == 0 {
+		t.Fatal("large payload aliases the buffer")
+	}
+}
+
+func TestReaderBytesLimit(t *testing.T) {
+	r := NewReader(bytes.NewReader(Append(nil, Max)), 0)
+	_, err := r.Bytes(1 << 20)
+	var sizeErr *FrameSizeError
+	if !errors.As(err, &sizeErr) || sizeErr.Declared != Max {
+		t.Fatalf("Bytes error = %v, want *FrameSizeError for Max", err)
+	}
+}
+
+func TestReaderError(t *testing.T) {
+	errBroken := errors.New("broken")
+	stream := AppendMany(nil, 1, 2)
+	r := NewReader(io.MultiReader(bytes.NewReader(stream), iotest.ErrReader(errBroken)), 0)
+	for _, want := range []uint64{1, 2} {
+		if v, err := r.Uvarint(); err != nil || v != want {
+			t.Fatalf("Uvarint = %d, %v; want %d", v, err, want)
+		}
+	}
+	if _, err := r.Uvarint(); err != errBroken {
+		t.Fatalf("Uvarint error = %v, want %v", err, errBroken)
+	}
+}
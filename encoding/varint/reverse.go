@@ -0,0 +1,40 @@
+package varint
+
+import (
+	"io"
+	"slices"
+)
+
+// Reverse varints are the standard encoding with its bytes in reverse order:
+// the byte carrying the two length bits and the most significant payload bits
+// comes last, so the value can be decoded backwards from the end of a buffer
+// (e.g. a file trailer) without knowing where it starts. The two formats are
+// not interchangeable
+
+// AppendReverse encodes v as a reverse varint and appends it to dst. It panics
+// with a *ValueTooLargeError if v exceeds Max
+func AppendReverse(dst []byte, v uint64) []byte {
+	off := len(dst)
+	dst = Append(dst, v)
+	slices.Reverse(dst[off:])
+	return dst
+}
+
+// ParseReverse reads the reverse varint that ends at the last byte of b and
+// returns its value and the number of bytes it occupies, i.e. the value starts
+// at b[len(b)-n]
+func ParseReverse(b []byte) (v uint64, n int, err error) {
+	if len(b) == 0 {
+		return 0, 0, io.EOF
+	}
+	last := len(b) - 1
+	n = EncodedLen(b[last])
+	if len(b) < n {
+		return 0, 0, io.ErrUnexpectedEOF
+	}
+	v = uint64(b[last] & 0x3F)
+	for i := last - 1; i > last-n; i-- {
+		v = (v << 8) | uint64(b[i])
+	}
+	return v, n, nil
+}
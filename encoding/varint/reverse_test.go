@@ -0,0 +1,55 @@
+package varint
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"testing"
+)
+
+// -------------------------
+// AppendReverse / ParseReverse
+// -------------------------
+
+func TestReverseRoundTrip(t *testing.T) {
+	for _, v := range testValues {
+		fwd := Append(nil, v)
+		rev := AppendReverse([]byte("payload"), v)
+		tail := rev[len("payload"):]
+		if want := slices.Clone(fwd); !bytes.Equal(tail, reversed(want)) {
+			t.Fatalf("AppendReverse(%d) = %x, want %x", v, tail, reversed(want))
+		}
+		got, n, err := ParseReverse(rev)
+		if err != nil || got != v || n != len(fwd) {
+			t.Fatalf("ParseReverse(%x) = %d, %d, %v; want %d, %d, nil", rev, got, n, err, v, len(fwd))
+		}
+		if !bytes.Equal(rev[:len(rev)-n], []byte("payload")) {
+			t.Fatalf("ParseReverse(%x) reported the wrong start", rev)
+		}
+	}
+}
+
+func TestReverseTrailer(t *testing.T) {
+	// A file laid out as records followed by the offset of its index
+	file := []byte("records...index")
+	file = AppendReverse(file, 10)
+	off, n, err := ParseReverse(file)
+	if err != nil || string(file[off:len(file)-n]) != "index" {
+		t.Fatalf("ParseReverse trailer = %d, %d, %v", off, n, err)
+	}
+}
+
+func TestParseReverseTruncated(t *testing.T) {
+	if _, _, err := ParseReverse(nil); err != io.EOF {
+		t.Fatalf("ParseReverse(nil) error = %v, want io.EOF", err)
+	}
+	rev := AppendReverse(nil, Max)
+	if _, _, err := ParseReverse(rev[1:]); err != io.ErrUnexpectedEOF {
+		t.Fatalf("ParseReverse(%x) error = %v, want io.ErrUnexpectedEOF", rev[1:], err)
+	}
+}
+
+func reversed(b []byte) []byte {
+	slices.Reverse(b)
+	return b
+}
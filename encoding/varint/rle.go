@@ -0,0 +1,97 @@
+package varint
+
+import (
+	"io"
+	"slices"
+)
+
+// A run-length encoded buffer is a sequence of sections, each starting with a
+// tag varint holding a value count n shifted left by one. If the low bit of
+// the tag is set, the section is a run: one varint follows and the value
+// repeats n times. Otherwise it is a literal section of n back-to-back
+// varints. n is never zero
+
+// minRun is the shortest run AppendRuns encodes as a run section. Shorter runs
+// stay literals, since a run section plus the literal section it splits off
+// cost at least 3 bytes
+const minRun = 4
+
+// AppendRuns appends vs to dst with runs of minRun or more equal values
+// encoded as run sections and everything else as literal sections. Like Append
+// it panics with a *ValueTooLargeError if any value exceeds Max
+func AppendRuns(dst []byte, vs []uint64) []byte {
+	lit := 0 // start of the pending literals
+	for i := 0; i < len(vs); {
+		j := i + 1
+		for j < len(vs) && vs[j] == vs[i] {
+			j++
+		}
+		if j-i >= minRun {
+			dst = appendLiterals(dst, vs[lit:i])
+			dst = Append(dst, uint64(j-i)<<1|1)
+			dst = Append(dst, vs[i])
+			lit = j
+		}
+		i = j
+	}
+	return appendLiterals(dst, vs[lit:])
+}
+
+// appendLiterals appends a literal section holding vs, or nothing if vs is
+// empty
+func appendLiterals(dst []byte, vs []uint64) []byte {
+	if len(vs) == 0 {
+		return dst
+	}
+	dst = Append(dst, uint64(len(vs))<<1)
+	return AppendMany(dst, vs...)
+}
+
+// ParseRuns decodes a buffer written by AppendRuns, which must consist solely
+// of sections. The running total of values is checked against maxValues
+// before each section is expanded, failing with a *FrameSizeError, so a short
+// input cannot declare a huge run. Malformed sections are reported as an
+// *OffsetError wrapping ErrEmptyRun or io.ErrUnexpectedEOF
+func ParseRuns(b []byte, maxValues uint64) ([]uint64, error) {
+	var vs []uint64
+	for off := 0; off < len(b); {
+		tag, n, err := Parse(b[off:])
+		if err != nil {
+			return nil, &OffsetError{Offset: off, Err: err}
+		}
+		count := tag >> 1
+		if count == 0 {
+			return nil, &OffsetError{Offset: off, Err: ErrEmptyRun}
+		}
+		if have := uint64(len(vs)); count > maxValues-have {
+			return nil, &FrameSizeError{Declared: have + count, Limit: maxValues}
+		}
+		off += n
+		if tag&1 == 1 {
+			v, n, err := Parse(b[off:])
+			if err != nil {
+				return nil, &OffsetError{Offset: off, Err: unexpectedEOF(err)}
+			}
+			off += n
+			vs = slices.Grow(vs, int(count))
+			for range count {
+				vs = append(vs, v)
+			}
+			continue
+		}
+		// Every literal takes at least one byte
+		if count > uint64(len(b)-off) {
+			return nil, &OffsetError{Offset: off, Err: io.ErrUnexpectedEOF}
+		}
+		vs = slices.Grow(vs, int(count))
+		for range count {
+			v, n, err := Parse(b[off:])
+			if err != nil {
+				return nil, &OffsetError{Offset: off, Err: unexpectedEOF(err)}
+			}
+			vs = append(vs, v)
+			off += n
+		}
+	}
+	return vs, nil
+}
@@ -0,0 +1,99 @@
+package varint
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+)
+
+// -------------------------
+// AppendRuns / ParseRuns
+// -------------------------
+
+func TestAppendRuns(t *testing.T) {
+	vs := []uint64{7, 7, 7, 0, 0, 0, 0, 0, 300, 9}
+	got := AppendRuns([]byte{0xff}, vs)
+	want := []byte{
+		0xff,
+		3 << 1, 7, 7, 7, // a run of 3 stays literal
+		5<<1 | 1, 0,
+		2 << 1, 0x41, 0x2c, 9,
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("AppendRuns = %x, want %x", got, want)
+	}
+}
+
+func TestRunsRoundTrip(t *testing.T) {
+	alternating := make([]uint64, 1000)
+	for i := range alternating {
+		alternating[i] = uint64(i % 2)
+	}
+	var edges []uint64
+	for n := 1; n <= 2*minRun; n++ {
+		for range n {
+			edges = append(edges, uint64(n))
+		}
+	}
+	cases := map[string][]uint64{
+		"empty":       {},
+		"single":      {Max},
+		"identical":   slices.Repeat([]uint64{0}, 100000),
+		"alternating": alternating,
+		"edges":       edges,
+		"mixed":       append(slices.Repeat([]uint64{Max}, 9), append(testValues, 1, 1, 1, 1)...),
+	}
+	for name, vs := range cases {
+		b := AppendRuns(nil, vs)
+		got, err := ParseRuns(b, uint64(len(vs)))
+		if err != nil || !slices.Equal(got, vs) {
+			t.Fatalf("%s: ParseRuns = %d values, %v; want %d", name, len(got), err, len(vs))
+		}
+		// No input grows by more than the tag of a single literal section
+		if plain := len(AppendMany(nil, vs...)); len(b) > plain+MaxLen {
+			t.Fatalf("%s: AppendRuns took %d bytes, plain encoding %d", name, len(b), plain)
+		}
+	}
+	if b := AppendRuns(nil, cases["identical"]); len(b) != 5 {
+		t.Fatalf("100000 zeros took %d bytes, want 5", len(b))
+	}
+}
+
+func TestParseRunsErrors(t *testing.T) {
+	run := AppendRuns(nil, slices.Repeat([]uint64{300}, 10))
+	lit := AppendRuns(nil, []uint64{1, 2, Max})
+	cases := []struct {
+		name   string
+		b      []byte
+		max    uint64
+		want   error
+		offset int
+	}{
+		{"empty run", []byte{1, 5}, 10, ErrEmptyRun, 0},
+		{"empty literal", []byte{2, 5, 0}, 10, ErrEmptyRun, 2},
+		{"truncated tag", []byte{0x40}, 10, io.ErrUnexpectedEOF, 0},
+		{"missing run value", run[:1], 10, io.ErrUnexpectedEOF, 1},
+		{"truncated run value", run[:2], 10, io.ErrUnexpectedEOF, 1},
+		{"short literals", lit[:3], 10, io.ErrUnexpectedEOF, 1},
+		{"truncated literal", lit[:len(lit)-1], 10, io.ErrUnexpectedEOF, 3},
+	}
+	for _, c := range cases {
+		_, err := ParseRuns(c.b, c.max)
+		var offErr *OffsetError
+		if !errors.Is(err, c.want) || !errors.As(err, &offErr) || offErr.Offset != c.offset {
+			t.Fatalf("%s: ParseRuns error = %v, want %v at byte %d", c.name, err, c.want, c.offset)
+		}
+	}
+
+	// A few bytes declaring an enormous run are rejected before expanding it
+	huge := AppendMany(nil, (Max>>1)<<1|1, 0)
+	var sizeErr *FrameSizeError
+	if _, err := ParseRuns(huge, 1<<20); !errors.As(err, &sizeErr) || sizeErr.Declared != Max>>1 {
+		t.Fatalf("ParseRuns(huge run) error = %v, want *FrameSizeError", err)
+	}
+	if _, err := ParseRuns(append(slices.Clone(run), run...), 15); !errors.As(err, &sizeErr) || sizeErr.Declared != 20 {
+		t.Fatalf("ParseRuns(total over limit) error = %v, want *FrameSizeError declaring 20", err)
+	}
+}
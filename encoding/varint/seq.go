@@ -0,0 +1,280 @@
+package varint
+
+import (
+	"encoding/binary"
+	"io"
+	"iter"
+	"math/bits"
+	"slices"
+)
+
+// AppendMany encodes every value in vs and appends them to dst, growing dst at
+// most once. Like Append it panics with a *ValueTooLargeError if any value
+// exceeds Max; the check happens before anything is written
+func AppendMany(dst []byte, vs ...uint64) []byte {
+	total := 0
+	for _, v := range vs {
+		total += Len(v)
+	}
+	dst = slices.Grow(dst, total)
+	for _, v := range vs {
+		dst = Append(dst, v)
+	}
+	return dst
+}
+
+// EncodeAll is the batch twin of DecodeAll: it appends the encodings of vs to
+// dst, sizing and growing dst once and then writing through an index cursor.
+// If any value exceeds Max it returns dst unchanged with a
+// *ValueTooLargeError for the first such value, before anything is written
+func EncodeAll(dst []byte, vs []uint64) ([]byte, error) {
+	total := 0
+	for _, v := range vs {
+		if v > Max {
+			return dst, &ValueTooLargeError{Num: v}
+		}
+		total += Len(v)
+	}
+	dst = slices.Grow(dst, total)
+	n := len(dst)
+	out := dst[n : n+total]
+	i := 0
+	// Like putN, one switch both picks the width and stores it. Sharing
+	// Encode or putN instead branches on the width twice per value, which
+	// doubles the cost of the loop
+	for _, v := range vs {
+		switch {
+		case v <= _maxVarInt1:
+			out[i] = byte(v)
+			i++
+		case v <= _maxVarInt2:
+			binary.BigEndian.PutUint16(out[i:i+2], uint16(v)|0x4000)
+			i += 2
+		case v <= _maxVarInt4:
+			binary.BigEndian.PutUint32(out[i:i+4], uint32(v)|0x80000000)
+			i += 4
+		default:
+			binary.BigEndian.PutUint64(out[i:i+8], v|0xc000000000000000)
+			i += 8
+		}
+	}
+	return dst[:n+total], nil
+}
+
+// ParseAll decodes a buffer consisting solely of back-to-back varints. If the
+// last value is truncated it returns the values decoded so far together with
+// an *OffsetError wrapping io.ErrUnexpectedEOF
+func ParseAll(b []byte) ([]uint64, error) {
+	return ParseAllInto(nil, b)
+}
+
+// ParseAllInto is like ParseAll but appends the decoded values to dst,
+// reusing its capacity
+func ParseAllInto(dst []uint64, b []byte) ([]uint64, error) {
+	for off := 0; off < len(b); {
+		v, n, err := Parse(b[off:])
+		if err != nil {
+			return dst, &OffsetError{Offset: off, Err: err}
+		}
+		dst = append(dst, v)
+		off += n
+	}
+	return dst, nil
+}
+
+// DecodeInto is like ParseAllInto but first counts the values in b so that
+// dst grows at most once, which pays off when dst starts without capacity
+func DecodeInto(dst []uint64, b []byte) ([]uint64, error) {
+	n, _ := Count(b)
+	return ParseAllInto(slices.Grow(dst, n), b)
+}
+
+// DecodeUint32Into is like DecodeInto for values known to fit in 32 bits. An
+// entry that does not fit stops decoding with an *OffsetError wrapping an
+// *OutOfRangeError, returning the values decoded before it
+func DecodeUint32Into(dst []uint32, b []byte) ([]uint32, error) {
+	n, _ := Count(b)
+	dst = slices.Grow(dst, n)
+	for off := 0; off < len(b); {
+		v, n, err := ParseUint32(b[off:])
+		if err != nil {
+			return dst, &OffsetError{Offset: off, Err: err}
+		}
+		dst = append(dst, v)
+		off += n
+	}
+	return dst, nil
+}
+
+// All returns an iterator over the back-to-back varints in b, yielding the
+// offset of each value and the value itself. Iteration stops silently at a
+// truncated value; use AllErr to find out whether that happened
+func All(b []byte) iter.Seq2[int, uint64] {
+	seq, _ := AllErr(b)
+	return seq
+}
+
+// AllErr is like All but also returns a function reporting the error that
+// stopped the most recent iteration, or nil if the buffer was exhausted or the
+// caller stopped early
+func AllErr(b []byte) (iter.Seq2[int, uint64], func() error) {
+	var err error
+	seq := func(yield func(int, uint64) bool) {
+		err = nil
+		off := 0
+		for off < len(b) {
+			v, n, perr := Parse(b[off:])
+			if perr != nil {
+				err = &OffsetError{Offset: off, Err: perr}
+				return
+			}
+			if !yield(off, v) {
+				return
+			}
+			off += n
+		}
+	}
+	return seq, func() error { return err }
+}
+
+// Count returns the number of varints in b using only the length bits of each
+// value. If the last value is truncated it returns the number of complete
+// values together with an *OffsetError wrapping io.ErrUnexpectedEOF. While 8
+// bytes remain, a run of 1-byte values is counted a word at a time
+func Count(b []byte) (n int, err error) {
+	off := 0
+	for off+MaxLen <= len(b) {
+		w := binary.BigEndian.Uint64(b[off : off+MaxLen])
+		if w&0xc000000000000000 != 0 {
+			off += 1 << (w >> 62)
+			n++
+			continue
+		}
+		ones := bits.LeadingZeros64(w&0xc0c0c0c0c0c0c0c0) >> 3
+		off += ones
+		n += ones
+	}
+	return countFrom(b, off, n)
+}
+
+// countFrom is the per-value loop behind Count, counting the varints from
+// b[off] on and adding them to n. countFrom(b, 0, 0) is the reference Count
+func countFrom(b []byte, off, n int) (int, error) {
+	for off < len(b) {
+		next := off + EncodedLen(b[off])
+		if next > len(b) {
+			return n, &OffsetError{Offset: off, Err: io.ErrUnexpectedEOF}
+		}
+		off = next
+		n++
+	}
+	return n, nil
+}
+
+// skipWord skips at most limit varints, limit > 0, starting at b[off], which
+// must have 8 bytes after it. A leading run of 1-byte values within those 8
+// bytes is skipped in one step, otherwise a single value is. It returns the
+// number of values skipped and the new offset. Count inlines the same steps
+func skipWord(b []byte, off, limit int) (int, int) {
+	w := binary.BigEndian.Uint64(b[off : off+MaxLen])
+	if w&0xc000000000000000 != 0 {
+		return 1, off + 1<<(w>>62)
+	}
+	ones := min(bits.LeadingZeros64(w&0xc0c0c0c0c0c0c0c0)>>3, limit)
+	return ones, off + ones
+}
+
+// Validate checks that b consists solely of complete back-to-back varints
+// without decoding them or allocating. A truncated final value is reported as
+// an *OffsetError wrapping io.ErrUnexpectedEOF
+func Validate(b []byte) error {
+	_, err := Count(b)
+	return err
+}
+
+// ValidateCanonical is like Validate but also requires every value to be
+// minimally encoded, reporting the first that is not as an *OffsetError
+// wrapping ErrNonCanonical
+func ValidateCanonical(b []byte) error {
+	for off := 0; off < len(b); {
+		_, n, err := ParseCanonical(b[off:])
+		if err != nil {
+			return &OffsetError{Offset: off, Err: err}
+		}
+		off += n
+	}
+	return nil
+}
+
+// Skip advances past n varints in b using only their length bits and returns
+// the offset of the first varint not skipped. If b runs out first it returns a
+// *SkipError wrapping io.ErrUnexpectedEOF. A non-positive n returns 0. Like
+// Count it steps over runs of 1-byte values a word at a time
+func Skip(b []byte, n int) (offset int, err error) {
+	i := 0
+	for i < n && offset+MaxLen <= len(b) {
+		k, next := skipWord(b, offset, n-i)
+		i += k
+		offset = next
+	}
+	return skipFrom(b, offset, i, n)
+}
+
+// skipFrom is the per-value loop behind Skip, continuing from b[offset] with i
+// of the n varints already skipped. skipFrom(b, 0, 0, n) is the reference Skip
+func skipFrom(b []byte, offset, i, n int) (int, error) {
+	for ; i < n; i++ {
+		if offset >= len(b) {
+			return offset, &SkipError{Skipped: i, Offset: offset, Err: io.ErrUnexpectedEOF}
+		}
+		next := offset + EncodedLen(b[offset])
+		if next > len(b) {
+			return offset, &SkipError{Skipped: i, Offset: offset, Err: io.ErrUnexpectedEOF}
+		}
+		offset = next
+	}
+	return offset, nil
+}
+
+// DecodeAll is an optimized ParseAllInto for long buffers. While 8 bytes of
+// input remain it works a word at a time: a run of 1-byte values at the start
+// of the word is emitted in one step, and any other value is decoded with a
+// single load, with no per-value slicing. Only the last few values take the
+// general path. Where the CPU allows, all but the last few values are decoded
+// with vector instructions instead. Results and errors are identical to
+// ParseAllInto
+func DecodeAll(dst []uint64, b []byte) ([]uint64, error) {
+	dst, off := decodeBulk(dst, b)
+	for off+MaxLen <= len(b) {
+		w := binary.BigEndian.Uint64(b[off : off+MaxLen])
+		if ones := bits.LeadingZeros64(w&0xc0c0c0c0c0c0c0c0) >> 3; ones > 0 {
+			// Write all 8 bytes as values and keep only the leading run
+			dst = slices.Grow(dst, MaxLen)
+			n := len(dst)
+			out := dst[n : n+MaxLen]
+			out[0] = w >> 56
+			out[1] = w >> 48 & 0xff
+			out[2] = w >> 40 & 0xff
+			out[3] = w >> 32 & 0xff
+			out[4] = w >> 24 & 0xff
+			out[5] = w >> 16 & 0xff
+			out[6] = w >> 8 & 0xff
+			out[7] = w & 0xff
+			dst = dst[:n+ones]
+			off += ones
+			continue
+		}
+		length := 1 << (w >> 62)
+		dst = append(dst, (w&Max)>>(64-8*length))
+		off += length
+	}
+	for off < len(b) {
+		v, n, err := Parse(b[off:])
+		if err != nil {
+			return dst, &OffsetError{Offset: off, Err: err}
+		}
+		dst = append(dst, v)
+		off += n
+	}
+	return dst, nil
+}
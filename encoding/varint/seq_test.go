@@ -0,0 +1,371 @@
+package varint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"testing"
+)
+
+// -------------------------
+// AppendMany
+// -------------------------
+
+func TestAppendMany(t *testing.T) {
+	var want []byte
+	want = append(want, 0xAA)
+	for _, v := range testValues {
+		want = Append(want, v)
+	}
+	got := AppendMany([]byte{0xAA}, testValues...)
+	if !bytes.Equal(got, want) {
+		t.Fatalf("AppendMany = %x, want %x", got, want)
+	}
+	if got := AppendMany(nil); len(got) != 0 {
+		t.Fatalf("AppendMany(nil) = %x, want empty", got)
+	}
+}
+
+func TestAppendManyTooLarge(t *testing.T) {
+	dst := make([]byte, 1, 64)
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, ErrValueTooLarge) {
+			t.Fatalf("recovered %v, want ErrValueTooLarge", err)
+		}
+		if dst[:2][1] != 0 {
+			t.Fatalf("AppendMany wrote before panicking: %x", dst[:cap(dst)])
+		}
+	}()
+	AppendMany(dst, 1, 2, Max+1)
+}
+
+// -------------------------
+// ParseAll / ParseAllInto
+// -------------------------
+
+func TestParseAll(t *testing.T) {
+	got, err := ParseAll(AppendMany(nil, testValues...))
+	if err != nil {
+		t.Fatalf("ParseAll error = %v", err)
+	}
+	if !slices.Equal(got, testValues) {
+		t.Fatalf("ParseAll = %v, want %v", got, testValues)
+	}
+}
+
+func TestParseAllEmpty(t *testing.T) {
+	got, err := ParseAll(nil)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("ParseAll(nil) = %v, %v; want empty, nil", got, err)
+	}
+}
+
+func TestParseAllTruncated(t *testing.T) {
+	b := AppendMany(nil, 1, 300, Max)
+	got, err := ParseAll(b[:len(b)-1])
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("ParseAll error = %v, want io.ErrUnexpectedEOF", err)
+	}
+	var offErr *OffsetError
+	if !errors.As(err, &offErr) || offErr.Offset != 3 {
+		t.Fatalf("ParseAll error = %v, want an *OffsetError at byte 3", err)
+	}
+	if !slices.Equal(got, []uint64{1, 300}) {
+		t.Fatalf("ParseAll = %v, want the values before the truncation", got)
+	}
+}
+
+func TestParseAllInto(t *testing.T) {
+	dst := make([]uint64, 1, 16)
+	dst[0] = 42
+	got, err := ParseAllInto(dst, AppendMany(nil, 7, 70000))
+	if err != nil {
+		t.Fatalf("ParseAllInto error = %v", err)
+	}
+	if !slices.Equal(got, []uint64{42, 7, 70000}) {
+		t.Fatalf("ParseAllInto = %v", got)
+	}
+	if &got[0] != &dst[0] {
+		t.Fatal("ParseAllInto did not reuse dst")
+	}
+}
+
+func TestDecodeInto(t *testing.T) {
+	b := AppendMany(nil, testValues...)
+	got, err := DecodeInto(nil, b)
+	if err != nil || !slices.Equal(got, testValues) || cap(got) != len(testValues) {
+		t.Fatalf("DecodeInto(nil) = %v (cap %d), %v", got, cap(got), err)
+	}
+	dst := []uint64{42}
+	got, err = DecodeInto(dst, b[:len(b)-1])
+	var offErr *OffsetError
+	if !errors.As(err, &offErr) || offErr.Offset != len(b)-8 || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("DecodeInto(truncated) error = %v", err)
+	}
+	if !slices.Equal(got, append([]uint64{42}, testValues[:len(testValues)-1]...)) {
+		t.Fatalf("DecodeInto(truncated) = %v", got)
+	}
+}
+
+func TestDecodeUint32Into(t *testing.T) {
+	b := AppendMany(nil, 1, math.MaxUint32, 7)
+	got, err := DecodeUint32Into(make([]uint32, 0, 4), b)
+	if err != nil || !slices.Equal(got, []uint32{1, math.MaxUint32, 7}) {
+		t.Fatalf("DecodeUint32Into = %v, %v", got, err)
+	}
+
+	b = AppendMany(nil, 1, math.MaxUint32+1, 7)
+	got, err = DecodeUint32Into(nil, b)
+	var offErr *OffsetError
+	var rangeErr *OutOfRangeError
+	if !errors.As(err, &offErr) || offErr.Offset != 1 || !errors.As(err, &rangeErr) {
+		t.Fatalf("DecodeUint32Into(overflow) error = %v, want *OutOfRangeError at byte 1", err)
+	}
+	if !slices.Equal(got, []uint32{1}) {
+		t.Fatalf("DecodeUint32Into(overflow) = %v, want the values before it", got)
+	}
+}
+
+func TestDecodeIntoAllocs(t *testing.T) {
+	b := corpus(4096)
+	b32 := AppendMany(nil, slices.Repeat([]uint64{63, 16383, 1073741823}, 100)...)
+	dst := make([]uint64, 0, len(b))
+	dst32 := make([]uint32, 0, len(b32))
+	allocs := testing.AllocsPerRun(100, func() {
+		dst, _ = DecodeInto(dst[:0], b)
+	})
+	allocs += testing.AllocsPerRun(100, func() {
+		dst32, _ = DecodeUint32Into(dst32[:0], b32)
+	})
+	if allocs != 0 {
+		t.Fatalf("DecodeInto with capacity allocated %v times per run", allocs)
+	}
+}
+
+// -------------------------
+// All / AllErr
+// -------------------------
+
+func TestAll(t *testing.T) {
+	b := AppendMany(nil, testValues...)
+	i, off := 0, 0
+	for gotOff, v := range All(b) {
+		if gotOff != off || v != testValues[i] {
+			t.Fatalf("All yielded (%d, %d), want (%d, %d)", gotOff, v, off, testValues[i])
+		}
+		off += Len(v)
+		i++
+	}
+	if i != len(testValues) {
+		t.Fatalf("All yielded %d values, want %d", i, len(testValues))
+	}
+}
+
+func TestAllErrTruncated(t *testing.T) {
+	b := AppendMany(nil, 1, 2, Max)
+	seq, errf := AllErr(b[:len(b)-3])
+	var got []uint64
+	for _, v := range seq {
+		got = append(got, v)
+	}
+	if !slices.Equal(got, []uint64{1, 2}) {
+		t.Fatalf("AllErr yielded %v, want [1 2]", got)
+	}
+	if !errors.Is(errf(), io.ErrUnexpectedEOF) {
+		t.Fatalf("AllErr error = %v, want io.ErrUnexpectedEOF", errf())
+	}
+}
+
+func TestAllErrEarlyBreak(t *testing.T) {
+	// The truncated tail is never reached when the caller stops early
+	b := AppendMany(nil, 1, 2, 3)
+	b = append(b, 0xC0)
+	seq, errf := AllErr(b)
+	calls := 0
+	for _, v := range seq {
+		calls++
+		if v == 2 {
+			break
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("loop body ran %d times, want 2", calls)
+	}
+	if errf() != nil {
+		t.Fatalf("AllErr error = %v after early break, want nil", errf())
+	}
+}
+
+// -------------------------
+// Count
+// -------------------------
+
+func TestCount(t *testing.T) {
+	b := AppendMany(nil, testValues...)
+	n, err := Count(b)
+	if err != nil || n != len(testValues) {
+		t.Fatalf("Count = %d, %v; want %d, nil", n, err, len(testValues))
+	}
+	if n, err := Count(nil); n != 0 || err != nil {
+		t.Fatalf("Count(nil) = %d, %v; want 0, nil", n, err)
+	}
+}
+
+func TestCountTruncated(t *testing.T) {
+	b := AppendMany(nil, 1, 300, Max)
+	n, err := Count(b[:len(b)-1])
+	if n != 2 {
+		t.Fatalf("Count = %d, want 2", n)
+	}
+	var offErr *OffsetError
+	if !errors.As(err, &offErr) || offErr.Offset != 3 || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("Count error = %v, want io.ErrUnexpectedEOF at byte 3", err)
+	}
+}
+
+func TestCountMatchesReference(t *testing.T) {
+	vs := append(bulkCorpus(3000), skewedValues(3000)...)
+	vs = append(vs, testValues...)
+	b := AppendMany(nil, vs...)
+	for cut := len(b) - 20; cut <= len(b); cut++ {
+		n, err := Count(b[:cut])
+		wantN, wantErr := countFrom(b[:cut], 0, 0)
+		if n != wantN || fmt.Sprint(err) != fmt.Sprint(wantErr) {
+			t.Fatalf("cut %d: Count = %d, %v; want %d, %v", cut, n, err, wantN, wantErr)
+		}
+	}
+}
+
+// -------------------------
+// Validate
+// -------------------------
+
+func TestValidate(t *testing.T) {
+	b := AppendMany(nil, testValues...)
+	if err := Validate(b); err != nil {
+		t.Fatalf("Validate error = %v", err)
+	}
+	if err := ValidateCanonical(b); err != nil {
+		t.Fatalf("ValidateCanonical error = %v", err)
+	}
+	if err := Validate(nil); err != nil {
+		t.Fatalf("Validate(nil) error = %v", err)
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		Validate(b)
+		ValidateCanonical(b)
+	})
+	if allocs != 0 {
+		t.Fatalf("Validate allocated %v times per run", allocs)
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	b := AppendMany(nil, 1, 300)
+	padded := append(slices.Clone(b), 0x80, 0, 0, 5, 0x3f)
+	cases := []struct {
+		name     string
+		validate func([]byte) error
+		buf      []byte
+		want     error
+		offset   int
+	}{
+		{"Validate/truncated", Validate, b[:len(b)-1], io.ErrUnexpectedEOF, 1},
+		{"Validate/padded", Validate, padded, nil, 0},
+		{"ValidateCanonical/truncated", ValidateCanonical, b[:len(b)-1], io.ErrUnexpectedEOF, 1},
+		{"ValidateCanonical/padded", ValidateCanonical, padded, ErrNonCanonical, 3},
+	}
+	for _, c := range cases {
+		err := c.validate(c.buf)
+		if c.want == nil {
+			if err != nil {
+				t.Fatalf("%s: error = %v", c.name, err)
+			}
+			continue
+		}
+		var offErr *OffsetError
+		if !errors.Is(err, c.want) || !errors.As(err, &offErr) || offErr.Offset != c.offset {
+			t.Fatalf("%s: error = %v, want %v at byte %d", c.name, err, c.want, c.offset)
+		}
+	}
+}
+
+// -------------------------
+// Skip
+// -------------------------
+
+func TestSkip(t *testing.T) {
+	b := AppendMany(nil, testValues...)
+	off := 0
+	for n := 0; n <= len(testValues); n++ {
+		got, err := Skip(b, n)
+		if err != nil || got != off {
+			t.Fatalf("Skip(b, %d) = %d, %v; want %d, nil", n, got, err, off)
+		}
+		if n < len(testValues) {
+			off += Len(testValues[n])
+		}
+	}
+}
+
+func TestSkipTooMany(t *testing.T) {
+	b := AppendMany(nil, 1, 300, Max)
+	cases := []struct {
+		buf     []byte
+		n       int
+		skipped int
+		offset  int
+	}{
+		{b, 4, 3, 11},           // ran out between values
+		{b[:len(b)-1], 3, 2, 3}, // ran out mid-value
+		{nil, 1, 0, 0},
+	}
+	for _, c := range cases {
+		off, err := Skip(c.buf, c.n)
+		var skipErr *SkipError
+		if !errors.As(err, &skipErr) || !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("Skip(%x, %d) error = %v, want *SkipError", c.buf, c.n, err)
+		}
+		if skipErr.Skipped != c.skipped || skipErr.Offset != c.offset || off != c.offset {
+			t.Fatalf("Skip(%x, %d) = %d, %v; want %d skipped at byte %d", c.buf, c.n, off, err, c.skipped, c.offset)
+		}
+	}
+}
+
+func TestSkipMatchesReference(t *testing.T) {
+	b := AppendMany(nil, append(bulkCorpus(300), skewedValues(300)...)...)
+	total, _ := Count(b)
+	for _, buf := range [][]byte{b, b[:len(b)-1]} {
+		for n := 0; n <= total+1; n++ {
+			off, err := Skip(buf, n)
+			wantOff, wantErr := skipFrom(buf, 0, 0, n)
+			if off != wantOff || fmt.Sprint(err) != fmt.Sprint(wantErr) {
+				t.Fatalf("Skip(b[:%d], %d) = %d, %v; want %d, %v", len(buf), n, off, err, wantOff, wantErr)
+			}
+		}
+	}
+}
+
+// -------------------------
+// DecodeAll
+// -------------------------
+
+func TestDecodeAllMatchesParseAllInto(t *testing.T) {
+	vs := skewedValues(5000)
+	vs = append(vs, testValues...)
+	b := AppendMany(nil, vs...)
+	for cut := len(b) - 20; cut <= len(b); cut++ {
+		want, wantErr := ParseAllInto(nil, b[:cut])
+		got, err := DecodeAll(nil, b[:cut])
+		if !slices.Equal(got, want) {
+			t.Fatalf("cut %d: DecodeAll differs from ParseAllInto", cut)
+		}
+		if (err == nil) != (wantErr == nil) || err != nil && err.Error() != wantErr.Error() {
+			t.Fatalf("cut %d: DecodeAll error = %v, ParseAllInto error = %v", cut, err, wantErr)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package varint
+
+import (
+	"flux/encoding/zigzag"
+)
+
+// Signed values are zigzag-mapped onto the unsigned varint space, so that
+// small magnitudes of either sign stay short: 0 => 0, -1 => 1, 1 => 2, -2 => 3
+// and so on. With a 62-bit payload the representable range is
+// [MinSigned, MaxSigned]
+const (
+	// MinSigned is the smallest signed value that can be encoded (-2^61)
+	MinSigned int64 = -1 << 61
+
+	// MaxSigned is the largest signed value that can be encoded (2^61 - 1)
+	MaxSigned int64 = 1<<61 - 1
+)
+
+// AppendInt zigzag-encodes v and appends it to dst, returning the new slice.
+// It panics with a *SignedRangeError if v is outside [MinSigned, MaxSigned]
+func AppendInt(dst []byte, v int64) []byte {
+	if v < MinSigned || v > MaxSigned {
+		panic(&SignedRangeError{Num: v})
+	}
+	return Append(dst, zigzag.Encode(v))
+}
+
+// ParseInt64 reads a zigzag-encoded varint from b and returns the value, bytes
+// consumed, and error
+func ParseInt64(b []byte) (int64, int, error) {
+	u, n, err := Parse(b)
+	if err != nil {
+		return 0, n, err
+	}
+	return zigzag.Decode(u), n, nil
+}
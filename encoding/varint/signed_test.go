@@ -0,0 +1,56 @@
+package varint
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+)
+
+// -------------------------
+// AppendInt / ParseInt64
+// -------------------------
+
+func TestAppendInt(t *testing.T) {
+	cases := []struct {
+		v    int64
+		want []byte
+	}{
+		{0, []byte{0x00}},
+		{-1, []byte{0x01}},
+		{1, []byte{0x02}},
+		{-32, []byte{0x3F}},
+		{32, []byte{0x40, 0x40}},
+		{MaxSigned, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE}},
+		{MinSigned, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}},
+		{-MaxSigned, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFD}},
+	}
+	for _, c := range cases {
+		got := AppendInt(nil, c.v)
+		if !bytes.Equal(got, c.want) {
+			t.Fatalf("AppendInt(%d) = %x, want %x", c.v, got, c.want)
+		}
+		v, n, err := ParseInt64(got)
+		if err != nil || v != c.v || n != len(got) {
+			t.Fatalf("ParseInt64(%x) = %d, %d, %v; want %d, %d, nil", got, v, n, err, c.v, len(got))
+		}
+	}
+}
+
+func TestAppendIntOutOfRange(t *testing.T) {
+	for _, v := range []int64{MaxSigned + 1, MinSigned - 1, math.MaxInt64, math.MinInt64} {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				var rangeErr *SignedRangeError
+				if !errors.As(err, &rangeErr) || rangeErr.Num != v {
+					t.Fatalf("AppendInt(%d) recovered %v, want *SignedRangeError", v, err)
+				}
+				if !errors.Is(err, ErrValueTooLarge) {
+					t.Fatalf("errors.Is(%v, ErrValueTooLarge) = false", err)
+				}
+			}()
+			AppendInt(nil, v)
+		}()
+	}
+}
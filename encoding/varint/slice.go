@@ -0,0 +1,51 @@
+package varint
+
+import (
+	"io"
+	"slices"
+)
+
+// AppendUint64Slice appends vs to dst as a varint count followed by the
+// values. Like Append it panics with a *ValueTooLargeError if any value
+// exceeds Max
+func AppendUint64Slice(dst []byte, vs []uint64) []byte {
+	total := Len(uint64(len(vs)))
+	for _, v := range vs {
+		total += Len(v)
+	}
+	dst = slices.Grow(dst, total)
+	dst = Append(dst, uint64(len(vs)))
+	for _, v := range vs {
+		dst = Append(dst, v)
+	}
+	return dst
+}
+
+// ParseUint64Slice reads a slice written by AppendUint64Slice and returns it
+// with the number of bytes consumed. The count is checked against maxCount,
+// failing with a *FrameSizeError, and against the bytes left in b, failing
+// with io.ErrUnexpectedEOF, before anything is allocated. A truncated value is
+// reported as an *OffsetError
+func ParseUint64Slice(b []byte, maxCount uint64) ([]uint64, int, error) {
+	count, off, err := Parse(b)
+	if err != nil {
+		return nil, 0, err
+	}
+	if count > maxCount {
+		return nil, 0, &FrameSizeError{Declared: count, Limit: maxCount}
+	}
+	// Every value takes at least one byte
+	if count > uint64(len(b)-off) {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	vs := make([]uint64, count)
+	for i := range vs {
+		v, n, err := Parse(b[off:])
+		if err != nil {
+			return nil, 0, &OffsetError{Offset: off, Err: err}
+		}
+		vs[i] = v
+		off += n
+	}
+	return vs, off, nil
+}
@@ -0,0 +1,69 @@
+package varint
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"testing"
+)
+
+// -------------------------
+// AppendUint64Slice / ParseUint64Slice
+// -------------------------
+
+func TestUint64SliceRoundTrip(t *testing.T) {
+	large := make([]uint64, 10000)
+	for i := range large {
+		large[i] = uint64(i) * 104729
+	}
+	for _, vs := range [][]uint64{{}, {0}, {Max}, testValues, large} {
+		b := AppendUint64Slice([]byte{0xff}, vs)
+		got, n, err := ParseUint64Slice(b[1:], uint64(len(vs)))
+		if err != nil || n != len(b)-1 || !slices.Equal(got, vs) {
+			t.Fatalf("round trip of %d values = %d values, %d bytes, %v", len(vs), len(got), n, err)
+		}
+	}
+}
+
+func TestParseUint64SliceTrailing(t *testing.T) {
+	b := AppendUint64Slice(nil, []uint64{1, 2})
+	b = append(b, 0x3f)
+	got, n, err := ParseUint64Slice(b, 10)
+	if err != nil || n != 3 || !slices.Equal(got, []uint64{1, 2}) {
+		t.Fatalf("ParseUint64Slice = %v, %d, %v; want [1 2], 3, nil", got, n, err)
+	}
+}
+
+func TestParseUint64SliceMaxCount(t *testing.T) {
+	b := AppendUint64Slice(nil, []uint64{1, 2, 3})
+	_, _, err := ParseUint64Slice(b, 2)
+	var sizeErr *FrameSizeError
+	if !errors.As(err, &sizeErr) || sizeErr.Declared != 3 || sizeErr.Limit != 2 {
+		t.Fatalf("ParseUint64Slice(maxCount 2) error = %v, want *FrameSizeError", err)
+	}
+}
+
+func TestParseUint64SliceMaliciousCount(t *testing.T) {
+	// A count of a billion followed by a handful of bytes
+	b := Append(nil, 1_000_000_000)
+	b = append(b, 1, 2, 3, 4, 5, 6)
+	var err error
+	allocs := testing.AllocsPerRun(10, func() {
+		_, _, err = ParseUint64Slice(b, Max)
+	})
+	if err != io.ErrUnexpectedEOF {
+		t.Fatalf("ParseUint64Slice error = %v, want io.ErrUnexpectedEOF", err)
+	}
+	if allocs != 0 {
+		t.Fatalf("ParseUint64Slice allocated %v times before rejecting the count", allocs)
+	}
+}
+
+func TestParseUint64SliceTruncated(t *testing.T) {
+	b := AppendUint64Slice(nil, []uint64{1, 1 << 40})
+	_, _, err := ParseUint64Slice(b[:len(b)-1], 10)
+	var offErr *OffsetError
+	if !errors.As(err, &offErr) || offErr.Offset != 2 || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("ParseUint64Slice(truncated) error = %v, want *OffsetError at byte 2", err)
+	}
+}
@@ -0,0 +1,125 @@
+package varint
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+
+	"flux/encoding/varint/internal/spec"
+)
+
+// -------------------------
+// Differential tests against the reference implementation
+// -------------------------
+
+func FuzzParseSpec(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0x25})
+	f.Add([]byte{0x40, 0x25})
+	f.Add([]byte{0x9d, 0x7f, 0x3e})
+	f.Add([]byte{0xc2, 0x19, 0x7c, 0x5e, 0xff, 0x14, 0xe8, 0x8c, 0x00})
+	f.Fuzz(func(t *testing.T, b []byte) {
+		want, wantN, wantErr := spec.Parse(b)
+		if v, n, err := Parse(b); v != want || n != wantN || err != wantErr {
+			t.Fatalf("Parse(%x) = %d, %d, %v; spec = %d, %d, %v", b, v, n, err, want, wantN, wantErr)
+		}
+		if v, n, err := PeekLen(b); v != want || n != wantN || err != wantErr {
+			t.Fatalf("PeekLen(%x) = %d, %d, %v; spec = %d, %d, %v", b, v, n, err, want, wantN, wantErr)
+		}
+		if wantErr == nil && EncodedLen(b[0]) != wantN {
+			t.Fatalf("EncodedLen(%#x) = %d, spec consumed %d", b[0], EncodedLen(b[0]), wantN)
+		}
+		v, n, err := ParseCanonical(b)
+		switch {
+		case wantErr != nil:
+			if err != wantErr {
+				t.Fatalf("ParseCanonical(%x) error = %v, spec = %v", b, err, wantErr)
+			}
+		case spec.Canonical(b):
+			if v != want || n != wantN || err != nil {
+				t.Fatalf("ParseCanonical(%x) = %d, %d, %v; spec = %d, %d", b, v, n, err, want, wantN)
+			}
+		default:
+			if !errors.Is(err, ErrNonCanonical) {
+				t.Fatalf("ParseCanonical(%x) error = %v, want ErrNonCanonical", b, err)
+			}
+		}
+		if got, gotN, err := ReadLen(bytes.NewReader(b)); wantErr == nil && (got != want || gotN != wantN || err != nil) {
+			t.Fatalf("ReadLen(%x) = %d, %d, %v; spec = %d, %d", b, got, gotN, err, want, wantN)
+		}
+	})
+}
+
+func FuzzAppendSpec(f *testing.F) {
+	for _, v := range testValues {
+		f.Add(v)
+	}
+	f.Add(uint64(Max + 1))
+	f.Add(^uint64(0))
+	f.Fuzz(func(t *testing.T, v uint64) {
+		want, wantErr := spec.Append([]byte{0xaa}, v)
+		got, err := AppendChecked([]byte{0xaa}, v)
+		if (err == nil) != (wantErr == nil) || !bytes.Equal(got, want) {
+			t.Fatalf("AppendChecked(%d) = %x, %v; spec = %x, %v", v, got, err, want, wantErr)
+		}
+		if wantErr != nil {
+			if Fits(v) {
+				t.Fatalf("Fits(%d) = true, spec rejects it", v)
+			}
+			return
+		}
+		if n := Len(v); n != spec.Length(v) {
+			t.Fatalf("Len(%d) = %d, spec = %d", v, n, spec.Length(v))
+		}
+		var buf [MaxLen]byte
+		if n := Put(buf[:], v); !bytes.Equal(buf[:n], want[1:]) {
+			t.Fatalf("Put(%d) = %x, spec = %x", v, buf[:n], want[1:])
+		}
+		if fixed, n := EncodeFixed(v); !bytes.Equal(fixed[:n], want[1:]) {
+			t.Fatalf("EncodeFixed(%d) = %x, spec = %x", v, fixed[:n], want[1:])
+		}
+		if many := AppendMany(nil, v, v); !bytes.Equal(many, append(want[1:], want[1:]...)) {
+			t.Fatalf("AppendMany(%d, %d) = %x, spec = %x twice", v, v, many, want[1:])
+		}
+		if all, err := EncodeAll(nil, []uint64{v, v}); err != nil || !bytes.Equal(all, append(want[1:], want[1:]...)) {
+			t.Fatalf("EncodeAll(%d, %d) = %x, %v; spec = %x twice", v, v, all, err, want[1:])
+		}
+	})
+}
+
+// specParseAll decodes back-to-back varints with the reference implementation,
+// returning the values before any failure and the failing offset, or -1
+func specParseAll(b []byte) ([]uint64, int) {
+	var vs []uint64
+	for off := 0; off < len(b); {
+		v, n, err := spec.Parse(b[off:])
+		if err != nil {
+			return vs, off
+		}
+		vs = append(vs, v)
+		off += n
+	}
+	return vs, -1
+}
+
+func FuzzDecodeAllSpec(f *testing.F) {
+	f.Add([]byte{})
+	f.Add(AppendMany(nil, testValues...))
+	f.Add(AppendMany(nil, 1, 2, 3, 4, 5, 6, 7, 8, 9, 300, 10, 11))
+	f.Add(append(AppendMany(nil, 1, 2, 3, 4, 5, 6, 7, 8), 0xc0, 0))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		want, failAt := specParseAll(b)
+		got, err := DecodeAll([]uint64{42}, b)
+		if !slices.Equal(got[1:], want) {
+			t.Fatalf("DecodeAll(%x) = %v, spec = %v", b, got[1:], want)
+		}
+		var offErr *OffsetError
+		switch {
+		case failAt < 0 && err != nil:
+			t.Fatalf("DecodeAll(%x) error = %v, spec succeeded", b, err)
+		case failAt >= 0 && (!errors.As(err, &offErr) || offErr.Offset != failAt || !errors.Is(err, ErrTruncated)):
+			t.Fatalf("DecodeAll(%x) error = %v, spec failed at byte %d", b, err, failAt)
+		}
+	})
+}
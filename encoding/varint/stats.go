@@ -0,0 +1,57 @@
+package varint
+
+import "flux/encoding/zigzag"
+
+// Collect returns the Stats of a buffer of back-to-back varints, with widths
+// taken from the length bits exactly as the decoder reads them. If the last
+// value is truncated the Stats cover the complete values before it and the
+// error is an *OffsetError wrapping io.ErrUnexpectedEOF
+func Collect(b []byte) (Stats, error) {
+	var s Stats
+	for off := 0; off < len(b); {
+		v, n, err := Parse(b[off:])
+		if err != nil {
+			return s, &OffsetError{Offset: off, Err: err}
+		}
+		s.add(v, n)
+		off += n
+	}
+	return s, nil
+}
+
+// StatsWriter writes values through a Writer and tallies the Stats of those
+// it accepts, sizing each with Len just as the Writer encodes it
+type StatsWriter struct {
+	w *Writer
+	s Stats
+}
+
+// NewStatsWriter returns a StatsWriter writing through w
+func NewStatsWriter(w *Writer) *StatsWriter {
+	return &StatsWriter{w: w}
+}
+
+// Uvarint writes v with Writer.Uvarint and counts it if that succeeds
+func (sw *StatsWriter) Uvarint(v uint64) error {
+	if err := sw.w.Uvarint(v); err != nil {
+		return err
+	}
+	sw.s.add(v, Len(v))
+	return nil
+}
+
+// Varint writes v with Writer.Varint and counts its zigzag encoding if that
+// succeeds
+func (sw *StatsWriter) Varint(v int64) error {
+	if err := sw.w.Varint(v); err != nil {
+		return err
+	}
+	u := zigzag.Encode(v)
+	sw.s.add(u, Len(u))
+	return nil
+}
+
+// Stats returns the tally of the values written so far
+func (sw *StatsWriter) Stats() Stats {
+	return sw.s
+}
@@ -0,0 +1,98 @@
+package varint
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+)
+
+// -------------------------
+// Collect / StatsWriter
+// -------------------------
+
+// referenceStats tallies vs from first principles, without the package's
+// width helpers
+func referenceStats(vs []uint64) Stats {
+	var s Stats
+	for i, v := range vs {
+		var w, n int
+		switch {
+		case v < 1<<6:
+			w, n = 0, 1
+		case v < 1<<14:
+			w, n = 1, 2
+		case v < 1<<30:
+			w, n = 2, 4
+		default:
+			w, n = 3, 8
+		}
+		s.Values++
+		s.Bytes += int64(n)
+		s.Widths[w]++
+		if i == 0 {
+			s.Min, s.Max = v, v
+		}
+		s.Min, s.Max = min(s.Min, v), max(s.Max, v)
+	}
+	return s
+}
+
+func TestCollect(t *testing.T) {
+	for name, vs := range map[string][]uint64{
+		"testValues": testValues,
+		"skewed":     skewedValues(10000),
+		"uniform":    uniformValues(10000),
+		"empty":      nil,
+	} {
+		b := AppendMany(nil, vs...)
+		s, err := Collect(b)
+		if want := referenceStats(vs); err != nil || s != want {
+			t.Fatalf("%s: Collect = %+v, %v; want %+v", name, s, err, want)
+		}
+		if s.Bytes != int64(len(b)) {
+			t.Fatalf("%s: Collect counted %d bytes of %d", name, s.Bytes, len(b))
+		}
+	}
+	// A truncated tail leaves the Stats of the values before it
+	b := AppendMany(nil, testValues...)
+	s, err := Collect(b[:len(b)-1])
+	var offErr *OffsetError
+	if !errors.Is(err, io.ErrUnexpectedEOF) || !errors.As(err, &offErr) || offErr.Offset != len(b)-MaxLen {
+		t.Fatalf("Collect(truncated) error = %v", err)
+	}
+	if want := referenceStats(testValues[:len(testValues)-1]); s != want {
+		t.Fatalf("Collect(truncated) = %+v, want %+v", s, want)
+	}
+}
+
+func TestStatsWriter(t *testing.T) {
+	vs := append(slices.Clone(testValues), skewedValues(5000)...)
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 0)
+	sw := NewStatsWriter(w)
+	for _, v := range vs {
+		if err := sw.Uvarint(v); err != nil {
+			t.Fatalf("Uvarint(%d) error = %v", v, err)
+		}
+	}
+	if err := sw.Varint(-3); err != nil {
+		t.Fatalf("Varint error = %v", err)
+	}
+	// Rejected values are not counted
+	if err := sw.Uvarint(Max + 1); err == nil {
+		t.Fatal("Uvarint(Max+1) succeeded")
+	}
+	if err := sw.Varint(MaxSigned + 1); err == nil {
+		t.Fatal("Varint(MaxSigned+1) succeeded")
+	}
+	w.Flush()
+	want := referenceStats(append(vs, 5))
+	if got := sw.Stats(); got != want {
+		t.Fatalf("Stats = %+v, want %+v", got, want)
+	}
+	if got, err := Collect(buf.Bytes()); err != nil || got != want {
+		t.Fatalf("Collect of the written stream = %+v, %v; want %+v", got, err, want)
+	}
+}
@@ -0,0 +1,105 @@
+package varint
+
+import (
+	"bytes"
+	"io"
+)
+
+// A sync record is SyncMarker followed by a varint whose bytes are escaped so
+// that the marker cannot appear inside it: every syncEscape byte is written
+// as syncEscape, 0x00. Since an escaped body never holds syncEscape followed
+// by anything but 0x00, the two marker bytes occur in an undamaged stream only
+// where a record starts, and a reader that lost its place after corruption can
+// pick up again at the next marker.
+//
+// The cost is the 2 marker bytes on every value plus one byte for each
+// syncEscape in the varint, so a 1-byte value takes 3 bytes and the worst
+// case is MaxSyncLen. The escape byte is a 4-byte varint's first byte and
+// otherwise appears only as a payload byte of a wider value, where it is
+// rare. Corruption can still produce a false marker, or a record that parses
+// to the wrong value, with a chance of roughly 1 in 65536 per damaged byte
+// for the former; callers that need certainty must add a checksum above this
+
+// SyncMarker starts every sync record
+const SyncMarker = "\xa5\x5a"
+
+const syncEscape = 0xa5 // first byte of SyncMarker
+
+// MaxSyncLen is the longest sync record: the marker and an 8-byte varint with
+// all seven of its trailing bytes escaped
+const MaxSyncLen = len(SyncMarker) + MaxLen + MaxLen - 1
+
+// AppendSync appends v to dst as a sync record. Like Append it panics with a
+// *ValueTooLargeError if v exceeds Max
+func AppendSync(dst []byte, v uint64) []byte {
+	dst = append(dst, SyncMarker...)
+	buf, n := Encode(v)
+	for _, c := range buf[:n] {
+		dst = append(dst, c)
+		if c == syncEscape {
+			dst = append(dst, 0)
+		}
+	}
+	return dst
+}
+
+// ParseSync reads the sync record at the start of b and returns its value and
+// the number of bytes consumed. It returns io.EOF for an empty b,
+// ErrNoSyncMarker if b does not start with the marker, ErrBadEscape if the
+// body is not properly escaped, which is also how a record running into the
+// next marker fails, and io.ErrUnexpectedEOF if b ends inside the record
+func ParseSync(b []byte) (value uint64, consumed int, err error) {
+	if len(b) == 0 {
+		return 0, 0, io.EOF
+	}
+	if !bytes.HasPrefix(b, []byte(SyncMarker)) {
+		if len(b) < len(SyncMarker) && b[0] == syncEscape {
+			return 0, 0, io.ErrUnexpectedEOF
+		}
+		return 0, 0, ErrNoSyncMarker
+	}
+	var buf [MaxLen]byte
+	n, length := 0, 1
+	off := len(SyncMarker)
+	for n < length {
+		if off >= len(b) {
+			return 0, 0, io.ErrUnexpectedEOF
+		}
+		c := b[off]
+		off++
+		if c == syncEscape {
+			if off >= len(b) {
+				return 0, 0, io.ErrUnexpectedEOF
+			}
+			if b[off] != 0 {
+				return 0, 0, ErrBadEscape
+			}
+			off++
+		}
+		if n == 0 {
+			length = EncodedLen(c)
+		}
+		buf[n] = c
+		n++
+	}
+	v, _, _ := Parse(buf[:length])
+	return v, off, nil
+}
+
+// Resync returns the offset of the first sync record in b that parses
+// completely, or false if there is none. After ParseSync fails at offset off,
+// Resync(b[off+1:]) finds where to resume. A record cut off by the end of b
+// is not reported, so a streaming caller should keep the unscanned tail of
+// up to MaxSyncLen-1 bytes and retry once more data arrives
+func Resync(b []byte) (offset int, ok bool) {
+	for off := 0; ; off++ {
+		i := bytes.Index(b[off:], []byte(SyncMarker))
+		if i < 0 {
+			return 0, false
+		}
+		off += i
+		if _, _, err := ParseSync(b[off:]); err == nil {
+			return off, true
+		}
+	}
+}
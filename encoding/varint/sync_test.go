@@ -0,0 +1,163 @@
+package varint
+
+import (
+	"bytes"
+	"io"
+	"math/rand/v2"
+	"testing"
+)
+
+// -------------------------
+// Sync records
+// -------------------------
+
+func TestSyncRoundTrip(t *testing.T) {
+	vs := append(append([]uint64{}, testValues...), 0x25, 0xa5, 0x25a5a5a5, 0x3fa5a5a5a5a5a5a5, 0x0000a55a)
+	var b []byte
+	for _, v := range vs {
+		b = AppendSync(b, v)
+	}
+	if bytes.Count(b, []byte(SyncMarker)) != len(vs) {
+		t.Fatalf("stream %x holds %d markers, want %d", b, bytes.Count(b, []byte(SyncMarker)), len(vs))
+	}
+	off := 0
+	for _, want := range vs {
+		v, n, err := ParseSync(b[off:])
+		if err != nil || v != want {
+			t.Fatalf("ParseSync(%x) = %d, %v; want %d", b[off:], v, err, want)
+		}
+		off += n
+	}
+	if _, _, err := ParseSync(b[off:]); err != io.EOF {
+		t.Fatalf("ParseSync at end error = %v, want io.EOF", err)
+	}
+
+	worst := AppendSync(nil, 0x3fa5a5a5a5a5a5a5)
+	if len(worst) != MaxSyncLen {
+		t.Fatalf("worst case record is %d bytes, want MaxSyncLen %d", len(worst), MaxSyncLen)
+	}
+	if got := AppendSync(nil, 7); !bytes.Equal(got, []byte{0xa5, 0x5a, 7}) {
+		t.Fatalf("AppendSync(7) = %x", got)
+	}
+}
+
+func TestParseSyncErrors(t *testing.T) {
+	rec := AppendSync(nil, 0x25a5a5a5)
+	for cut := 1; cut < len(rec); cut++ {
+		if _, _, err := ParseSync(rec[:cut]); err != io.ErrUnexpectedEOF {
+			t.Fatalf("ParseSync(%x) error = %v, want io.ErrUnexpectedEOF", rec[:cut], err)
+		}
+	}
+	cases := []struct {
+		in   []byte
+		want error
+	}{
+		{[]byte{7}, ErrNoSyncMarker},
+		{[]byte{0xa5, 0x00, 7}, ErrNoSyncMarker},
+		{[]byte{0xa5, 0x5a, 0xa5, 0x5a}, ErrBadEscape},
+		{append([]byte{0xa5, 0x5a, 0x40}, SyncMarker...), ErrBadEscape},
+	}
+	for _, c := range cases {
+		if _, _, err := ParseSync(c.in); err != c.want {
+			t.Fatalf("ParseSync(%x) error = %v, want %v", c.in, err, c.want)
+		}
+	}
+}
+
+func TestResync(t *testing.T) {
+	b := append([]byte{0x12, 0xa5, 0xa5}, AppendSync(nil, 300)...)
+	if off, ok := Resync(b); !ok || off != 3 {
+		t.Fatalf("Resync(%x) = %d, %v; want 3", b, off, ok)
+	}
+	if _, ok := Resync(b[:len(b)-1]); ok {
+		t.Fatalf("Resync found a record cut off by the end of the buffer")
+	}
+	if _, ok := Resync(nil); ok {
+		t.Fatalf("Resync(nil) reported a record")
+	}
+}
+
+// scanSync decodes b as ParseSync and Resync are meant to be combined,
+// returning each decoded value keyed by its offset
+func scanSync(b []byte) map[int]uint64 {
+	got := map[int]uint64{}
+	for off := 0; off < len(b); {
+		v, n, err := ParseSync(b[off:])
+		if err == nil {
+			got[off] = v
+			off += n
+			continue
+		}
+		skip, ok := Resync(b[off+1:])
+		if !ok {
+			break
+		}
+		off += 1 + skip
+	}
+	return got
+}
+
+func TestResyncAfterCorruption(t *testing.T) {
+	rng := rand.New(rand.NewPCG(97, 0))
+	var b []byte
+	var starts []int
+	for _, v := range bulkCorpus(2000) {
+		starts = append(starts, len(b))
+		b = AppendSync(b, v)
+	}
+	starts = append(starts, len(b))
+	vals := bulkCorpus(2000)
+
+	for round := 0; round < 500; round++ {
+		lo := rng.IntN(len(b))
+		hi := min(len(b), lo+1+rng.IntN(64))
+		damaged := bytes.Clone(b)
+		for i := lo; i < hi; i++ {
+			damaged[i] = byte(rng.IntN(256))
+		}
+		if round%4 == 0 {
+			// Favour the bytes the format depends on
+			for i := lo; i < hi; i += 2 {
+				damaged[i] = syncEscape
+			}
+		}
+
+		got := scanSync(damaged)
+		for i, v := range vals {
+			start, end := starts[i], starts[i+1]
+			if end <= lo || start >= hi {
+				if g, ok := got[start]; !ok || g != v {
+					t.Fatalf("round %d, damage [%d,%d): intact record %d at %d decoded as %d, %v; want %d",
+						round, lo, hi, i, start, g, ok, v)
+				}
+			}
+		}
+		// Anything else decoded must start inside the damaged area or at a
+		// record that overlaps it
+		for off := range got {
+			if off >= lo && off < hi {
+				continue
+			}
+			j := 0
+			for j < len(vals) && starts[j+1] <= off {
+				j++
+			}
+			if starts[j] != off {
+				t.Fatalf("round %d, damage [%d,%d): record decoded at %d, outside the damage and off the record grid",
+					round, lo, hi, off)
+			}
+		}
+	}
+}
+
+func BenchmarkAppendSync(b *testing.B) {
+	vs := bulkCorpus(1024)
+	buf := make([]byte, 0, len(vs)*MaxSyncLen)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = buf[:0]
+		for _, v := range vs {
+			buf = AppendSync(buf, v)
+		}
+	}
+}
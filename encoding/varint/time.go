@@ -0,0 +1,58 @@
+package varint
+
+import (
+	"time"
+)
+
+// Durations are carried as zigzag-encoded nanosecond counts, limiting them to
+// [MinSigned, MaxSigned] nanoseconds, or about ±73 years.
+//
+// Times are carried as the zigzag-encoded number of nanoseconds since the Unix
+// epoch (1970-01-01T00:00:00Z), which covers MinTime to MaxTime, roughly
+// 1896-12-06 to 2043-01-25. The zero time.Time is encoded as MinSigned, so the
+// earliest representable instant is one nanosecond after it. Locations and
+// monotonic clock readings are not preserved; times decode in UTC
+var (
+	// MinTime is the earliest time AppendTime accepts, apart from the zero time
+	MinTime = time.Unix(0, MinSigned+1).UTC()
+
+	// MaxTime is the latest time AppendTime accepts
+	MaxTime = time.Unix(0, MaxSigned).UTC()
+)
+
+// AppendDuration encodes d and appends it to dst. It panics with a
+// *SignedRangeError if d is outside [MinSigned, MaxSigned] nanoseconds
+func AppendDuration(dst []byte, d time.Duration) []byte {
+	return AppendInt(dst, int64(d))
+}
+
+// ParseDuration reads a duration encoded by AppendDuration from b
+func ParseDuration(b []byte) (time.Duration, int, error) {
+	v, n, err := ParseInt64(b)
+	return time.Duration(v), n, err
+}
+
+// AppendTime encodes t and appends it to dst, returning ErrTimeRange and dst
+// unchanged if t is neither the zero time nor within [MinTime, MaxTime]
+func AppendTime(dst []byte, t time.Time) ([]byte, error) {
+	if t.IsZero() {
+		return AppendInt(dst, MinSigned), nil
+	}
+	if t.Before(MinTime) || t.After(MaxTime) {
+		return dst, ErrTimeRange
+	}
+	return AppendInt(dst, t.UnixNano()), nil
+}
+
+// ParseTime reads a time encoded by AppendTime from b. The result is in UTC,
+// except for the zero time which is returned as time.Time{}
+func ParseTime(b []byte) (time.Time, int, error) {
+	v, n, err := ParseInt64(b)
+	if err != nil {
+		return time.Time{}, n, err
+	}
+	if v == MinSigned {
+		return time.Time{}, n, nil
+	}
+	return time.Unix(0, v).UTC(), n, nil
+}
@@ -0,0 +1,97 @@
+package varint
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+// -------------------------
+// AppendDuration / ParseDuration
+// -------------------------
+
+func TestDurationRoundTrip(t *testing.T) {
+	durations := []time.Duration{
+		0, 1, -1, time.Second, -time.Hour, 500 * time.Millisecond,
+		time.Duration(MaxSigned), time.Duration(MinSigned),
+	}
+	for _, d := range durations {
+		b := AppendDuration(nil, d)
+		got, n, err := ParseDuration(b)
+		if err != nil || got != d || n != len(b) {
+			t.Fatalf("ParseDuration(AppendDuration(%v)) = %v, %d, %v", d, got, n, err)
+		}
+	}
+	if got := AppendDuration(nil, -time.Nanosecond); !bytes.Equal(got, []byte{0x01}) {
+		t.Fatalf("AppendDuration(-1ns) = %x, want 01", got)
+	}
+}
+
+func TestAppendDurationOutOfRange(t *testing.T) {
+	defer func() {
+		if _, ok := recover().(*SignedRangeError); !ok {
+			t.Fatal("AppendDuration(MaxInt64) did not panic with *SignedRangeError")
+		}
+	}()
+	AppendDuration(nil, math.MaxInt64)
+}
+
+// -------------------------
+// AppendTime / ParseTime
+// -------------------------
+
+func TestTimeRoundTrip(t *testing.T) {
+	times := []time.Time{
+		time.Unix(0, 0).UTC(),
+		time.Date(2026, 10, 16, 12, 30, 45, 123456789, time.UTC),
+		time.Date(1969, 12, 31, 23, 59, 59, 999999999, time.UTC),
+		MinTime,
+		MaxTime,
+	}
+	for _, tm := range times {
+		b, err := AppendTime(nil, tm)
+		if err != nil {
+			t.Fatalf("AppendTime(%v) error = %v", tm, err)
+		}
+		got, n, err := ParseTime(b)
+		if err != nil || !got.Equal(tm) || n != len(b) {
+			t.Fatalf("ParseTime(AppendTime(%v)) = %v, %d, %v", tm, got, n, err)
+		}
+		if got.Location() != time.UTC {
+			t.Fatalf("ParseTime returned location %v, want UTC", got.Location())
+		}
+	}
+	// Other locations decode to the same instant
+	tm := time.Date(2030, 1, 2, 3, 4, 5, 6, time.FixedZone("X", 3600))
+	b, _ := AppendTime(nil, tm)
+	if got, _, _ := ParseTime(b); !got.Equal(tm) {
+		t.Fatalf("ParseTime = %v, want %v", got, tm)
+	}
+}
+
+func TestTimeZero(t *testing.T) {
+	b, err := AppendTime(nil, time.Time{})
+	if err != nil {
+		t.Fatalf("AppendTime(zero) error = %v", err)
+	}
+	got, _, err := ParseTime(b)
+	if err != nil || !got.IsZero() {
+		t.Fatalf("ParseTime(AppendTime(zero)) = %v, %v", got, err)
+	}
+}
+
+func TestAppendTimeOutOfRange(t *testing.T) {
+	for _, tm := range []time.Time{
+		MinTime.Add(-time.Nanosecond),
+		MaxTime.Add(time.Nanosecond),
+		time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(1800, 1, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		dst := []byte{0xAA}
+		got, err := AppendTime(dst, tm)
+		if err != ErrTimeRange || !bytes.Equal(got, dst) {
+			t.Fatalf("AppendTime(%v) = %x, %v; want unchanged dst, ErrTimeRange", tm, got, err)
+		}
+	}
+}
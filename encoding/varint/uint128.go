@@ -0,0 +1,68 @@
+package varint
+
+import (
+	"encoding/binary"
+	"io"
+	"math/bits"
+)
+
+// A 128-bit value cannot be split into two varints without losing the top
+// bits of each half, so it is written as a varint byte count n, at most 16,
+// followed by the value's n low-order bytes big-endian. Leading zero bytes
+// are always dropped, making the encoding unique: zero is the single byte
+// 0x00 and all ones is 0x10 followed by sixteen 0xff bytes. The count costs
+// one byte on top of the significant bytes, so a value whose high word is
+// zero takes at most 9 bytes
+
+// MaxUint128Len is the longest encoding of a 128-bit value
+const MaxUint128Len = 1 + 16
+
+// Uint128Len returns the number of bytes AppendUint128 writes for hi, lo
+func Uint128Len(hi, lo uint64) int {
+	return 1 + significantBytes(hi, lo)
+}
+
+func significantBytes(hi, lo uint64) int {
+	if hi != 0 {
+		return 8 + (bits.Len64(hi)+7)/8
+	}
+	return (bits.Len64(lo) + 7) / 8
+}
+
+// AppendUint128 appends the 128-bit value hi<<64 | lo to dst. Every value is
+// representable, so it never fails
+func AppendUint128(dst []byte, hi, lo uint64) []byte {
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], hi)
+	binary.BigEndian.PutUint64(buf[8:], lo)
+	n := significantBytes(hi, lo)
+	dst = append(dst, byte(n))
+	return append(dst, buf[16-n:]...)
+}
+
+// ParseUint128 decodes a value written by AppendUint128 and returns its high
+// and low words and the bytes consumed. A count above 16 is an
+// *OutOfRangeError, a padded count or a leading zero byte is ErrNonCanonical,
+// and input ending early is io.ErrUnexpectedEOF. An empty b returns io.EOF
+func ParseUint128(b []byte) (hi, lo uint64, consumed int, err error) {
+	n, c, err := ParseCanonical(b)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if n > 16 {
+		return 0, 0, 0, &OutOfRangeError{Value: n, Limit: 16}
+	}
+	body := b[c:]
+	if uint64(len(body)) < n {
+		return 0, 0, 0, io.ErrUnexpectedEOF
+	}
+	body = body[:n]
+	if n > 0 && body[0] == 0 {
+		return 0, 0, 0, ErrNonCanonical
+	}
+	var buf [16]byte
+	copy(buf[16-n:], body)
+	hi = binary.BigEndian.Uint64(buf[:8])
+	lo = binary.BigEndian.Uint64(buf[8:])
+	return hi, lo, c + int(n), nil
+}
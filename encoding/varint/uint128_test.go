@@ -0,0 +1,104 @@
+package varint
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"testing"
+)
+
+// -------------------------
+// Uint128
+// -------------------------
+
+func TestUint128(t *testing.T) {
+	cases := []struct {
+		hi, lo uint64
+		want   []byte
+	}{
+		{0, 0, []byte{0}},
+		{0, 1, []byte{1, 1}},
+		{0, 0xff, []byte{1, 0xff}},
+		{0, 0x100, []byte{2, 1, 0}},
+		{0, math.MaxUint64, append([]byte{8}, bytes.Repeat([]byte{0xff}, 8)...)},
+		{1, 0, []byte{9, 1, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{Max, 0, append(append([]byte{16, 0x3f}, bytes.Repeat([]byte{0xff}, 7)...), make([]byte, 8)...)},
+		{Max + 1, 5, []byte{16, 0x40, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 5}},
+		{math.MaxUint64, math.MaxUint64, append([]byte{16}, bytes.Repeat([]byte{0xff}, 16)...)},
+	}
+	for _, c := range cases {
+		got := AppendUint128(nil, c.hi, c.lo)
+		if !bytes.Equal(got, c.want) || Uint128Len(c.hi, c.lo) != len(got) {
+			t.Fatalf("AppendUint128(%#x, %#x) = %x, want %x", c.hi, c.lo, got, c.want)
+		}
+		hi, lo, n, err := ParseUint128(append(got, 0xee))
+		if err != nil || hi != c.hi || lo != c.lo || n != len(got) {
+			t.Fatalf("ParseUint128(%x) = %#x, %#x, %d, %v", got, hi, lo, n, err)
+		}
+	}
+	if got := AppendUint128(nil, math.MaxUint64, math.MaxUint64); len(got) != MaxUint128Len {
+		t.Fatalf("all ones takes %d bytes, want MaxUint128Len %d", len(got), MaxUint128Len)
+	}
+}
+
+func TestUint128Exhaustive(t *testing.T) {
+	// Every bit position in either word, alone and with everything below it
+	for i := 0; i < 128; i++ {
+		var hi, lo, hiMask, loMask uint64
+		if i < 64 {
+			lo, loMask = 1<<i, 1<<i|(1<<i-1)
+		} else {
+			hi, hiMask, loMask = 1<<(i-64), 1<<(i-64)|(1<<(i-64)-1), math.MaxUint64
+		}
+		for _, v := range [][2]uint64{{hi, lo}, {hiMask, loMask}} {
+			b := AppendUint128(nil, v[0], v[1])
+			if len(b) != 1+i/8+1 {
+				t.Fatalf("bit %d: AppendUint128 = %x, want %d bytes", i, b, 1+i/8+1)
+			}
+			gh, gl, _, err := ParseUint128(b)
+			if err != nil || gh != v[0] || gl != v[1] {
+				t.Fatalf("bit %d: ParseUint128(%x) = %#x, %#x, %v", i, b, gh, gl, err)
+			}
+		}
+	}
+}
+
+func TestParseUint128Errors(t *testing.T) {
+	full := AppendUint128(nil, 0x1234, 0x5678)
+	for cut := 1; cut < len(full); cut++ {
+		if _, _, _, err := ParseUint128(full[:cut]); err != io.ErrUnexpectedEOF {
+			t.Fatalf("ParseUint128(%x) error = %v, want io.ErrUnexpectedEOF", full[:cut], err)
+		}
+	}
+	if _, _, _, err := ParseUint128(nil); err != io.EOF {
+		t.Fatalf("ParseUint128(nil) error = %v, want io.EOF", err)
+	}
+	cases := []struct {
+		in   []byte
+		want error
+	}{
+		{append([]byte{17}, make([]byte, 17)...), ErrOutOfRange},
+		{[]byte{2, 0, 5}, ErrNonCanonical},
+		{[]byte{1, 0}, ErrNonCanonical},
+		{[]byte{0x40, 1, 5}, ErrNonCanonical},
+	}
+	for _, c := range cases {
+		if _, _, _, err := ParseUint128(c.in); !errors.Is(err, c.want) {
+			t.Fatalf("ParseUint128(%x) error = %v, want %v", c.in, err, c.want)
+		}
+	}
+}
+
+func FuzzUint128(f *testing.F) {
+	f.Add(uint64(0), uint64(0))
+	f.Add(uint64(math.MaxUint64), uint64(math.MaxUint64))
+	f.Add(uint64(Max+1), uint64(1))
+	f.Fuzz(func(t *testing.T, hi, lo uint64) {
+		b := AppendUint128(nil, hi, lo)
+		gh, gl, n, err := ParseUint128(b)
+		if err != nil || gh != hi || gl != lo || n != len(b) {
+			t.Fatalf("round trip of %#x, %#x via %x = %#x, %#x, %d, %v", hi, lo, b, gh, gl, n, err)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package varint
+
+import (
+	"bufio"
+	"io"
+)
+
+// Limits bounds the work ValidateReader will do. Zero fields mean no limit
+type Limits struct {
+	// MaxValues is the largest number of varints accepted
+	MaxValues int64
+	// MaxBytes is the largest number of input bytes accepted
+	MaxBytes int64
+	// Canonical rejects values that are not minimally encoded
+	Canonical bool
+}
+
+// Stats summarizes a stream of varints
+type Stats struct {
+	Values int64
+	Bytes  int64
+	// Min and Max are the smallest and largest values seen, both zero for an
+	// empty stream
+	Min, Max uint64
+	// Widths counts values by encoded width: 1, 2, 4 and 8 bytes
+	Widths [4]int64
+}
+
+// add records a value of encoded width n
+func (s *Stats) add(v uint64, n int) {
+	if s.Values == 0 || v < s.Min {
+		s.Min = v
+	}
+	if v > s.Max {
+		s.Max = v
+	}
+	s.Values++
+	s.Bytes += int64(n)
+	s.Widths[widthIndex(n)]++
+}
+
+// widthIndex maps an encoded width of 1, 2, 4 or 8 to 0, 1, 2 or 3
+func widthIndex(n int) int {
+	switch n {
+	case 1:
+		return 0
+	case 2:
+		return 1
+	case 4:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// ValidateReader streams through r checking that it holds only complete
+// varints, minimally encoded if limits.Canonical is set, within the given
+// limits. Memory use does not depend on the size of the input. The first
+// violation is reported as a *StreamOffsetError carrying its absolute position
+// in the stream and wrapping io.ErrUnexpectedEOF, ErrNonCanonical or
+// ErrLimitExceeded; read errors from r are returned as they are. The returned
+// Stats cover the values accepted before any failure
+func ValidateReader(r io.Reader, limits Limits) (Stats, error) {
+	return validateFrom(r, limits, Stats{})
+}
+
+// validateFrom is ValidateReader continuing from s, as though s.Bytes bytes
+// had already been accepted
+func validateFrom(r io.Reader, limits Limits, s Stats) (Stats, error) {
+	br := bufio.NewReader(r)
+	for {
+		off := s.Bytes
+		v, n, err := ReadLen(br)
+		switch {
+		case err == io.EOF:
+			return s, nil
+		case err == io.ErrUnexpectedEOF:
+			return s, &StreamOffsetError{Offset: off, Err: err}
+		case err != nil:
+			return s, err
+		}
+		if limits.Canonical && n > 1 && Len(v) < n {
+			return s, &StreamOffsetError{Offset: off, Err: ErrNonCanonical}
+		}
+		if limits.MaxValues > 0 && s.Values == limits.MaxValues ||
+			limits.MaxBytes > 0 && s.Bytes+int64(n) > limits.MaxBytes {
+			return s, &StreamOffsetError{Offset: off, Err: ErrLimitExceeded}
+		}
+		s.add(v, n)
+	}
+}
@@ -0,0 +1,110 @@
+package varint
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+// -------------------------
+// ValidateReader
+// -------------------------
+
+func TestValidateReaderStats(t *testing.T) {
+	b := AppendMany(nil, testValues...)
+	s, err := ValidateReader(iotest.OneByteReader(bytes.NewReader(b)), Limits{Canonical: true})
+	if err != nil {
+		t.Fatalf("ValidateReader error = %v", err)
+	}
+	want := Stats{Values: 8, Bytes: int64(len(b)), Min: 0, Max: Max, Widths: [4]int64{2, 2, 2, 2}}
+	if s != want {
+		t.Fatalf("ValidateReader = %+v, want %+v", s, want)
+	}
+
+	s, err = ValidateReader(bytes.NewReader(AppendMany(nil, 500, 70, 9000)), Limits{})
+	if err != nil || s.Min != 70 || s.Max != 9000 {
+		t.Fatalf("ValidateReader = %+v, %v; want min 70, max 9000", s, err)
+	}
+	if s, err := ValidateReader(bytes.NewReader(nil), Limits{}); err != nil || s != (Stats{}) {
+		t.Fatalf("ValidateReader(empty) = %+v, %v", s, err)
+	}
+}
+
+func TestValidateReaderViolations(t *testing.T) {
+	b := AppendMany(nil, 1, 300, 5)
+	padded := append(Append(nil, 300), 0x40, 5)
+	cases := []struct {
+		name   string
+		buf    []byte
+		limits Limits
+		want   error
+		offset int64
+		values int64
+	}{
+		{"truncated", b[:len(b)-2], Limits{}, io.ErrUnexpectedEOF, 1, 1},
+		{"non-canonical", padded, Limits{Canonical: true}, ErrNonCanonical, 2, 1},
+		{"max values", b, Limits{MaxValues: 2}, ErrLimitExceeded, 3, 2},
+		{"max bytes", b, Limits{MaxBytes: 2}, ErrLimitExceeded, 1, 1},
+	}
+	for _, c := range cases {
+		s, err := ValidateReader(bytes.NewReader(c.buf), c.limits)
+		var offErr *StreamOffsetError
+		if !errors.Is(err, c.want) || !errors.As(err, &offErr) || offErr.Offset != c.offset {
+			t.Fatalf("%s: error = %v, want %v at byte %d", c.name, err, c.want, c.offset)
+		}
+		if s.Values != c.values {
+			t.Fatalf("%s: Values = %d, want %d", c.name, s.Values, c.values)
+		}
+	}
+	if s, err := ValidateReader(bytes.NewReader(padded), Limits{}); err != nil || s.Values != 2 {
+		t.Fatalf("ValidateReader(padded, lenient) = %+v, %v", s, err)
+	}
+}
+
+// endlessReader yields the same canonical varints forever
+type endlessReader struct {
+	chunk []byte
+	off   int
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		c := copy(p[n:], r.chunk[r.off:])
+		n += c
+		r.off = (r.off + c) % len(r.chunk)
+	}
+	return n, nil
+}
+
+func TestValidateReaderLargeInput(t *testing.T) {
+	// 64 MiB is streamed through a fixed-size buffer and stopped by the byte
+	// limit, which falls on a value boundary
+	r := &endlessReader{chunk: AppendMany(nil, testValues...)}
+	limit := int64(len(r.chunk)) << 22
+	s, err := ValidateReader(r, Limits{MaxBytes: limit})
+	var offErr *StreamOffsetError
+	if !errors.Is(err, ErrLimitExceeded) || !errors.As(err, &offErr) || offErr.Offset != limit {
+		t.Fatalf("ValidateReader error = %v, want ErrLimitExceeded at %d", err, limit)
+	}
+	if s.Bytes != limit || s.Values != 8<<22 {
+		t.Fatalf("ValidateReader = %+v", s)
+	}
+}
+
+func TestValidateReaderOffsetPast4GiB(t *testing.T) {
+	// Offsets are int64 even where int is 32 bits, so a failure deep into a
+	// huge stream is reported at its true position
+	const start = 5 << 30
+	b := append(Append(nil, 300), 0x40, 5)
+	s, err := validateFrom(bytes.NewReader(b), Limits{Canonical: true}, Stats{Bytes: start})
+	var offErr *StreamOffsetError
+	if !errors.Is(err, ErrNonCanonical) || !errors.As(err, &offErr) || offErr.Offset != start+2 {
+		t.Fatalf("error = %v, want ErrNonCanonical at byte %d", err, int64(start+2))
+	}
+	if s.Bytes != start+2 {
+		t.Fatalf("Bytes = %d, want %d", s.Bytes, int64(start+2))
+	}
+}
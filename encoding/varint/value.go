@@ -0,0 +1,81 @@
+package varint
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Value is a uint64 that marshals to and from the varint format, letting
+// varint fields take part in generic serialization machinery
+type Value uint64
+
+// AppendBinary implements encoding.BinaryAppender. It returns a
+// *ValueTooLargeError if v exceeds Max
+func (v Value) AppendBinary(b []byte) ([]byte, error) {
+	return AppendChecked(b, uint64(v))
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. It returns a
+// *ValueTooLargeError if v exceeds Max
+func (v Value) MarshalBinary() ([]byte, error) {
+	return v.AppendBinary(make([]byte, 0, MaxLen))
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The data must hold
+// exactly one varint; trailing bytes are rejected with ErrTrailingBytes
+func (v *Value) UnmarshalBinary(data []byte) error {
+	u, err := ParseExact(data)
+	if err != nil {
+		return err
+	}
+	*v = Value(u)
+	return nil
+}
+
+// AppendText implements encoding.TextAppender, appending v in decimal. It
+// returns a *ValueTooLargeError if v exceeds Max
+func (v Value) AppendText(b []byte) ([]byte, error) {
+	if uint64(v) > Max {
+		return b, &ValueTooLargeError{Num: uint64(v)}
+	}
+	return strconv.AppendUint(b, uint64(v), 10), nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (v Value) MarshalText() ([]byte, error) {
+	return v.AppendText(nil)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. The text must be a
+// decimal integer in [0, Max]
+func (v *Value) UnmarshalText(text []byte) error {
+	u, err := strconv.ParseUint(string(text), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid varint value %q: want a decimal integer in [0, %d]: %w", text, Max, err)
+	}
+	if u > Max {
+		return &ValueTooLargeError{Num: u}
+	}
+	*v = Value(u)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. The value is written as a JSON
+// number; its decimal digits are exact even above 2^53, but consumers that
+// decode JSON numbers into float64 will lose precision
+func (v Value) MarshalJSON() ([]byte, error) {
+	return v.AppendText(nil)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting either a JSON number
+// or a string holding the decimal value. Numbers are parsed from their
+// literal text, so values above 2^53 are exact. A JSON null leaves v as is
+func (v *Value) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) >= 2 && data[0] == '"' && data[len(data)-1] == '"' {
+		data = data[1 : len(data)-1]
+	}
+	return v.UnmarshalText(data)
+}
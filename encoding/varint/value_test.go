@@ -0,0 +1,156 @@
+package varint
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = Value(0)
+	_ encoding.BinaryAppender    = Value(0)
+	_ encoding.BinaryUnmarshaler = (*Value)(nil)
+	_ encoding.TextAppender      = Value(0)
+	_ encoding.TextMarshaler     = Value(0)
+	_ encoding.TextUnmarshaler   = (*Value)(nil)
+	_ json.Marshaler             = Value(0)
+	_ json.Unmarshaler           = (*Value)(nil)
+)
+
+// -------------------------
+// Value binary marshalling
+// -------------------------
+
+func TestValueBinary(t *testing.T) {
+	for _, v := range testValues {
+		b, err := Value(v).MarshalBinary()
+		if err != nil || !bytes.Equal(b, Append(nil, v)) {
+			t.Fatalf("MarshalBinary(%d) = %x, %v", v, b, err)
+		}
+		var got Value
+		if err := got.UnmarshalBinary(b); err != nil || uint64(got) != v {
+			t.Fatalf("UnmarshalBinary(%x) = %d, %v", b, got, err)
+		}
+		b, err = Value(v).AppendBinary([]byte{0xAA})
+		if err != nil || !bytes.Equal(b, Append([]byte{0xAA}, v)) {
+			t.Fatalf("AppendBinary(%d) = %x, %v", v, b, err)
+		}
+	}
+}
+
+func TestValueBinaryErrors(t *testing.T) {
+	if _, err := Value(Max + 1).MarshalBinary(); !errors.Is(err, ErrValueTooLarge) {
+		t.Fatalf("MarshalBinary(Max+1) error = %v, want ErrValueTooLarge", err)
+	}
+	v := Value(9)
+	cases := []struct {
+		in   []byte
+		want error
+	}{
+		{[]byte{0x05, 0x00}, ErrTrailingBytes},
+		{[]byte{0x40}, io.ErrUnexpectedEOF},
+		{nil, io.EOF},
+	}
+	for _, c := range cases {
+		if err := v.UnmarshalBinary(c.in); err != c.want {
+			t.Fatalf("UnmarshalBinary(%x) error = %v, want %v", c.in, err, c.want)
+		}
+		if v != 9 {
+			t.Fatalf("failed UnmarshalBinary(%x) modified the value", c.in)
+		}
+	}
+}
+
+func TestValueGob(t *testing.T) {
+	type record struct {
+		ID     Value
+		Offset Value
+	}
+	in := record{ID: 42, Offset: Value(Max)}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatalf("gob Encode error = %v", err)
+	}
+	var out record
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatalf("gob Decode error = %v", err)
+	}
+	if out != in {
+		t.Fatalf("gob round trip = %+v, want %+v", out, in)
+	}
+	if err := gob.NewEncoder(io.Discard).Encode(record{ID: Value(Max + 1)}); !errors.Is(err, ErrValueTooLarge) {
+		t.Fatalf("gob Encode(Max+1) error = %v, want ErrValueTooLarge", err)
+	}
+}
+
+// -------------------------
+// Value text and JSON marshalling
+// -------------------------
+
+func TestValueText(t *testing.T) {
+	for _, v := range testValues {
+		text, err := Value(v).MarshalText()
+		if err != nil || string(text) != strconv.FormatUint(v, 10) {
+			t.Fatalf("MarshalText(%d) = %s, %v", v, text, err)
+		}
+		var got Value
+		if err := got.UnmarshalText(text); err != nil || uint64(got) != v {
+			t.Fatalf("UnmarshalText(%s) = %d, %v", text, got, err)
+		}
+	}
+	if _, err := Value(Max + 1).MarshalText(); !errors.Is(err, ErrValueTooLarge) {
+		t.Fatalf("MarshalText(Max+1) error = %v, want ErrValueTooLarge", err)
+	}
+}
+
+func TestValueTextErrors(t *testing.T) {
+	inputs := []string{"", "-1", "1.5", "0x10", " 1", "4611686018427387904", "18446744073709551616"}
+	for _, in := range inputs {
+		var v Value
+		err := v.UnmarshalText([]byte(in))
+		if err == nil {
+			t.Fatalf("UnmarshalText(%q) succeeded with %d", in, v)
+		}
+		if !strings.Contains(err.Error(), "62 bits") && !strings.Contains(err.Error(), strconv.FormatUint(Max, 10)) {
+			t.Fatalf("UnmarshalText(%q) error %q does not name the limit", in, err)
+		}
+	}
+}
+
+func TestValueJSON(t *testing.T) {
+	type frame struct {
+		Type   Value  `json:"type"`
+		Length Value  `json:"length"`
+		Opt    *Value `json:"opt"`
+	}
+	in := frame{Type: 1, Length: Value(Max)}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("json.Marshal error = %v", err)
+	}
+	if want := `{"type":1,"length":4611686018427387903,"opt":null}`; string(data) != want {
+		t.Fatalf("json.Marshal = %s, want %s", data, want)
+	}
+	var out frame
+	if err := json.Unmarshal(data, &out); err != nil || out != in {
+		t.Fatalf("json.Unmarshal = %+v, %v; want %+v", out, err, in)
+	}
+	// Values above 2^53 survive exactly, in number or string form
+	if err := json.Unmarshal([]byte(`{"type":"9007199254740993","length":9007199254740993}`), &out); err != nil {
+		t.Fatalf("json.Unmarshal error = %v", err)
+	}
+	if out.Type != 9007199254740993 || out.Length != 9007199254740993 {
+		t.Fatalf("json.Unmarshal = %+v, want exact 2^53+1", out)
+	}
+	for _, bad := range []string{`{"type":-1}`, `{"type":4611686018427387904}`, `{"type":1e3}`, `{"type":true}`} {
+		if err := json.Unmarshal([]byte(bad), &out); err == nil {
+			t.Fatalf("json.Unmarshal(%s) succeeded", bad)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package varint
 
 import (
-	"fmt"
+	"encoding/binary"
 	"io"
+	"math/bits"
+	"slices"
 )
 
 const (
@@ -11,6 +13,9 @@ const (
 
 	// Max is the maximum allowed value for a varint encoding (2^62 - 1)
 	Max uint64 = 0x3FFFFFFFFFFFFFFF
+
+	// MaxLen is the maximum number of bytes a varint encoding occupies
+	MaxLen = 8
 )
 
 // Internal maximums for each encoding length
@@ -21,70 +26,218 @@ const (
 	_maxVarInt8 = Max        // <=> 2^62-1 <=> 4611686018427387903
 )
 
-type varintLengthError struct {
-	Num uint64
+// lenByBits maps the bit length of a value, 0 through 62, to its encoded
+// length: up to 6 bits take 1 byte, 14 bits 2, 30 bits 4 and 62 bits 8
+const lenByBits = "\x01\x01\x01\x01\x01\x01\x01" +
+	"\x02\x02\x02\x02\x02\x02\x02\x02" +
+	"\x04\x04\x04\x04\x04\x04\x04\x04\x04\x04\x04\x04\x04\x04\x04\x04" +
+	"\x08\x08\x08\x08\x08\x08\x08\x08\x08\x08\x08\x08\x08\x08\x08\x08" +
+	"\x08\x08\x08\x08\x08\x08\x08\x08\x08\x08\x08\x08\x08\x08\x08\x08"
+
+// Len returns the number of bytes needed to encode v as a varint.
+// It panics with a *ValueTooLargeError if v exceeds Max
+func Len(v uint64) int {
+	if v > Max {
+		panic(&ValueTooLargeError{Num: v})
+	}
+	return int(lenByBits[bits.Len64(v)])
 }
 
-func (e *varintLengthError) Error() string {
-	return fmt.Sprintf("value too big to fit in 62 bits: %d", e.Num)
+// Fits reports whether v can be encoded as a varint, i.e. v <= Max
+func Fits(v uint64) bool {
+	return v <= Max
 }
 
-// Len returns the number of bytes needed to encode v as a varint
-func Len(v uint64) int {
-	switch {
-	case v>>6 == 0:
-		return 1
-	case v>>14 == 0:
-		return 2
-	case v>>30 == 0:
-		return 4
-	case v>>62 == 0:
-		return 8
-	default:
-		panic(&varintLengthError{Num: v})
+// Clamp returns v saturated at Max. Clamping is lossy: every value above Max
+// becomes Max, so it suits metrics-style fields but must never be used for
+// lengths, offsets or identifiers
+func Clamp(v uint64) uint64 {
+	return min(v, Max)
+}
+
+// LenChecked is like Len but returns an error matching ErrValueTooLarge
+// instead of panicking when v exceeds Max
+func LenChecked(v uint64) (int, error) {
+	if v > Max {
+		return 0, &ValueTooLargeError{Num: v}
 	}
+	return Len(v), nil
 }
 
-// Append encodes v and appends it to dst, returning the new slice
+// Append encodes v and appends it to dst, returning the new slice.
+// It panics with a *ValueTooLargeError if v exceeds Max
 func Append(dst []byte, v uint64) []byte {
+	// Wider values are laid out left-aligned in a word with the length prefix
+	// in the top two bits, then appended as one subslice. This is what Encode
+	// does, but appending from Encode measured 20-50% slower across widths,
+	// so each case keeps its constant shift
 	switch {
 	case v <= _maxVarInt1:
 		return append(dst, byte(v))
 	case v <= _maxVarInt2:
-		return append(dst,
-			byte((v>>8)&0x3F)|0x40,
-			byte(v),
-		)
+		var buf [MaxLen]byte
+		binary.BigEndian.PutUint64(buf[:], v<<48|0x40<<56)
+		return append(dst, buf[:2]...)
 	case v <= _maxVarInt4:
-		return append(dst,
-			byte((v>>24)&0x3F)|0x80,
-			byte(v>>16),
-			byte(v>>8),
-			byte(v),
-		)
+		var buf [MaxLen]byte
+		binary.BigEndian.PutUint64(buf[:], v<<32|0x80<<56)
+		return append(dst, buf[:4]...)
 	case v <= _maxVarInt8:
-		return append(dst,
-			byte((v>>56)&0x3F)|0xC0,
-			byte(v>>48),
-			byte(v>>40),
-			byte(v>>32),
-			byte(v>>24),
-			byte(v>>16),
-			byte(v>>8),
-			byte(v),
-		)
+		var buf [MaxLen]byte
+		binary.BigEndian.PutUint64(buf[:], v|0xC0<<56)
+		return append(dst, buf[:]...)
+	default:
+		panic(&ValueTooLargeError{Num: v})
+	}
+}
+
+// MustAppend is Append under a name that makes the panic explicit at the call
+// site: it panics with a *ValueTooLargeError if v exceeds Max. Use it when the
+// caller guarantees the range, and AppendChecked otherwise
+func MustAppend(dst []byte, v uint64) []byte {
+	return Append(dst, v)
+}
+
+// AppendChecked is like Append but returns an error matching ErrValueTooLarge
+// instead of panicking when v exceeds Max; dst is returned unchanged in that case
+func AppendChecked(dst []byte, v uint64) ([]byte, error) {
+	if v > Max {
+		return dst, &ValueTooLargeError{Num: v}
+	}
+	return Append(dst, v), nil
+}
+
+// Put encodes v into the beginning of dst and returns the number of bytes written.
+// It panics with io.ErrShortBuffer if dst is shorter than Len(v), leaving dst
+// untouched, and with a *ValueTooLargeError if v exceeds Max
+func Put(dst []byte, v uint64) int {
+	n := Len(v)
+	if len(dst) < n {
+		panic(io.ErrShortBuffer)
+	}
+	putN(dst, v, n)
+	return n
+}
+
+// putN writes v into dst using exactly n bytes. The caller guarantees that n is
+// a valid length, that v fits in it and that dst is long enough.
+//
+// Put goes through putN rather than Encode on purpose. Encode builds all
+// MaxLen bytes and Put may only write n of them, so the copy out is a
+// variable-length one; that, and Put no longer inlining, takes it from
+// about 1ns to 4-5ns per value
+func putN(dst []byte, v uint64, n int) {
+	switch n {
+	case 1:
+		dst[0] = byte(v)
+	case 2:
+		binary.BigEndian.PutUint16(dst, uint16(v)|0x4000)
+	case 4:
+		binary.BigEndian.PutUint32(dst, uint32(v)|0x80000000)
+	default:
+		binary.BigEndian.PutUint64(dst, v|0xC0<<56)
+	}
+}
+
+// maxForLen returns the largest value that can be encoded in n bytes, or
+// false if n is not a valid varint length
+func maxForLen(n int) (uint64, bool) {
+	switch n {
+	case 1:
+		return _maxVarInt1, true
+	case 2:
+		return _maxVarInt2, true
+	case 4:
+		return _maxVarInt4, true
+	case 8:
+		return _maxVarInt8, true
 	default:
-		panic(&varintLengthError{Num: v})
+		return 0, false
 	}
 }
 
+// AppendWithLen encodes v using exactly n bytes, where n is 1, 2, 4 or 8, and
+// appends it to dst. Lengths longer than Len(v) produce non-minimal encodings,
+// e.g. for reserving space for a field that is backfilled later. It returns
+// ErrInvalidLength for any other n and ErrLengthTooShort if v does not fit
+func AppendWithLen(dst []byte, v uint64, n int) ([]byte, error) {
+	max, ok := maxForLen(n)
+	if !ok {
+		return dst, ErrInvalidLength
+	}
+	if v > max {
+		return dst, ErrLengthTooShort
+	}
+	off := len(dst)
+	dst = slices.Grow(dst, n)[:off+n]
+	putN(dst[off:], v, n)
+	return dst, nil
+}
+
+// PutWithLen is like AppendWithLen but writes the encoding into the beginning
+// of dst, returning io.ErrShortBuffer if dst is shorter than n
+func PutWithLen(dst []byte, v uint64, n int) (int, error) {
+	max, ok := maxForLen(n)
+	if !ok {
+		return 0, ErrInvalidLength
+	}
+	if v > max {
+		return 0, ErrLengthTooShort
+	}
+	if len(dst) < n {
+		return 0, io.ErrShortBuffer
+	}
+	putN(dst, v, n)
+	return n, nil
+}
+
+// EncodedLen returns the total encoded length (1, 2, 4 or 8) of a varint
+// whose first byte is first
+func EncodedLen(first byte) int {
+	return 1 << (first >> 6)
+}
+
+// PeekEncodedLen returns the encoded length of the varint at the start of b,
+// which only requires its first byte to be present. It returns io.EOF if b is
+// empty
+func PeekEncodedLen(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, io.EOF
+	}
+	return EncodedLen(b[0]), nil
+}
+
+// Encode encodes v into an array returned by value and reports how many of its
+// bytes are significant, so callers can use buf[:n] without touching the heap.
+// WriteTo, WriteN and EncodeFixed are built on it. It panics with a
+// *ValueTooLargeError if v exceeds Max
+func Encode(v uint64) (buf [MaxLen]byte, n int) {
+	n = Len(v)
+	// Left-align the value in a word with the length code, log2(n), on top
+	w := v<<(64-8*n) | uint64(bits.TrailingZeros(uint(n)))<<62
+	binary.BigEndian.PutUint64(buf[:], w)
+	return buf, n
+}
+
+// EncodeFixed is Encode under its original name
+func EncodeFixed(v uint64) (buf [MaxLen]byte, n int) {
+	return Encode(v)
+}
+
 // Parse reads a varint from b and returns value, bytes consumed, and error
 func Parse(b []byte) (value uint64, consumed int, err error) {
+	if len(b) >= MaxLen {
+		// Load 8 bytes at once, clear the length bits and shift out the
+		// bytes past the end of the value
+		w := binary.BigEndian.Uint64(b)
+		length := 1 << (w >> 62)
+		return (w & Max) >> (64 - 8*length), length, nil
+	}
 	if len(b) == 0 {
 		return 0, 0, io.EOF
 	}
 	first := b[0]
-	length := 1 << (first >> 6)
+	length := EncodedLen(first)
 	if len(b) < length {
 		return 0, 0, io.ErrUnexpectedEOF
 	}
@@ -96,13 +249,63 @@ func Parse(b []byte) (value uint64, consumed int, err error) {
 	return value, length, nil
 }
 
+// ParseCanonical is like Parse but rejects encodings that are longer than
+// necessary with ErrNonCanonical, so every value has exactly one accepted
+// byte representation. Append always produces canonical encodings
+func ParseCanonical(b []byte) (uint64, int, error) {
+	v, n, err := Parse(b)
+	if err != nil {
+		return 0, 0, err
+	}
+	if n > 1 && Len(v) < n {
+		return 0, 0, ErrNonCanonical
+	}
+	return v, n, nil
+}
+
+// ParseExact decodes a varint that must occupy all of b, as for a field whose
+// extent is fixed by an enclosing length. It returns ErrTrailingBytes if the
+// varint ends before b does, and io.EOF for an empty b
+func ParseExact(b []byte) (uint64, error) {
+	v, n, err := Parse(b)
+	if err != nil {
+		return 0, err
+	}
+	if n != len(b) {
+		return 0, ErrTrailingBytes
+	}
+	return v, nil
+}
+
+// ParseAt reads the varint starting at b[off] and returns its value and the
+// absolute offset just past it. Failures, including an off outside of b, are
+// reported as an *OffsetError carrying off
+func ParseAt(b []byte, off int) (v uint64, next int, err error) {
+	if off < 0 || off > len(b) {
+		return 0, off, &OffsetError{Offset: off, Err: ErrInvalidOffset}
+	}
+	v, n, err := Parse(b[off:])
+	if err != nil {
+		return 0, off, &OffsetError{Offset: off, Err: err}
+	}
+	return v, off + n, nil
+}
+
 // Peek reads a varint from b without consuming bytes
 func Peek(b []byte) (uint64, error) {
 	v, _, err := Parse(b)
 	return v, err
 }
 
-// Read reads a varint from r (io.ByteReader)
+// PeekLen reads a varint from b without consuming bytes and also reports its
+// encoded length, which may exceed Len(value) for non-minimal encodings
+func PeekLen(b []byte) (value uint64, length int, err error) {
+	return Parse(b)
+}
+
+// Read reads a varint from r (io.ByteReader). It returns io.EOF only if r
+// ends before the first byte and io.ErrUnexpectedEOF if it ends partway
+// through a value, so a truncated value is never mistaken for a clean end
 func Read(r io.ByteReader) (uint64, error) {
 	b0, err := r.ReadByte()
 	if err != nil {
@@ -115,21 +318,21 @@ func Read(r io.ByteReader) (uint64, error) {
 	case 1: // 2 bytes
 		b1, err := r.ReadByte()
 		if err != nil {
-			return 0, err
+			return 0, unexpectedEOF(err)
 		}
 		return uint64(b0&0x3F)<<8 | uint64(b1), nil
 	case 2: // 4 bytes
 		b1, err := r.ReadByte()
 		if err != nil {
-			return 0, err
+			return 0, unexpectedEOF(err)
 		}
 		b2, err := r.ReadByte()
 		if err != nil {
-			return 0, err
+			return 0, unexpectedEOF(err)
 		}
 		b3, err := r.ReadByte()
 		if err != nil {
-			return 0, err
+			return 0, unexpectedEOF(err)
 		}
 		return (uint64(b0&0x3F) << 24) |
 			(uint64(b1) << 16) |
@@ -138,31 +341,31 @@ func Read(r io.ByteReader) (uint64, error) {
 	case 3: // 8 bytes
 		b1, err := r.ReadByte()
 		if err != nil {
-			return 0, err
+			return 0, unexpectedEOF(err)
 		}
 		b2, err := r.ReadByte()
 		if err != nil {
-			return 0, err
+			return 0, unexpectedEOF(err)
 		}
 		b3, err := r.ReadByte()
 		if err != nil {
-			return 0, err
+			return 0, unexpectedEOF(err)
 		}
 		b4, err := r.ReadByte()
 		if err != nil {
-			return 0, err
+			return 0, unexpectedEOF(err)
 		}
 		b5, err := r.ReadByte()
 		if err != nil {
-			return 0, err
+			return 0, unexpectedEOF(err)
 		}
 		b6, err := r.ReadByte()
 		if err != nil {
-			return 0, err
+			return 0, unexpectedEOF(err)
 		}
 		b7, err := r.ReadByte()
 		if err != nil {
-			return 0, err
+			return 0, unexpectedEOF(err)
 		}
 		return (uint64(b0&0x3F) << 56) |
 			(uint64(b1) << 48) |
@@ -177,51 +380,77 @@ func Read(r io.ByteReader) (uint64, error) {
 	}
 }
 
-// Write encodes v and writes it to w (io.ByteWriter)
-func Write(w io.ByteWriter, v uint64) error {
-	switch {
-	case v <= _maxVarInt1:
-		return w.WriteByte(byte(v))
-	case v <= _maxVarInt2:
-		if err := w.WriteByte(byte((v>>8)&0x3F) | 0x40); err != nil {
-			return err
-		}
-		return w.WriteByte(byte(v))
-	case v <= _maxVarInt4:
-		if err := w.WriteByte(byte((v>>24)&0x3F) | 0x80); err != nil {
-			return err
-		}
-		if err := w.WriteByte(byte(v >> 16)); err != nil {
-			return err
-		}
-		if err := w.WriteByte(byte(v >> 8)); err != nil {
-			return err
-		}
-		return w.WriteByte(byte(v))
-	case v <= _maxVarInt8:
-		if err := w.WriteByte(byte((v>>56)&0x3F) | 0xC0); err != nil {
-			return err
-		}
-		if err := w.WriteByte(byte(v >> 48)); err != nil {
-			return err
-		}
-		if err := w.WriteByte(byte(v >> 40)); err != nil {
-			return err
-		}
-		if err := w.WriteByte(byte(v >> 32)); err != nil {
-			return err
-		}
-		if err := w.WriteByte(byte(v >> 24)); err != nil {
-			return err
-		}
-		if err := w.WriteByte(byte(v >> 16)); err != nil {
-			return err
+// ReadLen reads a varint from r (io.ByteReader) and reports the number of bytes
+// consumed. If a read fails partway through a multi-byte value, n is the number
+// of bytes taken from r before the failure and the stream is desynchronized;
+// io.EOF at that point is reported as io.ErrUnexpectedEOF
+func ReadLen(r io.ByteReader) (v uint64, n int, err error) {
+	b0, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	length := EncodedLen(b0)
+	v = uint64(b0 & 0x3F)
+	for n = 1; n < length; n++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, n, unexpectedEOF(err)
 		}
-		if err := w.WriteByte(byte(v >> 8)); err != nil {
-			return err
+		v = (v << 8) | uint64(b)
+	}
+	return v, n, nil
+}
+
+// ReadFrom reads a varint from a plain io.Reader, returning the value and the
+// number of bytes consumed. It returns io.EOF only if no bytes were read and
+// io.ErrUnexpectedEOF if the stream ends partway through a value
+func ReadFrom(r io.Reader) (v uint64, n int, err error) {
+	var buf [MaxLen]byte
+	if n, err = io.ReadFull(r, buf[:1]); err != nil {
+		return 0, n, err
+	}
+	length := EncodedLen(buf[0])
+	if length == 1 {
+		return uint64(buf[0]), 1, nil
+	}
+	if m, err := io.ReadFull(r, buf[1:length]); err != nil {
+		return 0, 1 + m, unexpectedEOF(err)
+	}
+	// The bytes past the value are still zero, so one load decodes it
+	w := binary.BigEndian.Uint64(buf[:])
+	return (w & Max) >> (64 - 8*length), length, nil
+}
+
+// Write encodes v and writes it to w (io.ByteWriter). Unlike Append it returns
+// a *ValueTooLargeError instead of panicking when v exceeds Max
+func Write(w io.ByteWriter, v uint64) error {
+	_, err := WriteN(w, v)
+	return err
+}
+
+// WriteN is like Write but also reports the number of bytes written. If it
+// fails with 0 < n < Len(v), a partial value is on the stream and the stream
+// should be abandoned, since the reader cannot resynchronize
+func WriteN(w io.ByteWriter, v uint64) (n int, err error) {
+	if v > Max {
+		return 0, &ValueTooLargeError{Num: v}
+	}
+	buf, l := Encode(v)
+	for _, c := range buf[:l] {
+		if err := w.WriteByte(c); err != nil {
+			return n, err
 		}
-		return w.WriteByte(byte(v))
-	default:
-		panic(&varintLengthError{Num: v})
+		n++
+	}
+	return n, nil
+}
+
+// WriteTo encodes v and writes it to w with a single Write call, returning the
+// number of bytes written. It returns a *ValueTooLargeError if v exceeds Max
+func WriteTo(w io.Writer, v uint64) (int, error) {
+	if v > Max {
+		return 0, &ValueTooLargeError{Num: v}
 	}
+	buf, n := Encode(v)
+	return w.Write(buf[:n])
 }
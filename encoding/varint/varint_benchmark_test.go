@@ -1,8 +1,16 @@
 package varint
 
 import (
+	"bufio"
 	"bytes"
+	"io"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strconv"
 	"testing"
+
+	"flux/encoding/internal/testcorpus"
 )
 
 // go test -bench=. -run=^$
@@ -40,6 +48,36 @@ func BenchmarkLen(b *testing.B) {
 	}
 }
 
+// skewedValues returns n values where each successive width is ten times less
+// likely than the one before, as in typical IDs, lengths and counters
+func skewedValues(n int) []uint64 {
+	return testcorpus.Skewed(n)
+}
+
+// uniformValues returns n values drawn uniformly from [0, Max], nearly all of
+// which take 8 bytes
+func uniformValues(n int) []uint64 {
+	return testcorpus.Uniform(n)
+}
+
+func BenchmarkLenDistribution(b *testing.B) {
+	for _, c := range []struct {
+		name string
+		vs   []uint64
+	}{{"skewed", skewedValues(4096)}, {"uniform", uniformValues(4096)}} {
+		vs := c.vs
+		b.Run(c.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				n := 0
+				for _, v := range vs {
+					n += Len(v)
+				}
+				sinkInt = n
+			}
+		})
+	}
+}
+
 // -------------------------
 // Append
 // -------------------------
@@ -59,25 +97,276 @@ func BenchmarkAppend(b *testing.B) {
 }
 
 // -------------------------
-// Parse
+// AppendMany
 // -------------------------
 
-func BenchmarkParse(b *testing.B) {
+func BenchmarkAppendMany(b *testing.B) {
+	vs := make([]uint64, 0, 256)
+	for len(vs) < cap(vs) {
+		vs = append(vs, testValues...)
+	}
+	b.Run("AppendMany", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sinkInt = len(AppendMany(nil, vs...))
+		}
+	})
+	b.Run("AppendLoop", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var dst []byte
+			for _, v := range vs {
+				dst = Append(dst, v)
+			}
+			sinkInt = len(dst)
+		}
+	})
+}
+
+// -------------------------
+// Count
+// -------------------------
+
+// corpus returns about size bytes of back-to-back varints cycling through
+// testValues
+func corpus(size int) []byte {
+	var buf []byte
+	for len(buf) < size {
+		buf = AppendMany(buf, testValues...)
+	}
+	return buf
+}
+
+func BenchmarkCount(b *testing.B) {
+	buf := corpus(1 << 20)
+	b.Run("Count", func(b *testing.B) {
+		b.SetBytes(int64(len(buf)))
+		for i := 0; i < b.N; i++ {
+			var err error
+			sinkInt, err = Count(buf)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("ParseAllInto", func(b *testing.B) {
+		b.SetBytes(int64(len(buf)))
+		dst := make([]uint64, 0, len(buf))
+		for i := 0; i < b.N; i++ {
+			var err error
+			dst, err = ParseAllInto(dst[:0], buf)
+			if err != nil {
+				b.Fatal(err)
+			}
+			sinkInt = len(dst)
+		}
+	})
+}
+
+// BenchmarkCountSkip runs Count and Skip over buffers of only 1-byte values,
+// where every byte is a header, and of only 8-byte values
+func BenchmarkCountSkip(b *testing.B) {
+	for _, c := range []struct {
+		name string
+		v    uint64
+	}{{"1byte", 5}, {"8byte", Max}} {
+		buf := AppendMany(nil, slices.Repeat([]uint64{c.v}, (1<<20)/Len(c.v))...)
+		n := len(buf) / Len(c.v)
+		b.Run(c.name+"/Count", func(b *testing.B) {
+			b.SetBytes(int64(len(buf)))
+			for i := 0; i < b.N; i++ {
+				sinkInt, _ = Count(buf)
+			}
+		})
+		b.Run(c.name+"/Skip", func(b *testing.B) {
+			b.SetBytes(int64(len(buf)))
+			for i := 0; i < b.N; i++ {
+				sinkInt, _ = Skip(buf, n)
+			}
+		})
+	}
+}
+
+func BenchmarkDecodeInto(b *testing.B) {
+	buf := corpus(1 << 20)
+	n, _ := Count(buf)
+	b.Run("reuse", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(buf)))
+		dst := make([]uint64, 0, n)
+		for i := 0; i < b.N; i++ {
+			dst, _ = DecodeInto(dst[:0], buf)
+			sinkInt = len(dst)
+		}
+	})
+	b.Run("nil/ParseAllInto", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(buf)))
+		for i := 0; i < b.N; i++ {
+			dst, _ := ParseAllInto(nil, buf)
+			sinkInt = len(dst)
+		}
+	})
+	b.Run("nil/DecodeInto", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(buf)))
+		for i := 0; i < b.N; i++ {
+			dst, _ := DecodeInto(nil, buf)
+			sinkInt = len(dst)
+		}
+	})
+	b.Run("DecodeUint32Into", func(b *testing.B) {
+		small := AppendMany(nil, slices.Repeat([]uint64{63, 16383, 1073741823}, 1<<16)...)
+		b.ReportAllocs()
+		b.SetBytes(int64(len(small)))
+		dst := make([]uint32, 0, 3<<16)
+		for i := 0; i < b.N; i++ {
+			dst, _ = DecodeUint32Into(dst[:0], small)
+			sinkInt = len(dst)
+		}
+	})
+}
+
+// -------------------------
+// AppendDeltas
+// -------------------------
+
+// BenchmarkAppendDeltas encodes ascending IDs with small random gaps, as found
+// in sorted ID lists, and reports the encoded size against plain varints
+func BenchmarkAppendDeltas(b *testing.B) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	ids := make([]uint64, 10000)
+	id := uint64(1 << 40)
+	for i := range ids {
+		id += 1 + rng.Uint64N(50)
+		ids[i] = id
+	}
+	plain := len(AppendMany(nil, ids...))
+	b.Run("AppendDeltas", func(b *testing.B) {
+		b.ReportAllocs()
+		dst := make([]byte, 0, plain)
+		for i := 0; i < b.N; i++ {
+			var err error
+			dst, err = AppendDeltas(dst[:0], ids)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(len(dst)), "bytes")
+		b.ReportMetric(float64(plain), "plain-bytes")
+	})
+	b.Run("AppendMany", func(b *testing.B) {
+		b.ReportAllocs()
+		dst := make([]byte, 0, plain)
+		for i := 0; i < b.N; i++ {
+			dst = AppendMany(dst[:0], ids...)
+		}
+		b.ReportMetric(float64(len(dst)), "bytes")
+	})
+}
+
+// -------------------------
+// Put
+// -------------------------
+
+func BenchmarkPut(b *testing.B) {
 	for _, v := range testValues {
-		buf := Append(nil, v)
 		b.Run("v="+itoa(v), func(b *testing.B) {
 			b.ReportAllocs()
+			dst := make([]byte, Len(v))
 			for i := 0; i < b.N; i++ {
-				var err error
-				sinkU64, _, err = Parse(buf)
-				if err != nil {
-					b.Fatal(err)
-				}
+				sinkInt = Put(dst, v)
 			}
 		})
 	}
 }
 
+// -------------------------
+// EncodeFixed
+// -------------------------
+
+func BenchmarkEncodeFixed(b *testing.B) {
+	for _, v := range testValues {
+		b.Run("v="+itoa(v), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				buf, n := EncodeFixed(v)
+				sinkInt = n + int(buf[0])
+			}
+		})
+	}
+}
+
+// -------------------------
+// Parse
+// -------------------------
+
+// BenchmarkParse parses values on their own and, as is usual when decoding a
+// frame, followed by more input
+func BenchmarkParse(b *testing.B) {
+	for _, v := range testValues {
+		exact := Append(nil, v)
+		inBuf := append(Append(nil, v), make([]byte, MaxLen)...)
+		for _, c := range []struct {
+			name string
+			buf  []byte
+		}{{"exact", exact}, {"inbuf", inBuf}} {
+			buf := c.buf
+			b.Run(c.name+"/v="+itoa(v), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					var err error
+					sinkU64, _, err = Parse(buf)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// -------------------------
+// AppendOf / ParseOf
+// -------------------------
+
+func BenchmarkAppendOf(b *testing.B) {
+	dst := make([]byte, 0, 8)
+	b.Run("AppendOf[uint32]", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			dst = AppendOf(dst[:0], uint32(i))
+		}
+	})
+	b.Run("Append", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			dst = Append(dst[:0], uint64(uint32(i)))
+		}
+	})
+	sinkInt = len(dst)
+}
+
+func BenchmarkParseOf(b *testing.B) {
+	buf := Append(nil, 1073741823)
+	b.Run("ParseOf[uint32]", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			v, _, err := ParseOf[uint32](buf)
+			if err != nil {
+				b.Fatal(err)
+			}
+			sinkU64 = uint64(v)
+		}
+	})
+	b.Run("ParseUint32", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			v, _, err := ParseUint32(buf)
+			if err != nil {
+				b.Fatal(err)
+			}
+			sinkU64 = uint64(v)
+		}
+	})
+}
+
 // -------------------------
 // Peek
 // -------------------------
@@ -128,6 +417,112 @@ func BenchmarkRead(b *testing.B) {
 	}
 }
 
+// -------------------------
+// ReadLen (io.ByteReader)
+// -------------------------
+
+func BenchmarkReadLen(b *testing.B) {
+	for _, v := range testValues {
+		multiData := bytes.Repeat(Append(nil, v), 100)
+		br := bytes.NewReader(multiData)
+
+		b.Run("v="+itoa(v), func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if br.Len() < 8 {
+					br.Reset(multiData)
+				}
+
+				var err error
+				sinkU64, sinkInt, err = ReadLen(br)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// -------------------------
+// ReadFrom (io.Reader)
+// -------------------------
+
+// BenchmarkReadFrom decodes a single value from an unbuffered reader, the
+// alternative being to wrap the reader in bufio just to call Read
+func BenchmarkReadFrom(b *testing.B) {
+	for _, v := range testValues {
+		data := Append(nil, v)
+		br := bytes.NewReader(data)
+		r := readerOnly{br}
+
+		b.Run("ReadFrom/v="+itoa(v), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				br.Reset(data)
+				var err error
+				sinkU64, sinkInt, err = ReadFrom(r)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run("bufio+Read/v="+itoa(v), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				br.Reset(data)
+				var err error
+				sinkU64, err = Read(bufio.NewReader(r))
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkReaderStream decodes an endless stream of same-width values
+// through Reader, through ReadFrom over a bufio.Reader and through Read over a
+// bufio.Reader
+func BenchmarkReaderStream(b *testing.B) {
+	for _, v := range []uint64{63, 16383, 1073741823, Max} {
+		chunk := bytes.Repeat(Append(nil, v), 512)
+		bench := func(name string, decode func(src io.Reader) func() error) {
+			b.Run(name+"/v="+itoa(v), func(b *testing.B) {
+				next := decode(&endlessReader{chunk: chunk})
+				b.ReportAllocs()
+				b.SetBytes(int64(Len(v)))
+				for i := 0; i < b.N; i++ {
+					if err := next(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+		bench("Reader", func(src io.Reader) func() error {
+			rd := NewReader(src, 0)
+			return func() (err error) {
+				sinkU64, err = rd.Uvarint()
+				return err
+			}
+		})
+		bench("bufio+ReadFrom", func(src io.Reader) func() error {
+			br := bufio.NewReader(src)
+			return func() (err error) {
+				sinkU64, sinkInt, err = ReadFrom(br)
+				return err
+			}
+		})
+		bench("bufio+Read", func(src io.Reader) func() error {
+			br := bufio.NewReader(src)
+			return func() (err error) {
+				sinkU64, err = Read(br)
+				return err
+			}
+		})
+	}
+}
+
 // -------------------------
 // Write (io.Writer)
 // -------------------------
@@ -136,6 +531,25 @@ type discardByteWriter struct{}
 
 func (discardByteWriter) WriteByte(c byte) error { return nil }
 
+// countingWriter records writes without being a bytes.Buffer, so the
+// per-call overhead of Write vs WriteTo is visible
+type countingWriter struct {
+	buf   []byte
+	calls int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.calls++
+	w.buf = append(w.buf, p...)
+	return len(p), nil
+}
+
+func (w *countingWriter) WriteByte(c byte) error {
+	w.calls++
+	w.buf = append(w.buf, c)
+	return nil
+}
+
 func BenchmarkWrite(b *testing.B) {
 	var buf bytes.Buffer
 	for _, v := range testValues {
@@ -154,6 +568,217 @@ func BenchmarkWrite(b *testing.B) {
 	}
 }
 
+func BenchmarkWriteVsWriteTo(b *testing.B) {
+	w := &countingWriter{buf: make([]byte, 0, 8)}
+	for _, v := range testValues {
+		b.Run("Write/v="+itoa(v), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				w.buf = w.buf[:0]
+				if err := Write(w, v); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run("WriteTo/v="+itoa(v), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				w.buf = w.buf[:0]
+				var err error
+				sinkInt, err = WriteTo(w, v)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// -------------------------
+// Encoder
+// -------------------------
+
+func BenchmarkEncoderPool(b *testing.B) {
+	payload := []byte("0123456789abcdef")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		e := GetEncoder()
+		out, err := e.Uint64(7).Uint64(uint64(i)).Int64(-42).Bytes(payload).String("name").Finish()
+		if err != nil {
+			b.Fatal(err)
+		}
+		sinkInt = len(out)
+		PutEncoder(e)
+	}
+}
+
+// BenchmarkEncoderArena encodes the BenchmarkEncoderPool frame into a
+// freshly grown buffer and into an Arena reset every 256 frames
+func BenchmarkEncoderArena(b *testing.B) {
+	payload := []byte("0123456789abcdef")
+	b.Run("NewEncoder", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			out, err := NewEncoder(nil).Uint64(7).Uint64(uint64(i)).Int64(-42).Bytes(payload).String("name").Finish()
+			if err != nil {
+				b.Fatal(err)
+			}
+			sinkInt = len(out)
+		}
+	})
+	b.Run("Arena", func(b *testing.B) {
+		a := NewArena(0)
+		var e Encoder
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if i%256 == 0 {
+				a.Reset()
+			}
+			e.ResetArena(a, 32)
+			out, err := e.Uint64(7).Uint64(uint64(i)).Int64(-42).Bytes(payload).String("name").Finish()
+			if err != nil {
+				b.Fatal(err)
+			}
+			sinkInt = len(out)
+		}
+	})
+}
+
+// -------------------------
+// Writer (os.Pipe)
+// -------------------------
+
+// BenchmarkWriterPipe writes ten-field frames to a pipe, comparing the
+// per-byte Write path with a buffered Writer and with Write through a
+// BufferedByteWriter, both flushed once per frame
+func BenchmarkWriterPipe(b *testing.B) {
+	fields := append(slices.Clone(testValues), 1, 2)
+	run := func(b *testing.B, frame func(w *os.File) error) {
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			b.Fatal(err)
+		}
+		done := make(chan struct{})
+		go func() {
+			io.Copy(io.Discard, pr)
+			close(done)
+		}()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := frame(pw); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.StopTimer()
+		pw.Close()
+		<-done
+		pr.Close()
+	}
+	b.Run("Write", func(b *testing.B) {
+		run(b, func(f *os.File) error {
+			w := NewCountingWriter(f)
+			for _, v := range fields {
+				if err := Write(w, v); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	b.Run("Writer", func(b *testing.B) {
+		var w *Writer
+		run(b, func(f *os.File) error {
+			if w == nil {
+				w = NewWriter(f, 0)
+			}
+			for _, v := range fields {
+				if err := w.Uvarint(v); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		})
+	})
+	b.Run("BufferedByteWriter", func(b *testing.B) {
+		var w *BufferedByteWriter
+		run(b, func(f *os.File) error {
+			if w == nil {
+				w = NewBufferedByteWriter(f, 0)
+			}
+			for _, v := range fields {
+				if err := Write(w, v); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		})
+	})
+}
+
+// -------------------------
+// WriteBatch
+// -------------------------
+
+// pipeWriter counts Write calls on the write end of an os.Pipe whose read end
+// is drained in the background, so each call is one write syscall
+type pipeWriter struct {
+	f     *os.File
+	calls int
+}
+
+func (w *pipeWriter) Write(p []byte) (int, error) {
+	w.calls++
+	return w.f.Write(p)
+}
+
+// BenchmarkWriteBatch writes 10000 values to a pipe, comparing a WriteTo per
+// value with a single WriteBatch
+func BenchmarkWriteBatch(b *testing.B) {
+	vs := skewedValues(10000)
+	run := func(b *testing.B, write func(w io.Writer) error) {
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			b.Fatal(err)
+		}
+		done := make(chan struct{})
+		go func() {
+			io.Copy(io.Discard, pr)
+			close(done)
+		}()
+		w := &pipeWriter{f: pw}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := write(w); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.StopTimer()
+		b.ReportMetric(float64(w.calls)/float64(b.N), "writes/op")
+		pw.Close()
+		<-done
+		pr.Close()
+	}
+	b.Run("WriteTo", func(b *testing.B) {
+		run(b, func(w io.Writer) error {
+			for _, v := range vs {
+				if _, err := WriteTo(w, v); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	b.Run("WriteBatch", func(b *testing.B) {
+		scratch := make([]byte, 0, 32<<10)
+		run(b, func(w io.Writer) error {
+			_, err := WriteBatch(w, vs, scratch)
+			return err
+		})
+	})
+}
+
 // -------------------------
 // Helpers
 // -------------------------
@@ -180,3 +805,212 @@ func itoa(v uint64) string {
 		return "x"
 	}
 }
+
+// -------------------------
+// ReadMessage vs ReadMessageZeroCopy
+// -------------------------
+
+func BenchmarkReadMessageZeroCopy(b *testing.B) {
+	for _, size := range []int{64, 256, 512} {
+		var stream bytes.Buffer
+		for stream.Len() < 1<<20 {
+			WriteMessage(&stream, make([]byte, size))
+		}
+		data := stream.Bytes()
+		r := bytes.NewReader(data)
+		br := bufio.NewReaderSize(r, 64<<10)
+		run := func(b *testing.B, read func() ([]byte, error)) {
+			b.ReportAllocs()
+			b.SetBytes(int64(size))
+			for i := 0; i < b.N; i++ {
+				p, err := read()
+				if err == io.EOF {
+					r.Reset(data)
+					br.Reset(r)
+					p, err = read()
+				}
+				if err != nil {
+					b.Fatal(err)
+				}
+				sinkInt = len(p)
+			}
+		}
+		buf := make([]byte, 0, size)
+		b.Run("size="+strconv.Itoa(size)+"/copy", func(b *testing.B) {
+			r.Reset(data)
+			br.Reset(r)
+			run(b, func() ([]byte, error) { return ReadMessage(br, 1<<20, buf) })
+		})
+		b.Run("size="+strconv.Itoa(size)+"/zerocopy", func(b *testing.B) {
+			r.Reset(data)
+			br.Reset(r)
+			run(b, func() ([]byte, error) { return ReadMessageZeroCopy(br, 1<<20) })
+		})
+	}
+}
+
+// -------------------------
+// DecodeAll
+// -------------------------
+
+// BenchmarkDecodeAll decodes 1M values, either skewed towards 1-byte values,
+// in long runs of 1-byte values, cycling through every width, or nearly all
+// 8 bytes wide
+func BenchmarkDecodeAll(b *testing.B) {
+	cycled := make([]uint64, 0, 1<<20)
+	for len(cycled) < cap(cycled) {
+		cycled = append(cycled, testValues...)
+	}
+	dst := make([]uint64, 0, 1<<20)
+	for _, c := range []struct {
+		name string
+		vs   []uint64
+	}{
+		{"skewed", skewedValues(1 << 20)},
+		{"runs", bulkCorpus(1 << 20)},
+		{"cycled", cycled},
+		{"uniform", uniformValues(1 << 20)},
+	} {
+		buf := AppendMany(nil, c.vs...)
+		b.Run(c.name+"/DecodeAll", func(b *testing.B) {
+			b.SetBytes(int64(len(buf)))
+			for i := 0; i < b.N; i++ {
+				dst, _ = DecodeAll(dst[:0], buf)
+			}
+		})
+		b.Run(c.name+"/DecodeAllScalar", func(b *testing.B) {
+			b.SetBytes(int64(len(buf)))
+			withoutBulk(func() {
+				for i := 0; i < b.N; i++ {
+					dst, _ = DecodeAll(dst[:0], buf)
+				}
+			})
+		})
+		b.Run(c.name+"/ParseLoop", func(b *testing.B) {
+			b.SetBytes(int64(len(buf)))
+			for i := 0; i < b.N; i++ {
+				dst = dst[:0]
+				for off := 0; off < len(buf); {
+					v, n, err := Parse(buf[off:])
+					if err != nil {
+						b.Fatal(err)
+					}
+					dst = append(dst, v)
+					off += n
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkDecodeAllParallel decodes a column of 16M values, about 20 MiB,
+// with increasing worker counts; the serial DecodeAll is the baseline.
+// Scaling needs as many CPUs as workers
+func BenchmarkDecodeAllParallel(b *testing.B) {
+	buf := AppendMany(nil, skewedValues(1<<24)...)
+	b.Run("DecodeAll", func(b *testing.B) {
+		b.SetBytes(int64(len(buf)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			vs, _ := DecodeAll(nil, buf)
+			sinkInt = len(vs)
+		}
+	})
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run("workers="+strconv.Itoa(workers), func(b *testing.B) {
+			b.SetBytes(int64(len(buf)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				vs, _ := DecodeAllParallel(buf, workers)
+				sinkInt = len(vs)
+			}
+		})
+	}
+}
+
+// -------------------------
+// AppendRuns / ParseRuns
+// -------------------------
+
+// zeroHeavyValues returns n telemetry-like values: runs of zeros of random
+// length broken up by short stretches of skewed values
+func zeroHeavyValues(n int) []uint64 {
+	rng := rand.New(rand.NewPCG(4, 4))
+	other := skewedValues(n)
+	vs := make([]uint64, 0, n)
+	for len(vs) < n {
+		for run := rng.IntN(100); run > 0; run-- {
+			vs = append(vs, 0)
+		}
+		for k := rng.IntN(5); k >= 0; k-- {
+			vs = append(vs, other[len(vs)%n])
+		}
+	}
+	return vs[:n]
+}
+
+// BenchmarkRuns compares AppendRuns with plain AppendMany on a zero-heavy
+// corpus; the bytes/value metric is the encoded size
+func BenchmarkRuns(b *testing.B) {
+	vs := zeroHeavyValues(1 << 16)
+	var dst []byte
+	for _, c := range []struct {
+		name   string
+		encode func([]byte, []uint64) []byte
+	}{
+		{"AppendMany", func(dst []byte, vs []uint64) []byte { return AppendMany(dst, vs...) }},
+		{"AppendRuns", AppendRuns},
+	} {
+		b.Run(c.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				dst = c.encode(dst[:0], vs)
+			}
+			b.ReportMetric(float64(len(dst))/float64(len(vs)), "bytes/value")
+		})
+	}
+	buf := AppendRuns(nil, vs)
+	b.Run("ParseRuns", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			got, err := ParseRuns(buf, uint64(len(vs)))
+			if err != nil {
+				b.Fatal(err)
+			}
+			sinkInt = len(got)
+		}
+	})
+}
+
+// -------------------------
+// EncodeAll
+// -------------------------
+
+func BenchmarkEncodeAll(b *testing.B) {
+	vs := skewedValues(1 << 16)
+	size := len(AppendMany(nil, vs...))
+	dst := make([]byte, 0, size)
+	b.Run("EncodeAll", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(size))
+		for i := 0; i < b.N; i++ {
+			dst, _ = EncodeAll(dst[:0], vs)
+		}
+	})
+	b.Run("AppendMany", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(size))
+		for i := 0; i < b.N; i++ {
+			dst = AppendMany(dst[:0], vs...)
+		}
+	})
+	b.Run("AppendLoop", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(size))
+		for i := 0; i < b.N; i++ {
+			dst = dst[:0]
+			for _, v := range vs {
+				dst = Append(dst, v)
+			}
+		}
+	})
+}
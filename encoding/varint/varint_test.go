@@ -0,0 +1,613 @@
+package varint
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"testing/iotest"
+
+	"flux/encoding/varint/internal/spec"
+)
+
+// -------------------------
+// Put
+// -------------------------
+
+func TestPut(t *testing.T) {
+	cases := []struct {
+		v    uint64
+		want []byte
+	}{
+		{37, []byte{0x25}},
+		{15293, []byte{0x7b, 0xbd}},
+		{494878333, []byte{0x9d, 0x7f, 0x3e, 0x7d}},
+		{151288809941952652, []byte{0xc2, 0x19, 0x7c, 0x5e, 0xff, 0x14, 0xe8, 0x8c}},
+	}
+	for _, c := range cases {
+		dst := make([]byte, 8)
+		n := Put(dst, c.v)
+		if n != len(c.want) {
+			t.Fatalf("Put(%d) = %d bytes, want %d", c.v, n, len(c.want))
+		}
+		if !bytes.Equal(dst[:n], c.want) {
+			t.Fatalf("Put(%d) = %x, want %x", c.v, dst[:n], c.want)
+		}
+	}
+}
+
+func TestPutMatchesAppend(t *testing.T) {
+	for _, v := range testValues {
+		dst := make([]byte, Len(v))
+		n := Put(dst, v)
+		if n != len(dst) {
+			t.Fatalf("Put(%d) = %d bytes, want %d", v, n, len(dst))
+		}
+		if want := Append(nil, v); !bytes.Equal(dst, want) {
+			t.Fatalf("Put(%d) = %x, want %x", v, dst, want)
+		}
+	}
+}
+
+func TestPutShortBuffer(t *testing.T) {
+	for _, v := range testValues {
+		dst := make([]byte, Len(v)-1)
+		func() {
+			defer func() {
+				if r := recover(); r != io.ErrShortBuffer {
+					t.Fatalf("Put(%d) into %d bytes: recovered %v, want %v", v, len(dst), r, io.ErrShortBuffer)
+				}
+			}()
+			Put(dst, v)
+		}()
+		for _, b := range dst {
+			if b != 0 {
+				t.Fatalf("Put(%d) wrote into a short buffer: %x", v, dst)
+			}
+		}
+	}
+}
+
+// -------------------------
+// Len
+// -------------------------
+
+func TestLenBoundaries(t *testing.T) {
+	for shift := 0; shift <= 62; shift++ {
+		for _, d := range []int64{-1, 0, 1} {
+			v := uint64(int64(1)<<shift + d)
+			if v > Max {
+				continue
+			}
+			if want := spec.Length(v); Len(v) != want {
+				t.Fatalf("Len(%d) = %d, want %d", v, Len(v), want)
+			}
+		}
+	}
+	for _, v := range []uint64{Max + 1, 1 << 63, math.MaxUint64} {
+		func() {
+			defer func() {
+				if !errors.Is(recover().(error), ErrValueTooLarge) {
+					t.Fatalf("Len(%d) did not panic with ErrValueTooLarge", v)
+				}
+			}()
+			Len(v)
+		}()
+	}
+}
+
+// -------------------------
+// LenChecked / AppendChecked
+// -------------------------
+
+func TestLenChecked(t *testing.T) {
+	n, err := LenChecked(Max)
+	if err != nil || n != 8 {
+		t.Fatalf("LenChecked(Max) = %d, %v; want 8, nil", n, err)
+	}
+	for _, v := range []uint64{Max + 1, math.MaxUint64} {
+		n, err := LenChecked(v)
+		if !errors.Is(err, ErrValueTooLarge) {
+			t.Fatalf("LenChecked(%d) error = %v, want ErrValueTooLarge", v, err)
+		}
+		if n != 0 {
+			t.Fatalf("LenChecked(%d) = %d, want 0", v, n)
+		}
+	}
+}
+
+func TestAppendChecked(t *testing.T) {
+	prefix := []byte{0xAA}
+	got, err := AppendChecked(prefix, Max)
+	if err != nil {
+		t.Fatalf("AppendChecked(Max) error = %v", err)
+	}
+	if want := Append([]byte{0xAA}, Max); !bytes.Equal(got, want) {
+		t.Fatalf("AppendChecked(Max) = %x, want %x", got, want)
+	}
+	for _, v := range []uint64{Max + 1, math.MaxUint64} {
+		got, err := AppendChecked(prefix, v)
+		if !errors.Is(err, ErrValueTooLarge) {
+			t.Fatalf("AppendChecked(%d) error = %v, want ErrValueTooLarge", v, err)
+		}
+		if !bytes.Equal(got, prefix) {
+			t.Fatalf("AppendChecked(%d) modified dst: %x", v, got)
+		}
+	}
+}
+
+// -------------------------
+// PeekLen
+// -------------------------
+
+func TestPeekLen(t *testing.T) {
+	inputs := [][]byte{
+		{0x25},
+		{0x40, 0x25},
+		{0x9d, 0x7f, 0x3e, 0x7d},
+		{0xc2, 0x19, 0x7c, 0x5e, 0xff, 0x14, 0xe8, 0x8c},
+		{0x25, 0xff, 0xff}, // trailing bytes are not part of the varint
+		{},
+		{0x40},
+		{0x9d, 0x7f, 0x3e},
+		{0xc2, 0x19, 0x7c, 0x5e, 0xff, 0x14, 0xe8},
+	}
+	for _, in := range inputs {
+		v, n, err := PeekLen(in)
+		wv, wn, werr := Parse(in)
+		if v != wv || n != wn || err != werr {
+			t.Fatalf("PeekLen(%x) = %d, %d, %v; Parse = %d, %d, %v", in, v, n, err, wv, wn, werr)
+		}
+	}
+}
+
+// -------------------------
+// Read
+// -------------------------
+
+func TestReadTruncated(t *testing.T) {
+	if _, err := Read(bytes.NewReader(nil)); err != io.EOF {
+		t.Fatalf("Read(empty) error = %v, want io.EOF", err)
+	}
+	for _, v := range []uint64{16383, 1073741823, Max} {
+		enc := Append(nil, v)
+		for _, cut := range []int{1, 2, 3, 7} {
+			if cut >= len(enc) {
+				continue
+			}
+			if _, err := Read(bytes.NewReader(enc[:cut])); err != io.ErrUnexpectedEOF {
+				t.Fatalf("Read(%d of %d bytes) error = %v, want io.ErrUnexpectedEOF", cut, len(enc), err)
+			}
+		}
+	}
+}
+
+func TestReadPassesOtherErrors(t *testing.T) {
+	enc := Append(nil, Max)
+	r := iotest.TimeoutReader(iotest.OneByteReader(bytes.NewReader(enc)))
+	if _, err := Read(bufio.NewReaderSize(r, 16)); err != iotest.ErrTimeout {
+		t.Fatalf("Read error = %v, want iotest.ErrTimeout", err)
+	}
+}
+
+// -------------------------
+// ReadLen
+// -------------------------
+
+func TestReadLen(t *testing.T) {
+	for _, v := range testValues {
+		enc := Append(nil, v)
+		got, n, err := ReadLen(bytes.NewReader(enc))
+		if err != nil || got != v || n != len(enc) {
+			t.Fatalf("ReadLen(%x) = %d, %d, %v; want %d, %d, nil", enc, got, n, err, v, len(enc))
+		}
+	}
+}
+
+func TestReadLenTruncated(t *testing.T) {
+	if _, n, err := ReadLen(bytes.NewReader(nil)); err != io.EOF || n != 0 {
+		t.Fatalf("ReadLen(empty) = _, %d, %v; want 0, io.EOF", n, err)
+	}
+	for _, v := range testValues {
+		enc := Append(nil, v)
+		for cut := 1; cut < len(enc); cut++ {
+			r := bytes.NewReader(enc[:cut])
+			_, n, err := ReadLen(r)
+			if err != io.ErrUnexpectedEOF {
+				t.Fatalf("ReadLen(%x) error = %v, want io.ErrUnexpectedEOF", enc[:cut], err)
+			}
+			if n != cut {
+				t.Fatalf("ReadLen(%x) consumed %d, want %d", enc[:cut], n, cut)
+			}
+		}
+	}
+}
+
+// -------------------------
+// ReadFrom
+// -------------------------
+
+// readerOnly hides any io.ByteReader implementation of the wrapped reader
+type readerOnly struct {
+	r io.Reader
+}
+
+func (r readerOnly) Read(p []byte) (int, error) { return r.r.Read(p) }
+
+func TestReadFrom(t *testing.T) {
+	var stream []byte
+	for _, v := range testValues {
+		stream = Append(stream, v)
+	}
+	// One byte per Read call exercises io.ReadFull's retry loop
+	r := readerOnly{iotest.OneByteReader(bytes.NewReader(stream))}
+	for _, v := range testValues {
+		got, n, err := ReadFrom(r)
+		if err != nil || got != v || n != Len(v) {
+			t.Fatalf("ReadFrom = %d, %d, %v; want %d, %d, nil", got, n, err, v, Len(v))
+		}
+	}
+	if _, n, err := ReadFrom(r); err != io.EOF || n != 0 {
+		t.Fatalf("ReadFrom at end = _, %d, %v; want 0, io.EOF", n, err)
+	}
+}
+
+func TestReadFromTruncated(t *testing.T) {
+	for _, v := range []uint64{16383, 1073741823, Max} {
+		enc := Append(nil, v)
+		for cut := 1; cut < len(enc); cut++ {
+			_, n, err := ReadFrom(readerOnly{bytes.NewReader(enc[:cut])})
+			if err != io.ErrUnexpectedEOF {
+				t.Fatalf("ReadFrom(%x) error = %v, want io.ErrUnexpectedEOF", enc[:cut], err)
+			}
+			if n != cut {
+				t.Fatalf("ReadFrom(%x) consumed %d, want %d", enc[:cut], n, cut)
+			}
+		}
+	}
+}
+
+// -------------------------
+// Write / WriteTo
+// -------------------------
+
+func TestWriteAndWriteTo(t *testing.T) {
+	for _, v := range testValues {
+		want := Append(nil, v)
+
+		var bw bytes.Buffer
+		if err := Write(&bw, v); err != nil {
+			t.Fatalf("Write(%d) error = %v", v, err)
+		}
+		if !bytes.Equal(bw.Bytes(), want) {
+			t.Fatalf("Write(%d) = %x, want %x", v, bw.Bytes(), want)
+		}
+
+		cw := &countingWriter{}
+		n, err := WriteTo(cw, v)
+		if err != nil || n != len(want) {
+			t.Fatalf("WriteTo(%d) = %d, %v; want %d, nil", v, n, err, len(want))
+		}
+		if cw.calls != 1 {
+			t.Fatalf("WriteTo(%d) issued %d writes, want 1", v, cw.calls)
+		}
+		if !bytes.Equal(cw.buf, want) {
+			t.Fatalf("WriteTo(%d) = %x, want %x", v, cw.buf, want)
+		}
+	}
+	if _, err := WriteTo(&countingWriter{}, Max+1); !errors.Is(err, ErrValueTooLarge) {
+		t.Fatalf("WriteTo(Max+1) error = %v, want ErrValueTooLarge", err)
+	}
+}
+
+func TestWriteNPartial(t *testing.T) {
+	errBroken := errors.New("broken")
+	want := Append(nil, Max)
+	for k := 1; k <= 8; k++ {
+		// Fail on the k-th WriteByte call
+		w := &failingByteWriter{limit: k - 1, err: errBroken}
+		n, err := WriteN(w, Max)
+		if err != errBroken || n != k-1 {
+			t.Fatalf("k=%d: WriteN = %d, %v; want %d, %v", k, n, err, k-1, errBroken)
+		}
+		if !bytes.Equal(w.buf, want[:n]) {
+			t.Fatalf("k=%d: wrote %x, want %x", k, w.buf, want[:n])
+		}
+	}
+	if n, err := WriteN(&failingByteWriter{limit: 8}, Max); err != nil || n != 8 {
+		t.Fatalf("WriteN(Max) = %d, %v; want 8, nil", n, err)
+	}
+	if n, err := WriteN(&failingByteWriter{limit: 8}, Max+1); !errors.Is(err, ErrValueTooLarge) || n != 0 {
+		t.Fatalf("WriteN(Max+1) = %d, %v; want 0, ErrValueTooLarge", n, err)
+	}
+}
+
+// -------------------------
+// ParseExact
+// -------------------------
+
+func TestParseExact(t *testing.T) {
+	for _, v := range testValues {
+		enc := Append(nil, v)
+		if got, err := ParseExact(enc); err != nil || got != v {
+			t.Fatalf("ParseExact(%x) = %d, %v; want %d, nil", enc, got, err, v)
+		}
+		if _, err := ParseExact(append(enc, 0)); err != ErrTrailingBytes {
+			t.Fatalf("ParseExact(%x00) error = %v, want ErrTrailingBytes", enc, err)
+		}
+		if len(enc) > 1 {
+			if _, err := ParseExact(enc[:len(enc)-1]); err != io.ErrUnexpectedEOF {
+				t.Fatalf("ParseExact(%x) error = %v, want io.ErrUnexpectedEOF", enc[:len(enc)-1], err)
+			}
+		}
+	}
+	if _, err := ParseExact(nil); err != io.EOF {
+		t.Fatalf("ParseExact(nil) error = %v, want io.EOF", err)
+	}
+}
+
+// -------------------------
+// ParseAt
+// -------------------------
+
+func TestParseAt(t *testing.T) {
+	b := AppendMany(nil, testValues...)
+	off := 0
+	for _, want := range testValues {
+		v, next, err := ParseAt(b, off)
+		if err != nil || v != want || next != off+Len(want) {
+			t.Fatalf("ParseAt(b, %d) = %d, %d, %v; want %d, %d, nil", off, v, next, err, want, off+Len(want))
+		}
+		off = next
+	}
+}
+
+func TestParseAtErrors(t *testing.T) {
+	b := AppendMany(nil, 5, Max)
+	cases := []struct {
+		buf  []byte
+		off  int
+		want error
+	}{
+		{b, len(b), io.EOF},
+		{b, len(b) + 1, ErrInvalidOffset},
+		{b, -1, ErrInvalidOffset},
+		{b[:len(b)-1], 1, io.ErrUnexpectedEOF},
+	}
+	for _, c := range cases {
+		_, next, err := ParseAt(c.buf, c.off)
+		if !errors.Is(err, c.want) {
+			t.Fatalf("ParseAt(%x, %d) error = %v, want %v", c.buf, c.off, err, c.want)
+		}
+		var offErr *OffsetError
+		if !errors.As(err, &offErr) || offErr.Offset != c.off {
+			t.Fatalf("ParseAt(%x, %d) error = %v, want an *OffsetError at %d", c.buf, c.off, err, c.off)
+		}
+		if next != c.off {
+			t.Fatalf("ParseAt(%x, %d) next = %d, want %d", c.buf, c.off, next, c.off)
+		}
+	}
+}
+
+// -------------------------
+// ParseCanonical
+// -------------------------
+
+func TestParseCanonical(t *testing.T) {
+	for _, v := range testValues {
+		enc := Append(nil, v)
+		got, n, err := ParseCanonical(enc)
+		if err != nil || got != v || n != len(enc) {
+			t.Fatalf("ParseCanonical(%x) = %d, %d, %v; want %d, %d, nil", enc, got, n, err, v, len(enc))
+		}
+	}
+}
+
+func TestParseCanonicalRejects(t *testing.T) {
+	inputs := [][]byte{
+		{0x40, 0x00},             // 0 in 2 bytes
+		{0x80, 0x00, 0x00, 0x00}, // 0 in 4 bytes
+		{0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, // 0 in 8 bytes
+		{0x40, 0x3F},             // 63 in 2 bytes
+		{0x80, 0x00, 0x3F, 0xFF}, // 16383 in 4 bytes
+		{0xC0, 0x00, 0x00, 0x00, 0x3F, 0xFF, 0xFF, 0xFF}, // 2^30-1 in 8 bytes
+	}
+	for _, in := range inputs {
+		if _, _, err := Parse(in); err != nil {
+			t.Fatalf("Parse(%x) error = %v", in, err)
+		}
+		if _, _, err := ParseCanonical(in); err != ErrNonCanonical {
+			t.Fatalf("ParseCanonical(%x) error = %v, want ErrNonCanonical", in, err)
+		}
+	}
+	// The smallest value of each width is canonical
+	for _, in := range [][]byte{{0x40, 0x40}, {0x80, 0x00, 0x40, 0x00}, {0xC0, 0x00, 0x00, 0x00, 0x40, 0x00, 0x00, 0x00}} {
+		if _, _, err := ParseCanonical(in); err != nil {
+			t.Fatalf("ParseCanonical(%x) error = %v", in, err)
+		}
+	}
+}
+
+// -------------------------
+// AppendWithLen / PutWithLen
+// -------------------------
+
+func TestAppendWithLen(t *testing.T) {
+	cases := []struct {
+		v    uint64
+		n    int
+		want []byte
+	}{
+		{5, 1, []byte{0x05}},
+		{5, 2, []byte{0x40, 0x05}},
+		{5, 4, []byte{0x80, 0x00, 0x00, 0x05}},
+		{5, 8, []byte{0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05}},
+		{16383, 2, []byte{0x7F, 0xFF}},
+		{16383, 8, []byte{0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x3F, 0xFF}},
+		{Max, 8, Append(nil, Max)},
+	}
+	for _, c := range cases {
+		got, err := AppendWithLen(nil, c.v, c.n)
+		if err != nil || !bytes.Equal(got, c.want) {
+			t.Fatalf("AppendWithLen(%d, %d) = %x, %v; want %x, nil", c.v, c.n, got, err, c.want)
+		}
+		v, n, err := Parse(got)
+		if err != nil || v != c.v || n != c.n {
+			t.Fatalf("Parse(%x) = %d, %d, %v; want %d, %d, nil", got, v, n, err, c.v, c.n)
+		}
+		_, _, err = ParseCanonical(got)
+		if c.n == Len(c.v) && err != nil {
+			t.Fatalf("ParseCanonical(%x) error = %v, want nil", got, err)
+		}
+		if c.n != Len(c.v) && err != ErrNonCanonical {
+			t.Fatalf("ParseCanonical(%x) error = %v, want ErrNonCanonical", got, err)
+		}
+
+		dst := make([]byte, c.n)
+		if n, err := PutWithLen(dst, c.v, c.n); err != nil || n != c.n || !bytes.Equal(dst, c.want) {
+			t.Fatalf("PutWithLen(%d, %d) = %x, %d, %v", c.v, c.n, dst, n, err)
+		}
+	}
+}
+
+func TestAppendWithLenErrors(t *testing.T) {
+	cases := []struct {
+		v    uint64
+		n    int
+		want error
+	}{
+		{100, 1, ErrLengthTooShort},
+		{16384, 2, ErrLengthTooShort},
+		{1 << 30, 4, ErrLengthTooShort},
+		{Max + 1, 8, ErrLengthTooShort},
+		{1, 0, ErrInvalidLength},
+		{1, 3, ErrInvalidLength},
+		{1, 16, ErrInvalidLength},
+	}
+	for _, c := range cases {
+		got, err := AppendWithLen([]byte{0xAA}, c.v, c.n)
+		if err != c.want || !bytes.Equal(got, []byte{0xAA}) {
+			t.Fatalf("AppendWithLen(%d, %d) = %x, %v; want unchanged dst, %v", c.v, c.n, got, err, c.want)
+		}
+		if _, err := PutWithLen(make([]byte, 8), c.v, c.n); err != c.want {
+			t.Fatalf("PutWithLen(%d, %d) error = %v, want %v", c.v, c.n, err, c.want)
+		}
+	}
+	if _, err := PutWithLen(make([]byte, 3), 5, 4); err != io.ErrShortBuffer {
+		t.Fatalf("PutWithLen into a short buffer error = %v, want io.ErrShortBuffer", err)
+	}
+}
+
+// -------------------------
+// EncodedLen / PeekEncodedLen
+// -------------------------
+
+func TestEncodedLen(t *testing.T) {
+	for first := 0; first < 256; first++ {
+		want := []int{1, 2, 4, 8}[first>>6]
+		if got := EncodedLen(byte(first)); got != want {
+			t.Fatalf("EncodedLen(%#x) = %d, want %d", first, got, want)
+		}
+	}
+	for _, v := range testValues {
+		enc := Append(nil, v)
+		n, err := PeekEncodedLen(enc[:1])
+		if err != nil || n != len(enc) {
+			t.Fatalf("PeekEncodedLen(%x) = %d, %v; want %d, nil", enc[:1], n, err, len(enc))
+		}
+	}
+	if _, err := PeekEncodedLen(nil); err != io.EOF {
+		t.Fatalf("PeekEncodedLen(nil) error = %v, want io.EOF", err)
+	}
+}
+
+// -------------------------
+// Fits / Clamp / MustAppend
+// -------------------------
+
+func TestFitsAndClamp(t *testing.T) {
+	cases := []struct {
+		v       uint64
+		fits    bool
+		clamped uint64
+	}{
+		{0, true, 0},
+		{Max - 1, true, Max - 1},
+		{Max, true, Max},
+		{Max + 1, false, Max},
+		{math.MaxUint64, false, Max},
+	}
+	for _, c := range cases {
+		if got := Fits(c.v); got != c.fits {
+			t.Fatalf("Fits(%d) = %v, want %v", c.v, got, c.fits)
+		}
+		if got := Clamp(c.v); got != c.clamped {
+			t.Fatalf("Clamp(%d) = %d, want %d", c.v, got, c.clamped)
+		}
+	}
+}
+
+func TestMustAppend(t *testing.T) {
+	if got, want := MustAppend(nil, Max), Append(nil, Max); !bytes.Equal(got, want) {
+		t.Fatalf("MustAppend(Max) = %x, want %x", got, want)
+	}
+	defer func() {
+		if err, _ := recover().(error); !errors.Is(err, ErrValueTooLarge) {
+			t.Fatalf("MustAppend(Max+1) recovered %v, want ErrValueTooLarge", err)
+		}
+	}()
+	MustAppend(nil, Max+1)
+}
+
+// -------------------------
+// EncodeFixed
+// -------------------------
+
+func TestEncode(t *testing.T) {
+	for _, v := range testValues {
+		buf, n := Encode(v)
+		if want := Append(nil, v); !bytes.Equal(buf[:n], want) {
+			t.Fatalf("Encode(%d) = %x, want %x", v, buf[:n], want)
+		}
+		if !bytes.Equal(buf[n:], make([]byte, MaxLen-n)) {
+			t.Fatalf("Encode(%d) left %x past the encoding", v, buf[n:])
+		}
+	}
+	func() {
+		defer func() {
+			if _, ok := recover().(*ValueTooLargeError); !ok {
+				t.Fatal("Encode(Max+1) did not panic with *ValueTooLargeError")
+			}
+		}()
+		Encode(Max + 1)
+	}()
+	// Escape analysis must keep the array on the stack for every value width
+	var dst [MaxLen]byte
+	allocs := testing.AllocsPerRun(100, func() {
+		for _, v := range testValues {
+			buf, n := Encode(v)
+			sinkInt += n + int(buf[0]) + Put(dst[:], v)
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("Encode and Put allocated %v times per run", allocs)
+	}
+}
+
+func TestEncodeFixed(t *testing.T) {
+	for _, v := range testValues {
+		buf, n := EncodeFixed(v)
+		if want := Append(nil, v); !bytes.Equal(buf[:n], want) {
+			t.Fatalf("EncodeFixed(%d) = %x, want %x", v, buf[:n], want)
+		}
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		buf, n := EncodeFixed(Max)
+		sinkInt = n + int(buf[0])
+	})
+	if allocs != 0 {
+		t.Fatalf("EncodeFixed allocated %v times per run", allocs)
+	}
+}
@@ -0,0 +1,245 @@
+package varint
+
+import (
+	"io"
+
+	"flux/encoding/zigzag"
+)
+
+const (
+	defaultWriterSize = 4096
+	batchChunkSize    = 32 << 10
+)
+
+// Writer encodes varints into an internal buffer in front of an io.Writer, so
+// a frame of many small fields reaches the underlying writer in one Write.
+// Call Flush when done. The first error from the underlying writer is sticky:
+// every later call returns it
+type Writer struct {
+	wr  io.Writer
+	buf []byte
+	n   int64 // bytes accepted, buffered or not
+	err error
+}
+
+// NewWriter returns a Writer over w with a buffer of bufSize bytes. A
+// non-positive bufSize selects a default of 4 KiB; sizes below MaxLen are
+// raised to it
+func NewWriter(w io.Writer, bufSize int) *Writer {
+	if bufSize <= 0 {
+		bufSize = defaultWriterSize
+	}
+	return &Writer{wr: w, buf: make([]byte, 0, max(bufSize, MaxLen))}
+}
+
+// Uvarint writes v as a varint, returning a *ValueTooLargeError if v exceeds
+// Max
+func (w *Writer) Uvarint(v uint64) error {
+	if w.err != nil {
+		return w.err
+	}
+	if v > Max {
+		return &ValueTooLargeError{Num: v}
+	}
+	if cap(w.buf)-len(w.buf) < MaxLen {
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	n := len(w.buf)
+	w.buf = Append(w.buf, v)
+	w.n += int64(len(w.buf) - n)
+	return nil
+}
+
+// Varint writes v as a zigzag-encoded varint, returning a *SignedRangeError if
+// v is outside [MinSigned, MaxSigned]
+func (w *Writer) Varint(v int64) error {
+	if v < MinSigned || v > MaxSigned {
+		if w.err != nil {
+			return w.err
+		}
+		return &SignedRangeError{Num: v}
+	}
+	return w.Uvarint(zigzag.Encode(v))
+}
+
+// Bytes writes p prefixed with its length. Payloads larger than the buffer
+// bypass it
+func (w *Writer) Bytes(p []byte) error {
+	if err := w.Uvarint(uint64(len(p))); err != nil {
+		return err
+	}
+	return w.write(p)
+}
+
+// String writes s prefixed with its length
+func (w *Writer) String(s string) error {
+	if err := w.Uvarint(uint64(len(s))); err != nil {
+		return err
+	}
+	for len(s) > 0 {
+		if len(w.buf) == cap(w.buf) {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+		n := min(len(s), cap(w.buf)-len(w.buf))
+		w.buf = append(w.buf, s[:n]...)
+		w.n += int64(n)
+		s = s[n:]
+	}
+	return nil
+}
+
+func (w *Writer) write(p []byte) error {
+	if len(p) > cap(w.buf)-len(w.buf) {
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if len(p) >= cap(w.buf) {
+			n, err := w.wr.Write(p)
+			w.n += int64(n)
+			if err == nil && n < len(p) {
+				err = io.ErrShortWrite
+			}
+			w.err = err
+			return err
+		}
+	}
+	w.buf = append(w.buf, p...)
+	w.n += int64(len(p))
+	return nil
+}
+
+// Flush writes any buffered bytes to the underlying writer. Flushing an empty
+// buffer is a no-op, so Flush may be called any number of times
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.buf) == 0 {
+		return nil
+	}
+	n, err := w.wr.Write(w.buf)
+	if err == nil && n < len(w.buf) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		// Keep what was not written so Buffered reports it
+		w.buf = w.buf[:copy(w.buf, w.buf[n:])]
+		w.err = err
+		return err
+	}
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// Buffered returns the number of bytes not yet written to the underlying
+// writer
+func (w *Writer) Buffered() int {
+	return len(w.buf)
+}
+
+// BytesWritten returns the number of bytes written through the Writer,
+// including any that are still buffered
+func (w *Writer) BytesWritten() int64 {
+	return w.n
+}
+
+// BufferedByteWriter is an io.ByteWriter over an io.Writer that collects bytes
+// and writes them once threshold bytes are pending, or on Flush. Passing one
+// to Write or WriteN in place of a raw connection batches the per-byte calls
+// into large writes. The first error from the underlying writer is sticky:
+// every later call returns it
+type BufferedByteWriter struct {
+	wr        io.Writer
+	buf       []byte
+	threshold int
+	err       error
+}
+
+// NewBufferedByteWriter returns a BufferedByteWriter over w that writes once
+// threshold bytes are pending. A non-positive threshold selects a default of
+// 4 KiB
+func NewBufferedByteWriter(w io.Writer, threshold int) *BufferedByteWriter {
+	if threshold <= 0 {
+		threshold = defaultWriterSize
+	}
+	return &BufferedByteWriter{wr: w, buf: make([]byte, 0, threshold), threshold: threshold}
+}
+
+// WriteByte buffers c, writing the buffer out if it has reached the threshold
+func (w *BufferedByteWriter) WriteByte(c byte) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.buf = append(w.buf, c)
+	if len(w.buf) >= w.threshold {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush writes any pending bytes to the underlying writer. Flushing with
+// nothing pending is a no-op
+func (w *BufferedByteWriter) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.buf) == 0 {
+		return nil
+	}
+	n, err := w.wr.Write(w.buf)
+	if err == nil && n < len(w.buf) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		// Keep what was not written so Buffered reports it
+		w.buf = w.buf[:copy(w.buf, w.buf[n:])]
+		w.err = err
+		return err
+	}
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// Buffered returns the number of bytes not yet written to the underlying
+// writer
+func (w *BufferedByteWriter) Buffered() int {
+	return len(w.buf)
+}
+
+// WriteBatch encodes vs into scratch and writes them to w in chunks of up to
+// cap(scratch) bytes, so many values cost a handful of Write calls instead of
+// one each. If scratch has room for fewer than MaxLen bytes a 32 KiB buffer is
+// allocated instead. A value above Max is reported as a *ValueTooLargeError
+// before anything is written. WriteBatch returns the number of bytes written;
+// a short write is reported as io.ErrShortWrite
+func WriteBatch(w io.Writer, vs []uint64, scratch []byte) (int64, error) {
+	for _, v := range vs {
+		if v > Max {
+			return 0, &ValueTooLargeError{Num: v}
+		}
+	}
+	buf := scratch[:0]
+	if cap(buf) < MaxLen && len(vs) > 0 {
+		buf = make([]byte, 0, min(batchChunkSize, len(vs)*MaxLen))
+	}
+	var total int64
+	for i := 0; i < len(vs); {
+		buf = buf[:0]
+		for ; i < len(vs) && cap(buf)-len(buf) >= MaxLen; i++ {
+			buf = Append(buf, vs[i])
+		}
+		n, err := w.Write(buf)
+		total += int64(n)
+		if err == nil && n < len(buf) {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,223 @@
+package varint
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// -------------------------
+// Writer
+// -------------------------
+
+func TestWriter(t *testing.T) {
+	cw := &countingWriter{}
+	w := NewWriter(cw, 0)
+	for _, v := range testValues {
+		if err := w.Uvarint(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Varint(-12345); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Bytes([]byte("payload")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.String("name"); err != nil {
+		t.Fatal(err)
+	}
+	if cw.calls != 0 {
+		t.Fatalf("Writer wrote %d times before Flush", cw.calls)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("second Flush error = %v", err)
+	}
+	if cw.calls != 1 {
+		t.Fatalf("Writer issued %d writes for one frame, want 1", cw.calls)
+	}
+
+	var want []byte
+	want = AppendMany(want, testValues...)
+	want = AppendInt(want, -12345)
+	want = AppendBytes(want, []byte("payload"))
+	want = AppendString(want, "name")
+	if !bytes.Equal(cw.buf, want) {
+		t.Fatalf("Writer produced %x, want %x", cw.buf, want)
+	}
+	if w.BytesWritten() != int64(len(want)) {
+		t.Fatalf("BytesWritten = %d, want %d", w.BytesWritten(), len(want))
+	}
+}
+
+func TestWriterLargePayloads(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out, 16)
+	big := bytes.Repeat([]byte{1}, 100)
+	long := strings.Repeat("x", 50)
+	if err := w.Bytes(big); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.String(long); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Uvarint(Max); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := Append(AppendString(AppendBytes(nil, big), long), Max)
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Fatalf("Writer produced %x, want %x", out.Bytes(), want)
+	}
+}
+
+func TestWriterStickyError(t *testing.T) {
+	errBroken := errors.New("broken")
+	lw := &limitedWriter{limit: 2, err: errBroken}
+	w := NewWriter(lw, 0)
+	w.Uvarint(Max)
+	if err := w.Flush(); err != errBroken {
+		t.Fatalf("Flush error = %v, want %v", err, errBroken)
+	}
+	if w.Buffered() != 6 {
+		t.Fatalf("Buffered after short write = %d, want 6", w.Buffered())
+	}
+	for i, err := range []error{w.Uvarint(1), w.Varint(1), w.Bytes(nil), w.String(""), w.Flush()} {
+		if err != errBroken {
+			t.Fatalf("call %d after failure error = %v, want %v", i, err, errBroken)
+		}
+	}
+	if len(lw.buf) != 2 {
+		t.Fatalf("underlying writer received %d bytes, want 2", len(lw.buf))
+	}
+}
+
+func TestWriterRangeErrors(t *testing.T) {
+	w := NewWriter(&countingWriter{}, 0)
+	if err := w.Uvarint(Max + 1); !errors.Is(err, ErrValueTooLarge) {
+		t.Fatalf("Uvarint(Max+1) error = %v", err)
+	}
+	if err := w.Varint(MaxSigned + 1); !errors.Is(err, ErrValueTooLarge) {
+		t.Fatalf("Varint(MaxSigned+1) error = %v", err)
+	}
+	// Range errors are not sticky; the stream is still intact
+	if err := w.Uvarint(1); err != nil {
+		t.Fatalf("Uvarint after a range error = %v", err)
+	}
+}
+
+// -------------------------
+// BufferedByteWriter
+// -------------------------
+
+func TestBufferedByteWriter(t *testing.T) {
+	out := &countingWriter{}
+	w := NewBufferedByteWriter(out, 16)
+	for _, v := range testValues {
+		if err := Write(w, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := AppendMany(nil, testValues...)
+	if out.calls != len(want)/16 || w.Buffered() != len(want)%16 {
+		t.Fatalf("%d bytes through a 16-byte threshold made %d writes with %d buffered", len(want), out.calls, w.Buffered())
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.buf, want) || w.Buffered() != 0 {
+		t.Fatalf("BufferedByteWriter produced %x, want %x", out.buf, want)
+	}
+	if err := w.Flush(); err != nil || out.calls != len(want)/16+1 {
+		t.Fatalf("empty Flush = %v after %d writes", err, out.calls)
+	}
+}
+
+func TestBufferedByteWriterPartialFailure(t *testing.T) {
+	errBroken := errors.New("broken")
+	lw := &limitedWriter{limit: 6, err: errBroken}
+	w := NewBufferedByteWriter(lw, 4)
+	var err error
+	n := 0
+	for ; err == nil && n < 100; n++ {
+		err = w.WriteByte(byte(n))
+	}
+	// The second flush delivers 2 of its 4 bytes and fails
+	if err != errBroken || n != 8 {
+		t.Fatalf("WriteByte failed with %v after %d bytes, want %v after 8", err, n, errBroken)
+	}
+	if !bytes.Equal(lw.buf, []byte{0, 1, 2, 3, 4, 5}) || w.Buffered() != 2 {
+		t.Fatalf("underlying writer got %x with %d buffered", lw.buf, w.Buffered())
+	}
+	if err := w.WriteByte(9); err != errBroken {
+		t.Fatalf("WriteByte after failure = %v, want %v", err, errBroken)
+	}
+	if err := Write(w, 1); err != errBroken {
+		t.Fatalf("Write after failure = %v, want %v", err, errBroken)
+	}
+	if err := w.Flush(); err != errBroken || w.Buffered() != 2 {
+		t.Fatalf("Flush after failure = %v with %d buffered", err, w.Buffered())
+	}
+}
+
+// -------------------------
+// WriteBatch
+// -------------------------
+
+func TestWriteBatch(t *testing.T) {
+	vs := corpusValues(10000)
+	want := AppendMany(nil, vs...)
+	for _, size := range []int{0, MaxLen, 100, 1 << 20} {
+		w := &countingWriter{}
+		n, err := WriteBatch(w, vs, make([]byte, 0, size))
+		if err != nil || n != int64(len(want)) || !bytes.Equal(w.buf, want) {
+			t.Fatalf("scratch %d: WriteBatch = %d, %v; output matches: %v", size, n, err, bytes.Equal(w.buf, want))
+		}
+		chunk := size
+		if size < MaxLen {
+			chunk = batchChunkSize
+		}
+		// Every chunk but the last is filled to within MaxLen bytes
+		if max := len(want)/(chunk-MaxLen+1) + 1; w.calls > max {
+			t.Fatalf("scratch %d: WriteBatch made %d Write calls, want at most %d", size, w.calls, max)
+		}
+	}
+	w := &countingWriter{}
+	if n, err := WriteBatch(w, nil, nil); n != 0 || err != nil || w.calls != 0 {
+		t.Fatalf("WriteBatch(nil) = %d, %v with %d calls", n, err, w.calls)
+	}
+}
+
+func TestWriteBatchErrors(t *testing.T) {
+	w := &countingWriter{}
+	if n, err := WriteBatch(w, []uint64{1, Max + 1}, nil); n != 0 || !errors.Is(err, ErrValueTooLarge) || w.calls != 0 {
+		t.Fatalf("WriteBatch(Max+1) = %d, %v with %d calls", n, err, w.calls)
+	}
+
+	errBroken := errors.New("broken")
+	lw := &limitedWriter{limit: 20, err: errBroken}
+	n, err := WriteBatch(lw, corpusValues(100), make([]byte, 0, 16))
+	if n != 20 || err != errBroken {
+		t.Fatalf("WriteBatch over a failing writer = %d, %v; want 20, %v", n, err, errBroken)
+	}
+
+	short := &shortWriter{}
+	n, err = WriteBatch(short, []uint64{Max}, nil)
+	if n != 1 || err != io.ErrShortWrite {
+		t.Fatalf("WriteBatch over a short writer = %d, %v; want 1, io.ErrShortWrite", n, err)
+	}
+}
+
+// shortWriter accepts one byte per Write without reporting an error
+type shortWriter struct{}
+
+func (shortWriter) Write(p []byte) (int, error) {
+	return min(len(p), 1), nil
+}
@@ -0,0 +1,100 @@
+package wslen
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"flux/encoding/varint"
+)
+
+// A WebSocket frame header (RFC 6455 section 5.2) gives the payload length in
+// the low 7 bits of its second byte. Values up to 125 are stored there
+// directly; 126 announces a big-endian uint16 and 127 a big-endian uint64
+// whose most significant bit must be zero. The RFC requires the shortest
+// form. The high bit of the 7-bit field's byte is the MASK flag, which Append
+// leaves clear and the parsers ignore
+
+// MaxLen is the maximum encoded length of a payload length
+const MaxLen = 9
+
+// MaxLength is the largest payload length the format can carry
+const MaxLength = 1<<63 - 1
+
+// ErrNonCanonical is reported by ParseCanonical when a length uses a wider
+// form than it needs. It is varint.ErrNonCanonical itself
+var ErrNonCanonical = varint.ErrNonCanonical
+
+// ErrTopBit is reported when a 64-bit length has its most significant bit set
+var ErrTopBit = errors.New("wslen: 64-bit payload length has the top bit set")
+
+// Len returns the number of bytes needed to encode length
+func Len(length uint64) int {
+	switch {
+	case length < 126:
+		return 1
+	case length <= 0xffff:
+		return 3
+	default:
+		return 9
+	}
+}
+
+// Append encodes length in the shortest form and appends it to dst. It
+// panics if length exceeds MaxLength
+func Append(dst []byte, length uint64) []byte {
+	switch Len(length) {
+	case 1:
+		return append(dst, byte(length))
+	case 3:
+		return binary.BigEndian.AppendUint16(append(dst, 126), uint16(length))
+	}
+	if length > MaxLength {
+		panic(fmt.Sprintf("wslen: payload length %d exceeds 2^63-1", length))
+	}
+	return binary.BigEndian.AppendUint64(append(dst, 127), length)
+}
+
+// Parse reads a payload length from the start of b, ignoring the MASK bit of
+// b[0], and returns it with the number of bytes consumed. It returns io.EOF
+// for an empty b, io.ErrUnexpectedEOF if b ends before the length does and
+// ErrTopBit for a 64-bit length with its top bit set. Lengths in a wider form
+// than needed are accepted
+func Parse(b []byte) (length uint64, consumed int, err error) {
+	if len(b) == 0 {
+		return 0, 0, io.EOF
+	}
+	switch l := b[0] & 0x7f; l {
+	case 126:
+		if len(b) < 3 {
+			return 0, 0, io.ErrUnexpectedEOF
+		}
+		return uint64(binary.BigEndian.Uint16(b[1:])), 3, nil
+	case 127:
+		if len(b) < 9 {
+			return 0, 0, io.ErrUnexpectedEOF
+		}
+		length = binary.BigEndian.Uint64(b[1:])
+		if length > MaxLength {
+			return 0, 0, ErrTopBit
+		}
+		return length, 9, nil
+	default:
+		return uint64(l), 1, nil
+	}
+}
+
+// ParseCanonical is like Parse but rejects lengths in a wider form than
+// needed with ErrNonCanonical, as RFC 6455 requires of receivers that check.
+// Append always produces canonical encodings
+func ParseCanonical(b []byte) (uint64, int, error) {
+	length, n, err := Parse(b)
+	if err != nil {
+		return 0, 0, err
+	}
+	if Len(length) != n {
+		return 0, 0, ErrNonCanonical
+	}
+	return length, n, nil
+}
@@ -0,0 +1,83 @@
+package wslen
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"flux/encoding/varint"
+)
+
+// -------------------------
+// Append / Parse
+// -------------------------
+
+func TestVectors(t *testing.T) {
+	cases := []struct {
+		length uint64
+		want   []byte
+	}{
+		{0, []byte{0x00}},
+		{5, []byte{0x05}},
+		{125, []byte{0x7d}},
+		{126, []byte{0x7e, 0x00, 0x7e}},
+		{256, []byte{0x7e, 0x01, 0x00}},
+		{65535, []byte{0x7e, 0xff, 0xff}},
+		{65536, []byte{0x7f, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00}},
+		{MaxLength, []byte{0x7f, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+	}
+	for _, c := range cases {
+		if got := Append(nil, c.length); !bytes.Equal(got, c.want) {
+			t.Fatalf("Append(%d) = %x, want %x", c.length, got, c.want)
+		}
+		if Len(c.length) != len(c.want) {
+			t.Fatalf("Len(%d) = %d, want %d", c.length, Len(c.length), len(c.want))
+		}
+		if l, n, err := ParseCanonical(append(c.want, 0xaa)); l != c.length || n != len(c.want) || err != nil {
+			t.Fatalf("ParseCanonical(%x) = %d, %d, %v; want %d", c.want, l, n, err, c.length)
+		}
+		// The MASK bit shares the first byte and is not part of the length
+		masked := append([]byte{c.want[0] | 0x80}, c.want[1:]...)
+		if l, _, err := ParseCanonical(masked); l != c.length || err != nil {
+			t.Fatalf("ParseCanonical(%x) = %d, %v; want %d", masked, l, err, c.length)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		name      string
+		b         []byte
+		lenient   error
+		canonical error
+	}{
+		{"empty", nil, io.EOF, io.EOF},
+		{"truncated 16-bit", []byte{0x7e, 0x01}, io.ErrUnexpectedEOF, io.ErrUnexpectedEOF},
+		{"truncated 64-bit", []byte{0xff, 0, 0, 0, 0, 0, 0, 1}, io.ErrUnexpectedEOF, io.ErrUnexpectedEOF},
+		{"top bit", []byte{0x7f, 0x80, 0, 0, 0, 0, 0, 0, 0}, ErrTopBit, ErrTopBit},
+		{"16-bit under 126", []byte{0x7e, 0x00, 0x7d}, nil, ErrNonCanonical},
+		{"64-bit under 65536", []byte{0x7f, 0, 0, 0, 0, 0, 0, 0xff, 0xff}, nil, ErrNonCanonical},
+		{"64-bit under 126", []byte{0x7f, 0, 0, 0, 0, 0, 0, 0, 0x01}, nil, ErrNonCanonical},
+	}
+	for _, c := range cases {
+		if _, _, err := Parse(c.b); err != c.lenient {
+			t.Fatalf("%s: Parse error = %v, want %v", c.name, err, c.lenient)
+		}
+		if _, _, err := ParseCanonical(c.b); err != c.canonical {
+			t.Fatalf("%s: ParseCanonical error = %v, want %v", c.name, err, c.canonical)
+		}
+	}
+	if !errors.Is(ErrNonCanonical, varint.ErrNonCanonical) {
+		t.Fatal("ErrNonCanonical does not match varint.ErrNonCanonical")
+	}
+}
+
+func TestAppendTooLarge(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Append(2^63) did not panic")
+		}
+	}()
+	Append(nil, MaxLength+1)
+}
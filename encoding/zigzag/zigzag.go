@@ -0,0 +1,25 @@
+package zigzag
+
+// Zigzag encoding maps signed integers onto unsigned ones so that values of
+// small magnitude, positive or negative, map to small numbers:
+// 0 => 0, -1 => 1, 1 => 2, -2 => 3, 2 => 4 and so on
+
+// Encode maps v onto the unsigned integers
+func Encode(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+// Decode is the inverse of Encode
+func Decode(u uint64) int64 {
+	return int64(u>>1) ^ -int64(u&1)
+}
+
+// Encode32 maps v onto the unsigned 32-bit integers
+func Encode32(v int32) uint32 {
+	return uint32(v<<1) ^ uint32(v>>31)
+}
+
+// Decode32 is the inverse of Encode32
+func Decode32(u uint32) int32 {
+	return int32(u>>1) ^ -int32(u&1)
+}
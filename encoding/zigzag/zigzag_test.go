@@ -0,0 +1,101 @@
+package zigzag
+
+import (
+	"math"
+	"testing"
+)
+
+// -------------------------
+// Encode / Decode
+// -------------------------
+
+func TestEncode(t *testing.T) {
+	cases := []struct {
+		v int64
+		u uint64
+	}{
+		{0, 0},
+		{-1, 1},
+		{1, 2},
+		{-2, 3},
+		{2, 4},
+		{math.MaxInt32, math.MaxUint32 - 1},
+		{math.MinInt32, math.MaxUint32},
+		{math.MaxInt64, math.MaxUint64 - 1},
+		{math.MinInt64, math.MaxUint64},
+		{1<<61 - 1, 1<<62 - 2},
+		{-1 << 61, 1<<62 - 1},
+	}
+	for _, c := range cases {
+		if got := Encode(c.v); got != c.u {
+			t.Fatalf("Encode(%d) = %d, want %d", c.v, got, c.u)
+		}
+		if got := Decode(c.u); got != c.v {
+			t.Fatalf("Decode(%d) = %d, want %d", c.u, got, c.v)
+		}
+	}
+}
+
+func TestEncode32(t *testing.T) {
+	cases := []struct {
+		v int32
+		u uint32
+	}{
+		{0, 0},
+		{-1, 1},
+		{1, 2},
+		{-2, 3},
+		{math.MaxInt16, math.MaxUint16 - 1},
+		{math.MinInt16, math.MaxUint16},
+		{math.MaxInt32, math.MaxUint32 - 1},
+		{math.MinInt32, math.MaxUint32},
+	}
+	for _, c := range cases {
+		if got := Encode32(c.v); got != c.u {
+			t.Fatalf("Encode32(%d) = %d, want %d", c.v, got, c.u)
+		}
+		if got := Decode32(c.u); got != c.v {
+			t.Fatalf("Decode32(%d) = %d, want %d", c.u, got, c.v)
+		}
+	}
+}
+
+func TestSignBoundaries(t *testing.T) {
+	// Around every power of two the mapping must stay a bijection that
+	// interleaves signs
+	for shift := 0; shift < 63; shift++ {
+		for _, v := range []int64{1 << shift, 1<<shift - 1, -(1 << shift), -(1 << shift) + 1} {
+			u := Encode(v)
+			if Decode(u) != v {
+				t.Fatalf("Decode(Encode(%d)) = %d", v, Decode(u))
+			}
+			if want := uint64(v) << 1; v >= 0 && u != want {
+				t.Fatalf("Encode(%d) = %d, want %d", v, u, want)
+			}
+			if want := uint64(-(v+1))<<1 | 1; v < 0 && u != want {
+				t.Fatalf("Encode(%d) = %d, want %d", v, u, want)
+			}
+			if v >= math.MinInt32 && v <= math.MaxInt32 && uint64(Encode32(int32(v))) != u {
+				t.Fatalf("Encode32(%d) = %d, want %d", v, Encode32(int32(v)), u)
+			}
+		}
+	}
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	f.Add(int64(0))
+	f.Add(int64(-1))
+	f.Add(int64(math.MaxInt64))
+	f.Add(int64(math.MinInt64))
+	f.Fuzz(func(t *testing.T, v int64) {
+		if got := Decode(Encode(v)); got != v {
+			t.Fatalf("Decode(Encode(%d)) = %d", v, got)
+		}
+		if got := Encode(Decode(uint64(v))); got != uint64(v) {
+			t.Fatalf("Encode(Decode(%d)) = %d", uint64(v), got)
+		}
+		if got := Decode32(Encode32(int32(v))); got != int32(v) {
+			t.Fatalf("Decode32(Encode32(%d)) = %d", int32(v), got)
+		}
+	})
+}